@@ -18,17 +18,24 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/cmd/skaffold/app/flags"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
 )
 
 var (
 	quietFlag       bool
+	buildFileOutput string
 	buildFormatFlag = flags.NewTemplateFlag("{{range .Builds}}{{.ImageName}} -> {{.Tag}}\n{{end}}", BuildOutput{})
 )
 
@@ -43,8 +50,10 @@ func NewCmdBuild(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	AddCacheFlags(cmd)
 	cmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress the build output and print image built on success")
 	cmd.Flags().VarP(buildFormatFlag, "output", "o", buildFormatFlag.Usage())
+	cmd.Flags().StringVar(&buildFileOutput, "file-output", "", "Write the build results to this file, as json or as yaml if it ends with .yaml or .yml, for `skaffold deploy --build-artifacts`")
 	return cmd
 }
 
@@ -53,6 +62,105 @@ type BuildOutput struct {
 	Builds []build.Artifact
 }
 
+// buildOutputFile is the format of the file written by `skaffold build --file-output`.
+type buildOutputFile struct {
+	Builds []buildOutputFileArtifact `json:"builds" yaml:"builds"`
+}
+
+type buildOutputFileArtifact struct {
+	ImageName string `json:"imageName" yaml:"imageName"`
+	Tag       string `json:"tag" yaml:"tag"`
+}
+
+// readBuildOutput reads the build results written by `skaffold build --file-output`
+// or by `skaffold build -o '{{json .}}'`.
+func readBuildOutput(filename string) ([]build.Artifact, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", filename)
+	}
+
+	// json keys are matched case-insensitively so this also reads the template output.
+	var file buildOutputFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		if err := yaml.UnmarshalStrict(contents, &file); err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", filename)
+		}
+	}
+
+	var builds []build.Artifact
+	for _, b := range file.Builds {
+		builds = append(builds, build.Artifact{
+			ImageName: b.ImageName,
+			Tag:       b.Tag,
+		})
+	}
+
+	return builds, nil
+}
+
+// writeBuildOutput writes the build results to a file, as yaml if the
+// file has a yaml extension and as json otherwise.
+func writeBuildOutput(filename string, buildOutput BuildOutput) error {
+	var file buildOutputFile
+	for _, b := range buildOutput.Builds {
+		file.Builds = append(file.Builds, buildOutputFileArtifact{
+			ImageName: b.ImageName,
+			Tag:       b.Tag,
+		})
+	}
+
+	var contents []byte
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		contents, err = yaml.Marshal(file)
+	default:
+		contents, err = json.MarshalIndent(file, "", "  ")
+	}
+	if err != nil {
+		return errors.Wrap(err, "marshalling build output")
+	}
+
+	return ioutil.WriteFile(filename, contents, 0644)
+}
+
+// checkBuildOutput makes sure that there's a build result for each artifact of the configuration.
+func checkBuildOutput(artifacts []*v1alpha3.Artifact, builds []build.Artifact) error {
+	built := map[string]bool{}
+	for _, b := range builds {
+		built[b.ImageName] = true
+	}
+
+	var missing []string
+	for _, a := range artifacts {
+		if !built[a.ImageName] {
+			missing = append(missing, a.ImageName)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no build result for %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// readBuildArtifacts reads the build results given with --build-artifacts
+// and checks that they cover all the artifacts of the configuration.
+func readBuildArtifacts(artifacts []*v1alpha3.Artifact) ([]build.Artifact, error) {
+	builds, err := readBuildOutput(buildArtifacts)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading build artifacts")
+	}
+
+	if err := checkBuildOutput(artifacts, builds); err != nil {
+		return nil, errors.Wrapf(err, "invalid build artifacts in %s", buildArtifacts)
+	}
+
+	return builds, nil
+}
+
 func runBuild(out io.Writer) error {
 	ctx := context.Background()
 
@@ -72,6 +180,11 @@ func runBuild(out io.Writer) error {
 	}
 
 	cmdOut := BuildOutput{Builds: bRes}
+	if buildFileOutput != "" {
+		if err := writeBuildOutput(buildFileOutput, cmdOut); err != nil {
+			return errors.Wrap(err, "writing build output")
+		}
+	}
 	if err := buildFormatFlag.Template().Execute(out, cmdOut); err != nil {
 		return errors.Wrap(err, "executing template")
 	}
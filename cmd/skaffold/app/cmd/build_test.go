@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/cmd/skaffold/app/flags"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestReadBuildOutput(t *testing.T) {
+	var tests = []struct {
+		description string
+		contents    string
+		shouldErr   bool
+		expected    []build.Artifact
+	}{
+		{
+			description: "json file",
+			contents:    `{"builds":[{"imageName":"gcr.io/project/web","tag":"gcr.io/project/web:v1"}]}`,
+			expected: []build.Artifact{{
+				ImageName: "gcr.io/project/web",
+				Tag:       "gcr.io/project/web:v1",
+			}},
+		},
+		{
+			description: "yaml file",
+			contents:    "builds:\n- imageName: gcr.io/project/web\n  tag: gcr.io/project/web:v1\n",
+			expected: []build.Artifact{{
+				ImageName: "gcr.io/project/web",
+				Tag:       "gcr.io/project/web:v1",
+			}},
+		},
+		{
+			description: "json template output",
+			contents:    `{"Builds":[{"ImageName":"gcr.io/project/web","Tag":"gcr.io/project/web:v1"}]}`,
+			expected: []build.Artifact{{
+				ImageName: "gcr.io/project/web",
+				Tag:       "gcr.io/project/web:v1",
+			}},
+		},
+		{
+			description: "default template output",
+			contents:    "gcr.io/project/web -> gcr.io/project/web:v1\n",
+			shouldErr:   true,
+		},
+		{
+			description: "unknown field",
+			contents:    "builds:\n- image: gcr.io/project/web\n",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			file, teardown := testutil.TempFile(t, "build.json", []byte(test.contents))
+			defer teardown()
+
+			builds, err := readBuildOutput(file)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, builds)
+		})
+	}
+}
+
+func TestWriteBuildOutput(t *testing.T) {
+	builds := []build.Artifact{
+		{ImageName: "gcr.io/project/web", Tag: "gcr.io/project/web:v1"},
+		{ImageName: "gcr.io/project/worker", Tag: "gcr.io/project/worker:v2"},
+	}
+
+	for _, filename := range []string{"build.json", "build.yaml", "build.yml"} {
+		t.Run(filename, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			file := filepath.Join(tmpDir, filename)
+
+			err := writeBuildOutput(file, BuildOutput{Builds: builds})
+			testutil.CheckError(t, false, err)
+
+			read, err := readBuildOutput(file)
+			testutil.CheckErrorAndDeepEqual(t, false, err, builds, read)
+		})
+	}
+}
+
+func TestBuildOutputTemplate(t *testing.T) {
+	template := flags.NewTemplateFlag("{{json .}}", BuildOutput{})
+	buildOutput := BuildOutput{Builds: []build.Artifact{
+		{ImageName: "gcr.io/project/web", Tag: "gcr.io/project/web:v1"},
+	}}
+
+	var out bytes.Buffer
+	err := template.Template().Execute(&out, buildOutput)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, `{"Builds":[{"ImageName":"gcr.io/project/web","Tag":"gcr.io/project/web:v1"}]}`, out.String())
+}
+
+func TestCheckBuildOutput(t *testing.T) {
+	artifacts := []*v1alpha3.Artifact{
+		{ImageName: "gcr.io/project/web"},
+		{ImageName: "gcr.io/project/worker"},
+	}
+
+	var tests = []struct {
+		description string
+		builds      []build.Artifact
+		shouldErr   bool
+	}{
+		{
+			description: "all images built",
+			builds: []build.Artifact{
+				{ImageName: "gcr.io/project/web", Tag: "gcr.io/project/web:v1"},
+				{ImageName: "gcr.io/project/worker", Tag: "gcr.io/project/worker:v2"},
+			},
+		},
+		{
+			description: "missing image",
+			builds: []build.Artifact{
+				{ImageName: "gcr.io/project/web", Tag: "gcr.io/project/web:v1"},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := checkBuildOutput(artifacts, test.builds)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
@@ -22,10 +22,12 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	cmdutil "github.com/GoogleContainerTools/skaffold/cmd/skaffold/app/cmd/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/update"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/version"
 	"github.com/pkg/errors"
@@ -35,11 +37,15 @@ import (
 )
 
 var (
-	opts      = &config.SkaffoldOptions{}
-	v         string
-	overwrite bool
+	opts           = &config.SkaffoldOptions{}
+	v              string
+	overwrite      bool
+	buildArtifacts string
 
 	updateMsg = make(chan string)
+
+	// stopEvents stops publishing the events of the pipeline.
+	stopEvents = func() {}
 )
 
 var rootCmd = &cobra.Command{
@@ -53,7 +59,11 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 			return err
 		}
 		rootCmd.SilenceUsage = true
+		opts.Command = cmd.Name()
 		logrus.Infof("Skaffold %+v", version.Get())
+		if err := startEvents(opts); err != nil {
+			return err
+		}
 		go func() {
 			if err := updateCheck(updateMsg); err != nil {
 				logrus.Infof("update check failed: %s", err)
@@ -63,6 +73,8 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 	}
 
 	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		stopEvents()
+
 		select {
 		case msg := <-updateMsg:
 			fmt.Fprintf(out, "%s\n", msg)
@@ -77,8 +89,12 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 	rootCmd.AddCommand(NewCmdDev(out))
 	rootCmd.AddCommand(NewCmdBuild(out))
 	rootCmd.AddCommand(NewCmdDeploy(out))
+	rootCmd.AddCommand(NewCmdRender(out))
 	rootCmd.AddCommand(NewCmdDelete(out))
+	rootCmd.AddCommand(NewCmdLogs(out))
 	rootCmd.AddCommand(NewCmdFix(out))
+	rootCmd.AddCommand(NewCmdInit(out))
+	rootCmd.AddCommand(NewCmdValidate(out))
 
 	rootCmd.PersistentFlags().StringVarP(&v, "verbosity", "v", constants.DefaultLogLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
 
@@ -87,6 +103,38 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 	return rootCmd
 }
 
+// startEvents starts the event server and the event log, if they are enabled.
+func startEvents(opts *config.SkaffoldOptions) error {
+	var stops []func()
+
+	if opts.RPCPort != 0 {
+		stop, err := event.StartServer(opts.RPCPort)
+		if err != nil {
+			return errors.Wrap(err, "starting event server")
+		}
+		stops = append(stops, stop)
+	}
+
+	if opts.EventLogFile != "" {
+		stop, err := event.LogToFile(opts.EventLogFile)
+		if err != nil {
+			return err
+		}
+		stops = append(stops, func() {
+			if err := stop(); err != nil {
+				logrus.Warnln("Closing event log:", err)
+			}
+		})
+	}
+
+	stopEvents = func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+	return nil
+}
+
 func updateCheck(ch chan string) error {
 	if !update.IsUpdateCheckEnabled() {
 		logrus.Debugf("Update check not enabled, skipping.")
@@ -128,13 +176,49 @@ func setFlagsFromEnvVariables(commands []*cobra.Command) {
 
 func AddDevFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&opts.Cleanup, "cleanup", true, "Delete deployments after dev mode is interrupted")
+	cmd.Flags().BoolVar(&opts.PortForward, "port-forward", true, "Port-forward exposed container ports and services to the host")
+	cmd.Flags().StringVar(&opts.Trigger, "trigger", "polling", "How are changes detected and applied? (polling, notify or manual)")
+	cmd.Flags().IntVar(&opts.TriggerPort, "trigger-port", 0, "Port of a local HTTP endpoint that applies pending changes when called with POST /build and changes the log filter with /logs (0 to disable)")
+	cmd.Flags().DurationVar(&opts.WatchDebounce, "watch-debounce", 0, "Wait for this long without new changes before rebuilding")
+}
+
+func AddLabelFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&opts.RunID, "run-id", "", "ID of this run, set as the skaffold-run-id label of the deployed objects (random by default)")
+	cmd.Flags().BoolVar(&opts.LabelPodTemplates, "label-pod-templates", false, "Also set the labels on the pod templates of the deployed workloads, which rolls out new pods when the labels change")
+}
+
+func AddLogFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&opts.LogInclude, "log-include", nil, "Only print the logs of the containers matching one of these selectors (image:<name>, label:<key>=<value> or container:<name>)")
+	cmd.Flags().StringArrayVar(&opts.LogExclude, "log-exclude", nil, "Don't print the logs of the containers matching one of these selectors (image:<name>, label:<key>=<value> or container:<name>)")
+	cmd.Flags().StringVar(&opts.LogMatch, "log-match", "", "Only print the log lines matching this regular expression")
+	cmd.Flags().StringVar(&opts.LogFormat, "log-format", "text", "Format of the printed logs (text or json)")
+	cmd.Flags().StringVar(&opts.LogDir, "log-dir", "", "Directory where the logs of each container are written to a separate file, whatever the log filter")
 }
 
 func AddRunDevFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&opts.ConfigurationFile, "filename", "f", "skaffold.yaml", "Filename or URL to the pipeline file")
 	cmd.Flags().BoolVar(&opts.Notification, "toot", false, "Emit a terminal beep after the deploy is complete")
 	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name")
+	cmd.Flags().BoolVar(&opts.ProfileAutoActivation, "profile-auto-activation", true, "Activate the profiles whose activation conditions are met")
+	cmd.Flags().StringSliceVarP(&opts.Modules, "module", "m", nil, "Only include these modules, and the modules they require, from the required configurations")
 	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", "", "Run Helm deployments in the specified namespace")
+	cmd.Flags().IntVar(&opts.RPCPort, "rpc-port", 0, "Port of a local HTTP server that streams the events of the pipeline and serves its state (0 to disable)")
+	cmd.Flags().StringVar(&opts.EventLogFile, "event-log-file", "", "File to write the events of the pipeline to, as newline-delimited JSON")
+}
+
+func AddCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&opts.CacheArtifacts, "cache-artifacts", true, "Reuse the images previously built from unchanged artifacts, when building locally")
+	cmd.Flags().StringVar(&opts.CacheFile, "cache-file", "", "Location of the artifact cache (defaults to $HOME/.skaffold/cache)")
+	cmd.Flags().BoolVar(&opts.PurgeCache, "purge-cache", false, "Clear the artifact cache before building")
+}
+
+func AddStatusCheckFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&opts.StatusCheck, "status-check", true, "Wait for the deployed resources to be ready and fail if they aren't")
+	cmd.Flags().DurationVar(&opts.StatusCheckDeadline, "status-check-deadline", 10*time.Minute, "How long to wait for the deployed resources to be ready")
+}
+
+func AddBuildArtifactsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&buildArtifacts, "build-artifacts", "", "File containing the build results written by `skaffold build --file-output`, to use instead of building")
 }
 
 func AddFixFlags(cmd *cobra.Command) {
@@ -152,14 +236,10 @@ func SetUpLogs(out io.Writer, level string) error {
 	return nil
 }
 
-func readConfiguration(opts *config.SkaffoldOptions) (*config.SkaffoldConfig, error) {
-	config, err := cmdutil.ParseConfig(opts.ConfigurationFile)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing skaffold config")
-	}
-	err = config.ApplyProfiles(opts.Profiles)
+func readConfiguration(opts *config.SkaffoldOptions) (*config.SkaffoldConfig, []string, error) {
+	config, activatedProfiles, err := cmdutil.ParseConfig(opts)
 	if err != nil {
-		return nil, errors.Wrap(err, "applying profiles")
+		return nil, nil, errors.Wrap(err, "parsing skaffold config")
 	}
-	return config, nil
+	return config, activatedProfiles, nil
 }
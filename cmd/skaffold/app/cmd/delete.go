@@ -35,6 +35,7 @@ func NewCmdDelete(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	cmd.Flags().StringVar(&opts.RunID, "run-id", "", "Delete the resources labeled with this run ID, even if the manifests changed or were removed")
 	return cmd
 }
 
@@ -42,6 +42,9 @@ func NewCmdDeploy(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	AddStatusCheckFlags(cmd)
+	AddBuildArtifactsFlag(cmd)
+	AddLabelFlags(cmd)
 	cmd.Flags().StringSliceVar(&images, "images", nil, "A list of images to deploy")
 	cmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress the deploy output")
 	return cmd
@@ -50,10 +53,17 @@ func NewCmdDeploy(out io.Writer) *cobra.Command {
 func runDeploy(out io.Writer) error {
 	ctx := context.Background()
 
-	r, _, err := newRunner(opts)
+	if err := setRunID(opts); err != nil {
+		return err
+	}
+
+	r, config, err := newRunner(opts)
 	if err != nil {
 		return errors.Wrap(err, "creating runner")
 	}
+	if buildArtifacts != "" && len(images) > 0 {
+		return errors.New("--build-artifacts and --images can't be used together")
+	}
 
 	deployOut := out
 	if quietFlag {
@@ -61,6 +71,12 @@ func runDeploy(out io.Writer) error {
 	}
 
 	var builds []build.Artifact
+	if buildArtifacts != "" {
+		builds, err = readBuildArtifacts(config.Build.Artifacts)
+		if err != nil {
+			return err
+		}
+	}
 	for _, image := range images {
 		parsed, err := docker.ParseReference(image)
 		if err != nil {
@@ -72,6 +88,10 @@ func runDeploy(out io.Writer) error {
 		})
 	}
 
-	_, err = r.Deploy(ctx, deployOut, builds)
-	return err
+	deployed, err := r.Deploy(ctx, deployOut, builds)
+	if err != nil {
+		return err
+	}
+
+	return r.CheckStatus(ctx, deployOut, deployed)
 }
@@ -23,6 +23,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -40,7 +41,10 @@ func NewCmdDev(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	AddCacheFlags(cmd)
 	AddDevFlags(cmd)
+	AddLogFlags(cmd)
+	AddLabelFlags(cmd)
 	return cmd
 }
 
@@ -48,6 +52,12 @@ func dev(out io.Writer) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// The same run ID is kept when dev mode is restarted so that
+	// the cleanup deletes everything that was deployed.
+	if err := setRunID(opts); err != nil {
+		return err
+	}
+
 	if opts.Cleanup {
 		catchCtrlC(cancel)
 
@@ -58,6 +68,9 @@ func dev(out io.Writer) error {
 		}()
 	}
 
+	// The log filter can be changed while dev mode is running.
+	var logFilter *kubernetes.LogFilter
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -67,8 +80,15 @@ func dev(out io.Writer) error {
 			if err != nil {
 				return errors.Wrap(err, "creating runner")
 			}
+			if logFilter != nil {
+				r.KeepLogFilter(*logFilter)
+			}
+
+			_, err = r.Dev(ctx, out, config.Build.Artifacts)
+			filter := r.LogFilter()
+			logFilter = &filter
 
-			if _, err := r.Dev(ctx, out, config.Build.Artifacts); err != nil {
+			if err != nil {
 				if errors.Cause(err) != runner.ErrorConfigurationChanged {
 					return err
 				}
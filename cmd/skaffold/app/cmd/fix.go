@@ -23,12 +23,10 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema"
-	schemautil "github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	yaml "gopkg.in/yaml.v2"
 )
 
 func NewCmdFix(out io.Writer) *cobra.Command {
@@ -49,7 +47,7 @@ func NewCmdFix(out io.Writer) *cobra.Command {
 				color.Default.Fprintln(out, "config is already latest version")
 				return
 			}
-			if err := runFix(out, cfg); err != nil {
+			if err := runFix(out, contents); err != nil {
 				logrus.Errorf("fix: %s", err)
 			}
 		},
@@ -59,20 +57,16 @@ func NewCmdFix(out io.Writer) *cobra.Command {
 	return cmd
 }
 
-func runFix(out io.Writer, cfg schemautil.VersionedConfig) error {
-	cfg, err := schema.RunTransform(cfg)
+func runFix(out io.Writer, contents []byte) error {
+	newCfg, err := schema.UpgradeConfig(contents)
 	if err != nil {
 		return err
 	}
-	newCfg, err := yaml.Marshal(cfg)
-	if err != nil {
-		return errors.Wrap(err, "marshaling new config")
-	}
 	if overwrite {
 		if err := ioutil.WriteFile(opts.ConfigurationFile, newCfg, 0644); err != nil {
 			return errors.Wrap(err, "writing config file")
 		}
-		color.Default.Fprintf(out, "New config at version %s generated and written to %s\n", cfg.GetVersion(), opts.ConfigurationFile)
+		color.Default.Fprintf(out, "New config at version %s generated and written to %s\n", config.LatestVersion, opts.ConfigurationFile)
 	} else {
 		out.Write(newCfg)
 	}
@@ -0,0 +1,50 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/initializer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cliArtifacts []string
+	force        bool
+)
+
+// NewCmdInit describes the CLI command to generate a skaffold configuration.
+func NewCmdInit(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Automatically generates a skaffold configuration for an existing project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return initializer.DoInit(out, os.Stdin, initializer.Config{
+				ConfigFile: opts.ConfigurationFile,
+				Artifacts:  cliArtifacts,
+				Force:      force,
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&opts.ConfigurationFile, "filename", "f", "skaffold.yaml", "Filename of the generated pipeline file")
+	cmd.Flags().StringArrayVarP(&cliArtifacts, "artifact", "a", nil, "'='-delimited build file/image pair, skips all prompts (example: --artifact=web/Dockerfile=gcr.io/project/web)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing pipeline file")
+	return cmd
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsSince  time.Duration
+	logsFollow bool
+)
+
+// NewCmdLogs describes the CLI command to print the logs of a deployed pipeline.
+func NewCmdLogs(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "logs",
+		Aliases: []string{"tail"},
+		Short:   "Prints the logs of the containers deployed by a pipeline",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return logs(out)
+		},
+	}
+	AddRunDevFlags(cmd)
+	AddLogFlags(cmd)
+	cmd.Flags().DurationVar(&logsSince, "since", 0, "Only print the logs newer than a relative duration like 5s, 2m, or 3h (0 to print all the logs)")
+	cmd.Flags().BoolVar(&logsFollow, "follow", false, "Stream the logs until interrupted")
+	cmd.Flags().StringVar(&opts.RunID, "run-id", "", "Only print the logs of the containers deployed by this run")
+	return cmd
+}
+
+func logs(out io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	catchCtrlC(cancel)
+
+	runner, config, err := newRunner(opts)
+	if err != nil {
+		return errors.Wrap(err, "creating runner")
+	}
+
+	var since time.Time
+	if logsSince > 0 {
+		since = time.Now().Add(-logsSince)
+	}
+
+	return runner.Logs(ctx, out, config.Build.Artifacts, since, logsFollow)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var renderOutput string
+
+// NewCmdRender describes the CLI command to render the manifests of a pipeline.
+func NewCmdRender(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Renders the manifests with the images replaced, without deploying them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRender(out)
+		},
+	}
+	AddRunDevFlags(cmd)
+	AddCacheFlags(cmd)
+	AddBuildArtifactsFlag(cmd)
+	cmd.Flags().StringVarP(&renderOutput, "output", "o", "", "File to write the rendered manifests to, instead of stdout")
+	return cmd
+}
+
+func runRender(out io.Writer) error {
+	ctx := context.Background()
+
+	runner, config, err := newRunner(opts)
+	if err != nil {
+		return errors.Wrap(err, "creating runner")
+	}
+
+	var builds []build.Artifact
+	if buildArtifacts != "" {
+		builds, err = readBuildArtifacts(config.Build.Artifacts)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Keep the build logs out of the rendered manifests.
+		buildOut := out
+		if renderOutput == "" {
+			buildOut = os.Stderr
+		}
+
+		builds, err = runner.Build(ctx, buildOut, runner.Tagger, config.Build.Artifacts)
+		if err != nil {
+			return errors.Wrap(err, "build step")
+		}
+	}
+
+	if renderOutput == "" {
+		return runner.Render(ctx, out, builds)
+	}
+
+	var manifests bytes.Buffer
+	if err := runner.Render(ctx, &manifests, builds); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(renderOutput, manifests.Bytes(), 0644)
+}
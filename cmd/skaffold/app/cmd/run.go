@@ -35,6 +35,9 @@ func NewCmdRun(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	AddCacheFlags(cmd)
+	AddStatusCheckFlags(cmd)
+	AddLabelFlags(cmd)
 
 	cmd.Flags().StringVarP(&opts.CustomTag, "tag", "t", "", "The optional custom tag to use for images which overrides the current Tagger configuration")
 	return cmd
@@ -43,6 +46,10 @@ func NewCmdRun(out io.Writer) *cobra.Command {
 func run(out io.Writer) error {
 	ctx := context.Background()
 
+	if err := setRunID(opts); err != nil {
+		return err
+	}
+
 	runner, config, err := newRunner(opts)
 	if err != nil {
 		return errors.Wrap(err, "creating runner")
@@ -17,22 +17,41 @@ limitations under the License.
 package cmd
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // newRunner creates a SkaffoldRunner and returns the SkaffoldConfig associated with it.
 func newRunner(opts *config.SkaffoldOptions) (*runner.SkaffoldRunner, *config.SkaffoldConfig, error) {
-	config, err := readConfiguration(opts)
+	config, activatedProfiles, err := readConfiguration(opts)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "reading configuration")
 	}
 
-	runner, err := runner.NewForConfig(opts, config)
+	runner, err := runner.NewForConfig(opts, config, activatedProfiles)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "creating runner")
 	}
 
 	return runner, config, nil
 }
+
+// setRunID generates the ID that identifies the objects deployed by this run,
+// unless one was given with --run-id.
+func setRunID(opts *config.SkaffoldOptions) error {
+	if opts.RunID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return errors.Wrap(err, "generating run id")
+		}
+		opts.RunID = hex.EncodeToString(id)
+	}
+
+	logrus.Infof("Run ID: %s", opts.RunID)
+	return nil
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// moduleResolver combines a configuration with the configurations it requires.
+type moduleResolver struct {
+	profiles       []string
+	modules        []string
+	command        string
+	autoActivation bool
+
+	activated       []string
+	selected        map[string]bool
+	foundModules    map[string]bool
+	appliedProfiles map[string]bool
+	visiting        map[string]bool
+	included        map[string]bool
+}
+
+func newModuleResolver(opts *config.SkaffoldOptions) *moduleResolver {
+	selected := map[string]bool{}
+	for _, module := range opts.Modules {
+		selected[module] = true
+	}
+
+	return &moduleResolver{
+		profiles:        opts.Profiles,
+		modules:         opts.Modules,
+		command:         opts.Command,
+		autoActivation:  opts.ProfileAutoActivation,
+		selected:        selected,
+		foundModules:    map[string]bool{},
+		appliedProfiles: map[string]bool{},
+		visiting:        map[string]bool{},
+		included:        map[string]bool{},
+	}
+}
+
+// resolve adds the artifacts and the deployers of the required configurations
+// to cfg. Once resolved, cfg.Requires lists all the configurations that were
+// added, with paths relative to the current directory.
+func (r *moduleResolver) resolve(cfg *config.SkaffoldConfig, filename string) error {
+	requires := cfg.Requires
+	cfg.Requires = nil
+
+	if len(requires) > 0 && isURL(filename) {
+		return fmt.Errorf("%s can't require other configurations", filename)
+	}
+
+	r.visiting[absPath(filename)] = true
+	if err := r.resolveRequires(cfg, requires, filepath.Dir(filename), len(r.modules) == 0); err != nil {
+		return err
+	}
+
+	for _, module := range r.modules {
+		if !r.foundModules[module] {
+			return fmt.Errorf("couldn't find module %s", module)
+		}
+	}
+	for _, profile := range r.profiles {
+		if !r.appliedProfiles[profile] {
+			return fmt.Errorf("couldn't find profile %s", profile)
+		}
+	}
+
+	return nil
+}
+
+func (r *moduleResolver) resolveRequires(cfg *config.SkaffoldConfig, requires []v1alpha3.ConfigDependency, dir string, active bool) error {
+	for _, required := range requires {
+		filename := filepath.Join(dir, required.Path)
+		if info, err := os.Stat(filename); err == nil && info.IsDir() {
+			filename = filepath.Join(filename, "skaffold.yaml")
+		}
+
+		name := required.Name
+		if name == "" {
+			name = filepath.Base(filepath.Dir(absPath(filename)))
+		}
+		r.foundModules[name] = true
+
+		abs := absPath(filename)
+		if r.visiting[abs] {
+			return fmt.Errorf("cycle in required configurations: %s requires itself", filename)
+		}
+		if r.included[abs] {
+			continue
+		}
+
+		module, err := readConfig(filename)
+		if err != nil {
+			return errors.Wrapf(err, "reading module %s", name)
+		}
+		if err := r.applyProfiles(module); err != nil {
+			return errors.Wrapf(err, "reading module %s", name)
+		}
+		rebase(module, filepath.Dir(filename))
+
+		moduleActive := active || r.selected[name]
+
+		r.visiting[abs] = true
+		err = r.resolveRequires(cfg, module.Requires, filepath.Dir(filename), moduleActive)
+		delete(r.visiting, abs)
+		if err != nil {
+			return err
+		}
+
+		if !moduleActive {
+			continue
+		}
+		r.included[abs] = true
+
+		logrus.Debugf("Including module %s from %s", name, filename)
+		if err := merge(cfg, module, name); err != nil {
+			return errors.Wrapf(err, "including module %s", name)
+		}
+		cfg.Requires = append(cfg.Requires, v1alpha3.ConfigDependency{
+			Name: name,
+			Path: filename,
+		})
+	}
+
+	return nil
+}
+
+// applyProfiles activates the profiles of a configuration that are either
+// selected or activated automatically.
+func (r *moduleResolver) applyProfiles(cfg *config.SkaffoldConfig) error {
+	var profiles []string
+	if r.autoActivation {
+		activated, err := cfg.ActivatedProfiles(r.command)
+		if err != nil {
+			return errors.Wrap(err, "activating profiles")
+		}
+		for _, profile := range activated {
+			if !util.StrSliceContains(r.profiles, profile) {
+				profiles = append(profiles, profile)
+				if !util.StrSliceContains(r.activated, profile) {
+					r.activated = append(r.activated, profile)
+				}
+			}
+		}
+	}
+
+	for _, profile := range r.profiles {
+		for _, p := range cfg.Profiles {
+			if p.Name == profile {
+				profiles = append(profiles, profile)
+				r.appliedProfiles[profile] = true
+				break
+			}
+		}
+	}
+
+	if err := cfg.ApplyProfiles(profiles); err != nil {
+		return errors.Wrap(err, "applying profiles")
+	}
+	return nil
+}
+
+// rebase makes the paths of a configuration relative to the current
+// directory instead of the directory of its file.
+func rebase(cfg *config.SkaffoldConfig, dir string) {
+	if dir == "." {
+		return
+	}
+
+	for _, a := range cfg.Build.Artifacts {
+		a.Workspace = filepath.Join(dir, a.Workspace)
+	}
+
+	if kubectl := cfg.Deploy.KubectlDeploy; kubectl != nil {
+		// The manifests might be the default ones, shared by all configurations.
+		var manifests []string
+		for _, manifest := range kubectl.Manifests {
+			manifests = append(manifests, filepath.Join(dir, manifest))
+		}
+		kubectl.Manifests = manifests
+	}
+
+	if helm := cfg.Deploy.HelmDeploy; helm != nil {
+		for i, release := range helm.Releases {
+			// Charts from a repository, like stable/redis, are left untouched.
+			if _, err := os.Stat(filepath.Join(dir, release.ChartPath)); err == nil {
+				helm.Releases[i].ChartPath = filepath.Join(dir, release.ChartPath)
+			}
+			if release.ValuesFilePath != "" {
+				helm.Releases[i].ValuesFilePath = filepath.Join(dir, release.ValuesFilePath)
+			}
+		}
+	}
+
+	if kustomize := cfg.Deploy.KustomizeDeploy; kustomize != nil {
+		kustomize.KustomizePath = filepath.Join(dir, kustomize.KustomizePath)
+	}
+}
+
+// merge adds the artifacts and the deployers of a module to a configuration.
+// The build type and the tag policy of the configuration are used for the
+// artifacts of the module.
+func merge(cfg *config.SkaffoldConfig, module *config.SkaffoldConfig, name string) error {
+	if !reflect.DeepEqual(cfg.Build.BuildType, module.Build.BuildType) || !reflect.DeepEqual(cfg.Build.TagPolicy, module.Build.TagPolicy) {
+		logrus.Warnf("Ignoring the build type and the tag policy of module %s, its artifacts are built like the ones of the configuration that requires it", name)
+	}
+	cfg.Build.Artifacts = append(cfg.Build.Artifacts, module.Build.Artifacts...)
+
+	if helm := module.Deploy.HelmDeploy; helm != nil {
+		if cfg.Deploy.HelmDeploy == nil {
+			cfg.Deploy.HelmDeploy = &v1alpha3.HelmDeploy{}
+		}
+		cfg.Deploy.HelmDeploy.Releases = append(cfg.Deploy.HelmDeploy.Releases, helm.Releases...)
+	}
+
+	if kubectl := module.Deploy.KubectlDeploy; kubectl != nil {
+		if cfg.Deploy.KubectlDeploy == nil {
+			cfg.Deploy.KubectlDeploy = &v1alpha3.KubectlDeploy{Flags: kubectl.Flags}
+		} else if !reflect.DeepEqual(cfg.Deploy.KubectlDeploy.Flags, kubectl.Flags) {
+			return errors.New("kubectl flags are different from the ones of the configuration that requires it")
+		}
+		cfg.Deploy.KubectlDeploy.Manifests = append(cfg.Deploy.KubectlDeploy.Manifests, kubectl.Manifests...)
+		cfg.Deploy.KubectlDeploy.RemoteManifests = append(cfg.Deploy.KubectlDeploy.RemoteManifests, kubectl.RemoteManifests...)
+	}
+
+	if kustomize := module.Deploy.KustomizeDeploy; kustomize != nil {
+		if cfg.Deploy.KustomizeDeploy == nil {
+			cfg.Deploy.KustomizeDeploy = kustomize
+		} else if !reflect.DeepEqual(cfg.Deploy.KustomizeDeploy, kustomize) {
+			return errors.New("only one kustomization can be deployed")
+		}
+	}
+
+	cfg.Deploy.PortForward = append(cfg.Deploy.PortForward, module.Deploy.PortForward...)
+
+	return nil
+}
+
+func isURL(filename string) bool {
+	return strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://")
+}
+
+func absPath(filename string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filename
+	}
+	return abs
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const rootConfig = `apiVersion: skaffold/v1alpha3
+kind: Config
+requires:
+- path: services/web
+- path: services/worker/skaffold.yaml
+  name: jobs
+deploy:
+  kubectl:
+    manifests:
+    - k8s/infra.yaml
+`
+
+const webConfig = `apiVersion: skaffold/v1alpha3
+kind: Config
+requires:
+- path: ../lib
+build:
+  artifacts:
+  - imageName: web
+deploy:
+  kubectl: {}
+`
+
+const libConfig = `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: lib
+    workspace: src
+`
+
+const workerConfig = `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: worker
+deploy:
+  helm:
+    releases:
+    - name: worker
+      chartPath: chart
+      valuesFilePath: values.yaml
+    - name: redis
+      chartPath: stable/redis
+profiles:
+- name: dev
+  build:
+    artifacts:
+    - imageName: worker-dev
+`
+
+func TestParseConfigWithModules(t *testing.T) {
+	var tests = []struct {
+		description       string
+		files             map[string]string
+		profiles          []string
+		modules           []string
+		command           string
+		shouldErr         bool
+		expectedImages    []string
+		expectedWorkspace map[string]string
+		expectedManifests []string
+		expectedRequires  []v1alpha3.ConfigDependency
+		expectedActivated []string
+	}{
+		{
+			description:       "all modules",
+			expectedImages:    []string{"lib", "web", "worker"},
+			expectedWorkspace: map[string]string{"lib": filepath.Join("services", "lib", "src"), "web": filepath.Join("services", "web"), "worker": filepath.Join("services", "worker")},
+			expectedManifests: []string{"k8s/infra.yaml", filepath.Join("services", "web", "k8s", "*.yaml")},
+			expectedRequires: []v1alpha3.ConfigDependency{
+				{Name: "lib", Path: filepath.Join("services", "lib", "skaffold.yaml")},
+				{Name: "web", Path: filepath.Join("services", "web", "skaffold.yaml")},
+				{Name: "jobs", Path: filepath.Join("services", "worker", "skaffold.yaml")},
+			},
+		},
+		{
+			description:       "selected module",
+			modules:           []string{"jobs"},
+			expectedImages:    []string{"worker"},
+			expectedWorkspace: map[string]string{"worker": filepath.Join("services", "worker")},
+			expectedManifests: []string{"k8s/infra.yaml"},
+			expectedRequires:  []v1alpha3.ConfigDependency{{Name: "jobs", Path: filepath.Join("services", "worker", "skaffold.yaml")}},
+		},
+		{
+			description:       "selected module and its requirements",
+			modules:           []string{"web"},
+			expectedImages:    []string{"lib", "web"},
+			expectedWorkspace: map[string]string{"lib": filepath.Join("services", "lib", "src"), "web": filepath.Join("services", "web")},
+			expectedManifests: []string{"k8s/infra.yaml", filepath.Join("services", "web", "k8s", "*.yaml")},
+			expectedRequires: []v1alpha3.ConfigDependency{
+				{Name: "lib", Path: filepath.Join("services", "lib", "skaffold.yaml")},
+				{Name: "web", Path: filepath.Join("services", "web", "skaffold.yaml")},
+			},
+		},
+		{
+			description:       "profile of a module",
+			modules:           []string{"jobs"},
+			profiles:          []string{"dev"},
+			expectedImages:    []string{"worker-dev"},
+			expectedWorkspace: map[string]string{"worker-dev": filepath.Join("services", "worker")},
+			expectedManifests: []string{"k8s/infra.yaml"},
+			expectedRequires:  []v1alpha3.ConfigDependency{{Name: "jobs", Path: filepath.Join("services", "worker", "skaffold.yaml")}},
+		},
+		{
+			description: "auto-activated profile of a module",
+			files: map[string]string{
+				"services/worker/skaffold.yaml": workerConfig + "  activation:\n  - command: dev\n",
+			},
+			modules:           []string{"jobs"},
+			command:           "dev",
+			expectedImages:    []string{"worker-dev"},
+			expectedWorkspace: map[string]string{"worker-dev": filepath.Join("services", "worker")},
+			expectedManifests: []string{"k8s/infra.yaml"},
+			expectedRequires:  []v1alpha3.ConfigDependency{{Name: "jobs", Path: filepath.Join("services", "worker", "skaffold.yaml")}},
+			expectedActivated: []string{"dev"},
+		},
+		{
+			description: "unknown profile",
+			profiles:    []string{"unknown"},
+			shouldErr:   true,
+		},
+		{
+			description: "unknown module",
+			modules:     []string{"unknown"},
+			shouldErr:   true,
+		},
+		{
+			description: "cycle",
+			files: map[string]string{
+				"services/lib/skaffold.yaml": "apiVersion: skaffold/v1alpha3\nkind: Config\nrequires:\n- path: ../web\n",
+			},
+			shouldErr: true,
+		},
+		{
+			description: "missing module",
+			files: map[string]string{
+				"services/web/skaffold.yaml": "apiVersion: skaffold/v1alpha3\nkind: Config\nrequires:\n- path: ../missing\n",
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			files := map[string]string{
+				"skaffold.yaml":                    rootConfig,
+				"services/web/skaffold.yaml":       webConfig,
+				"services/lib/skaffold.yaml":       libConfig,
+				"services/worker/skaffold.yaml":    workerConfig,
+				"services/worker/chart/Chart.yaml": "name: worker",
+			}
+			for path, content := range test.files {
+				files[path] = content
+			}
+			writeFiles(t, tmpDir, files)
+
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(wd)
+
+			opts := &config.SkaffoldOptions{
+				ConfigurationFile: "skaffold.yaml",
+				Profiles:          test.profiles,
+				Modules:           test.modules,
+				Command:           test.command,
+
+				ProfileAutoActivation: true,
+			}
+			cfg, activatedProfiles, err := ParseConfig(opts)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if test.shouldErr {
+				return
+			}
+
+			images := []string{}
+			workspaces := map[string]string{}
+			for _, a := range cfg.Build.Artifacts {
+				images = append(images, a.ImageName)
+				workspaces[a.ImageName] = a.Workspace
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedImages, images)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedWorkspace, workspaces)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedManifests, cfg.Deploy.KubectlDeploy.Manifests)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedRequires, cfg.Requires)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.profiles, opts.Profiles)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedActivated, activatedProfiles)
+		})
+	}
+}
+
+func TestRebaseHelmCharts(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	writeFiles(t, tmpDir, map[string]string{"chart/Chart.yaml": "name: chart"})
+
+	cfg := &v1alpha3.SkaffoldConfig{
+		Deploy: v1alpha3.DeployConfig{
+			DeployType: v1alpha3.DeployType{
+				HelmDeploy: &v1alpha3.HelmDeploy{
+					Releases: []v1alpha3.HelmRelease{
+						{Name: "local", ChartPath: "chart", ValuesFilePath: "values.yaml"},
+						{Name: "remote", ChartPath: "stable/redis"},
+					},
+				},
+			},
+		},
+	}
+	rebase(cfg, tmpDir)
+
+	releases := cfg.Deploy.HelmDeploy.Releases
+	testutil.CheckErrorAndDeepEqual(t, false, nil, filepath.Join(tmpDir, "chart"), releases[0].ChartPath)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, filepath.Join(tmpDir, "values.yaml"), releases[0].ValuesFilePath)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "stable/redis", releases[1].ChartPath)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", releases[1].ValuesFilePath)
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -24,7 +24,29 @@ import (
 	"github.com/pkg/errors"
 )
 
-func ParseConfig(filename string) (*config.SkaffoldConfig, error) {
+// ParseConfig reads the skaffold configuration file, applies the selected
+// and the automatically activated profiles, and combines it with the
+// configurations it requires. When modules are selected, only those modules,
+// and the modules they require, are combined. It also returns the profiles
+// that were activated automatically.
+func ParseConfig(opts *config.SkaffoldOptions) (*config.SkaffoldConfig, []string, error) {
+	cfg, err := readConfig(opts.ConfigurationFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := newModuleResolver(opts)
+	if err := r.applyProfiles(cfg); err != nil {
+		return nil, nil, err
+	}
+	if err := r.resolve(cfg, opts.ConfigurationFile); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, r.activated, nil
+}
+
+func readConfig(filename string) (*config.SkaffoldConfig, error) {
 	buf, err := util.ReadConfiguration(filename)
 	if err != nil {
 		return nil, errors.Wrap(err, "read skaffold config")
@@ -39,7 +61,9 @@ func ParseConfig(filename string) (*config.SkaffoldConfig, error) {
 		return nil, errors.New("Config version out of date: run `skaffold fix`")
 	}
 
-	cfg, err := config.GetConfig(buf, true)
+	// Defaults are applied along with the profiles, so that
+	// the profiles patch the configuration as it was written.
+	cfg, err := config.GetConfig(buf, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold config")
 	}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/validation"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdValidate describes the CLI command to validate a configuration.
+func NewCmdValidate(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Checks skaffold.yaml and the configurations it requires for errors",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(out)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.ConfigurationFile, "filename", "f", "skaffold.yaml", "Filename or URL to the pipeline file")
+	return cmd
+}
+
+func runValidate(out io.Writer) error {
+	problems, err := validation.Validate(opts.ConfigurationFile)
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		color.Red.Fprintln(out, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s) in the configuration", len(problems))
+	}
+
+	color.Default.Fprintln(out, "The configuration is valid")
+	return nil
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha1"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/sirupsen/logrus"
+)
+
+// schemas lists the configuration versions a JSON Schema is generated for.
+var schemas = []util.VersionedConfig{
+	&v1alpha1.SkaffoldConfig{APIVersion: v1alpha1.Version},
+	&v1alpha2.SkaffoldConfig{APIVersion: v1alpha2.Version},
+	&v1alpha3.SkaffoldConfig{APIVersion: v1alpha3.Version},
+}
+
+// Definition is a JSON Schema definition.
+type Definition struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *Definition            `json:"items,omitempty"`
+	Properties           map[string]*Definition `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Definitions          map[string]*Definition `json:"definitions,omitempty"`
+}
+
+type generator struct {
+	docs        map[string]string
+	definitions map[string]*Definition
+}
+
+func main() {
+	for _, cfg := range schemas {
+		if err := generateSchema(".", cfg); err != nil {
+			logrus.Fatalln(err)
+		}
+	}
+}
+
+func schemaPath(root, version string) string {
+	return filepath.Join(root, "schemas", strings.TrimPrefix(version, "skaffold/")+".json")
+}
+
+func generateSchema(root string, cfg util.VersionedConfig) error {
+	buf, err := generate(root, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := schemaPath(root, cfg.GetVersion())
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, buf, 0644)
+}
+
+// generate builds the JSON Schema of a configuration version. The descriptions
+// are read from the doc comments of the types and of the fields.
+func generate(root string, cfg util.VersionedConfig) ([]byte, error) {
+	version := strings.TrimPrefix(cfg.GetVersion(), "skaffold/")
+	docs, err := readDocs(filepath.Join(root, "pkg", "skaffold", "schema", version))
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		docs:        docs,
+		definitions: map[string]*Definition{},
+	}
+
+	schema := g.newDefinition(reflect.TypeOf(cfg), "")
+	schema.Schema = "http://json-schema.org/draft-07/schema#"
+	schema.Definitions = g.definitions
+
+	// The apiVersion identifies the schema to use.
+	g.definitions["SkaffoldConfig"].Properties["apiVersion"].Enum = []string{cfg.GetVersion()}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (g *generator) newDefinition(t reflect.Type, description string) *Definition {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.newDefinition(t.Elem(), description)
+
+	case reflect.Struct:
+		name := t.Name()
+		if _, present := g.definitions[name]; !present {
+			// Register the definition first to support recursive types.
+			def := &Definition{
+				Type:                 "object",
+				Description:          g.docs[name],
+				AdditionalProperties: false,
+			}
+			g.definitions[name] = def
+			def.Properties = g.properties(t)
+		}
+		return &Definition{Ref: "#/definitions/" + name, Description: description}
+
+	case reflect.Slice:
+		return &Definition{Type: "array", Items: g.newDefinition(t.Elem(), ""), Description: description}
+
+	case reflect.Map:
+		return &Definition{Type: "object", AdditionalProperties: g.newDefinition(t.Elem(), ""), Description: description}
+
+	case reflect.String:
+		return &Definition{Type: "string", Description: description}
+
+	case reflect.Bool:
+		return &Definition{Type: "boolean", Description: description}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Definition{Type: "integer", Description: description}
+
+	case reflect.Float32, reflect.Float64:
+		return &Definition{Type: "number", Description: description}
+
+	default:
+		// Any value
+		return &Definition{Description: description}
+	}
+}
+
+// properties lists the properties of a struct, the way it's marshalled to yaml.
+func (g *generator) properties(t reflect.Type) map[string]*Definition {
+	properties := map[string]*Definition{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "inline" {
+			inlined := field.Type
+			if inlined.Kind() == reflect.Ptr {
+				inlined = inlined.Elem()
+			}
+			for name, def := range g.properties(inlined) {
+				properties[name] = def
+			}
+			continue
+		}
+
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		properties[name] = g.newDefinition(field.Type, g.docs[t.Name()+"."+field.Name])
+	}
+
+	return properties
+}
+
+// readDocs reads the doc comments of the types, indexed by type name,
+// and of their fields, indexed by `Type.Field`.
+func readDocs(dir string) (map[string]string, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", dir, err)
+	}
+
+	docs := map[string]string{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					name := typeSpec.Name.Name
+
+					doc := typeSpec.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+					docs[name] = text(doc)
+
+					structType, ok := typeSpec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					for _, field := range structType.Fields.List {
+						doc := field.Doc
+						if doc == nil {
+							doc = field.Comment
+						}
+						for _, fieldName := range field.Names {
+							docs[name+"."+fieldName.Name] = text(doc)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+func text(doc *ast.CommentGroup) string {
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// TestSchemas checks that the JSON Schemas are up to date.
+// Run `make generate-schemas` to update them.
+func TestSchemas(t *testing.T) {
+	root := filepath.Join("..", "..")
+
+	for _, cfg := range schemas {
+		t.Run(cfg.GetVersion(), func(t *testing.T) {
+			expected, err := generate(root, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := ioutil.ReadFile(schemaPath(root, cfg.GetVersion()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, string(expected), string(actual))
+		})
+	}
+}
@@ -21,13 +21,16 @@ package integration
 import (
 	"bytes"
 	"flag"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
 	kubernetesutil "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
@@ -40,6 +43,7 @@ var (
 	gkeClusterName = flag.String("gke-cluster-name", "integration-tests", "name of the integration test cluster")
 	gcpProject     = flag.String("gcp-project", "k8s-skaffold", "the gcp project where the integration test cluster lives")
 	remote         = flag.Bool("remote", false, "if true, run tests on a remote GKE cluster")
+	kanikoRegistry = flag.String("kaniko-registry", "", "registry without credentials, reachable from this machine and from the cluster, used by the local kaniko tests. Those tests are skipped if it's not set")
 
 	client kubernetes.Interface
 )
@@ -133,6 +137,13 @@ func TestRun(t *testing.T) {
 			dir:         "../examples/kaniko",
 			remoteOnly:  true,
 		},
+		{
+			description: "kaniko local context example",
+			args:        []string{"run"},
+			pods:        []string{"getting-started-kaniko-local"},
+			dir:         "../examples/kaniko-local",
+			remoteOnly:  true,
+		},
 		{
 			description: "helm example",
 			args:        []string{"run"},
@@ -198,6 +209,55 @@ func TestRun(t *testing.T) {
 	}
 }
 
+// TestKanikoLocalRegistry builds with kaniko, without a GCS bucket nor a secret,
+// and pushes to a registry that needs no credentials, like the local registry
+// of a kind cluster. It only runs when such a registry is given with --kaniko-registry.
+func TestKanikoLocalRegistry(t *testing.T) {
+	if *remote {
+		t.Skip("skipping local only test")
+	}
+	if *kanikoRegistry == "" {
+		t.Skip("skipping test that needs a registry without credentials, set with --kaniko-registry")
+	}
+
+	ns, deleteNs := setupNamespace(t)
+	defer deleteNs()
+
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "kaniko-local-registry", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = bytes.Replace(contents, []byte("localhost:5000"), []byte(*kanikoRegistry), -1)
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(file)), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("skaffold", "run", "--namespace", ns.Name)
+	cmd.Dir = dir
+	if output, err := util.RunCmdOut(cmd); err != nil {
+		t.Fatalf("skaffold: %s %v", output, err)
+	}
+
+	if err := kubernetesutil.WaitForPodReady(client.CoreV1().Pods(ns.Name), "kaniko-local-registry"); err != nil {
+		t.Fatalf("Timed out waiting for pod ready")
+	}
+
+	cmd = exec.Command("skaffold", "delete", "--namespace", ns.Name)
+	cmd.Dir = dir
+	if output, err := util.RunCmdOut(cmd); err != nil {
+		t.Fatalf("skaffold delete: %s %v", output, err)
+	}
+}
+
 func setupNamespace(t *testing.T) (*v1.Namespace, func()) {
 	ns, err := client.CoreV1().Namespaces().Create(&v1.Namespace{
 		ObjectMeta: meta_v1.ObjectMeta{
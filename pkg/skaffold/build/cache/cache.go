@@ -0,0 +1,203 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// For testing
+var dependenciesForArtifact = build.DependenciesForArtifact
+
+// Cache remembers the images built for given artifact inputs, across
+// skaffold sessions, so that unchanged artifacts are not rebuilt.
+type Cache struct {
+	file string
+
+	mu        sync.Mutex
+	artifacts map[string]Entry
+	dirty     bool
+}
+
+// Entry is a cached image.
+type Entry struct {
+	// Digest is the digest the image was tagged with:
+	// its ID in the local docker daemon or its digest in the registry.
+	Digest string `yaml:"digest"`
+
+	// RemoteDigest is the digest of the image in the registry, if it was pushed.
+	RemoteDigest string `yaml:"remoteDigest,omitempty"`
+}
+
+// DefaultFile is the location of the cache, under the user's home.
+func DefaultFile() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", errors.Wrap(err, "getting home directory")
+	}
+
+	return filepath.Join(home, constants.DefaultSkaffoldDir, constants.DefaultCacheFile), nil
+}
+
+// Load reads the cache stored in a given file.
+// A missing file is an empty cache.
+func Load(file string) (*Cache, error) {
+	c := &Cache{
+		file:      file,
+		artifacts: map[string]Entry{},
+	}
+
+	contents, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cache")
+	}
+
+	if err := yaml.Unmarshal(contents, &c.artifacts); err != nil {
+		return nil, errors.Wrap(err, "parsing cache")
+	}
+
+	return c, nil
+}
+
+// Purge deletes the cache stored in a given file.
+func Purge(file string) error {
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "purging cache")
+	}
+
+	return nil
+}
+
+// Get returns the image cached for a given hash.
+func (c *Cache) Get(hash string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, present := c.artifacts[hash]
+	return entry, present
+}
+
+// Put caches the image built for a given hash.
+func (c *Cache) Put(hash string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.artifacts[hash] = entry
+	c.dirty = true
+}
+
+// Save writes the cache to its file, if it has changed.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	contents, err := yaml.Marshal(c.artifacts)
+	if err != nil {
+		return errors.Wrap(err, "marshalling cache")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		return errors.Wrap(err, "creating cache directory")
+	}
+	if err := ioutil.WriteFile(c.file, contents, 0644); err != nil {
+		return errors.Wrap(err, "writing cache")
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Hash computes a hash of everything that goes into building an artifact:
+// its configuration, the contents of its dependencies and a set of
+// builder specific options.
+func Hash(a *v1alpha3.Artifact, options ...string) (string, error) {
+	config, err := yaml.Marshal(a)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling artifact")
+	}
+
+	deps, err := dependenciesForArtifact(a)
+	if err != nil {
+		return "", errors.Wrap(err, "listing dependencies")
+	}
+	sort.Strings(deps)
+
+	hasher := sha256.New()
+	hasher.Write(config)
+	for _, option := range options {
+		io.WriteString(hasher, option)
+	}
+
+	for _, dep := range deps {
+		rel, err := filepath.Rel(a.Workspace, dep)
+		if err != nil {
+			return "", errors.Wrapf(err, "finding %s relative to workspace", dep)
+		}
+		io.WriteString(hasher, filepath.ToSlash(rel))
+
+		if err := hashFile(hasher, dep); err != nil {
+			return "", errors.Wrapf(err, "hashing %s", dep)
+		}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	_, err = w.Write(h.Sum(nil))
+	return err
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestLoadSavePurge(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(tmpDir, "home", ".skaffold", "cache")
+
+	c, err := Load(file)
+	testutil.CheckError(t, false, err)
+	_, present := c.Get("hash")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, present)
+
+	c.Put("hash", Entry{Digest: "sha256:abacab"})
+	testutil.CheckError(t, false, c.Save())
+
+	c, err = Load(file)
+	testutil.CheckError(t, false, err)
+	entry, present := c.Get("hash")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, present)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, Entry{Digest: "sha256:abacab"}, entry)
+
+	testutil.CheckError(t, false, Purge(file))
+	testutil.CheckError(t, false, Purge(file))
+
+	c, err = Load(file)
+	testutil.CheckError(t, false, err)
+	_, present = c.Get("hash")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, present)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(tmpDir, "cache")
+	ioutil.WriteFile(file, []byte("invalid"), 0644)
+
+	_, err := Load(file)
+	testutil.CheckError(t, true, err)
+}
+
+func TestHash(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	dockerfile := filepath.Join(tmpDir, "Dockerfile")
+	source := filepath.Join(tmpDir, "main.go")
+	ioutil.WriteFile(dockerfile, []byte("FROM scratch"), 0644)
+	ioutil.WriteFile(source, []byte("package main"), 0644)
+
+	defer func(f func(*v1alpha3.Artifact) ([]string, error)) { dependenciesForArtifact = f }(dependenciesForArtifact)
+	dependenciesForArtifact = func(*v1alpha3.Artifact) ([]string, error) {
+		return []string{source, dockerfile}, nil
+	}
+
+	artifact := &v1alpha3.Artifact{
+		ImageName: "gcr.io/project/app",
+		Workspace: tmpDir,
+		ArtifactType: v1alpha3.ArtifactType{
+			DockerArtifact: &v1alpha3.DockerArtifact{DockerfilePath: "Dockerfile"},
+		},
+	}
+
+	hash := func(a *v1alpha3.Artifact, options ...string) string {
+		h, err := Hash(a, options...)
+		testutil.CheckError(t, false, err)
+		return h
+	}
+
+	initial := hash(artifact)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, initial, hash(artifact))
+
+	// Builder options
+	if hash(artifact, "push=true") == initial {
+		t.Error("hash should depend on the builder options")
+	}
+
+	// Configuration
+	other := *artifact
+	other.ImageName = "gcr.io/project/other"
+	if hash(&other) == initial {
+		t.Error("hash should depend on the configuration")
+	}
+
+	// Dependencies
+	ioutil.WriteFile(source, []byte("package main\n\nfunc main() {}"), 0644)
+	if hash(artifact) == initial {
+		t.Error("hash should depend on the content of the dependencies")
+	}
+}
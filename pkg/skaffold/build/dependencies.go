@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/custom"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/jib"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+)
+
+// DependenciesForArtifact lists the files an artifact depends on.
+// All paths are prefixed with the artifact's workspace.
+func DependenciesForArtifact(a *v1alpha3.Artifact) ([]string, error) {
+	var (
+		paths []string
+		err   error
+	)
+
+	switch {
+	case a.DockerArtifact != nil:
+		paths, err = docker.GetDependencies(a.Workspace, a.DockerArtifact)
+
+	case a.BazelArtifact != nil:
+		paths, err = bazel.GetDependencies(a.Workspace, a.BazelArtifact)
+
+	case a.JibMavenArtifact != nil:
+		paths, err = jib.GetDependenciesMaven(a.Workspace, a.JibMavenArtifact)
+
+	case a.JibGradleArtifact != nil:
+		paths, err = jib.GetDependenciesGradle(a.Workspace, a.JibGradleArtifact)
+
+	case a.CustomArtifact != nil:
+		paths, err = custom.GetDependencies(a.Workspace, a.CustomArtifact)
+
+	default:
+		return nil, fmt.Errorf("undefined artifact type: %+v", a.ArtifactType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var p []string
+	for _, path := range paths {
+		p = append(p, filepath.Join(a.Workspace, path))
+	}
+	return p, nil
+}
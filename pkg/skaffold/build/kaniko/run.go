@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os/exec"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -28,7 +29,7 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -37,17 +38,30 @@ import (
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
-const kanikoContainerName = "kaniko"
+const (
+	kanikoContainerName = "kaniko"
+	initContainerName   = "kaniko-init-container"
 
-func runKaniko(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact, cfg *v1alpha2.KanikoBuild) (string, error) {
-	dockerfilePath := artifact.DockerArtifact.DockerfilePath
+	// The build context is copied into an emptyDir volume
+	// when it's not uploaded to Google Cloud Storage.
+	contextVolumeName = "kaniko-build-context"
+	contextMountPath  = "/kaniko/buildcontext"
+	contextReadyFile  = "/tmp/context-ready"
+)
 
+func runKaniko(ctx context.Context, out io.Writer, artifact *v1alpha3.Artifact, cfg *v1alpha3.KanikoBuild, kubeContext string) (string, error) {
 	initialTag := util.RandomID()
-	tarName := fmt.Sprintf("context-%s.tar.gz", initialTag)
-	if err := docker.UploadContextToGCS(ctx, artifact.Workspace, artifact.DockerArtifact, cfg.GCSBucket, tarName); err != nil {
-		return "", errors.Wrap(err, "uploading tar to gcs")
+
+	buildContext := contextMountPath
+	if cfg.GCSBucket != "" {
+		tarName := fmt.Sprintf("context-%s.tar.gz", initialTag)
+		if err := docker.UploadContextToGCS(ctx, artifact.Workspace, artifact.DockerArtifact, cfg.GCSBucket, tarName); err != nil {
+			return "", errors.Wrap(err, "uploading tar to gcs")
+		}
+		defer gcsDelete(ctx, cfg.GCSBucket, tarName)
+
+		buildContext = fmt.Sprintf("gs://%s/%s", cfg.GCSBucket, tarName)
 	}
-	defer gcsDelete(ctx, cfg.GCSBucket, tarName)
 
 	client, err := kubernetes.GetClientset()
 	if err != nil {
@@ -57,47 +71,14 @@ func runKaniko(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact,
 
 	imageDst := fmt.Sprintf("%s:%s", artifact.ImageName, initialTag)
 	args := []string{
-		fmt.Sprintf("--dockerfile=%s", dockerfilePath),
-		fmt.Sprintf("--context=gs://%s/%s", cfg.GCSBucket, tarName),
+		fmt.Sprintf("--dockerfile=%s", artifact.DockerArtifact.DockerfilePath),
+		fmt.Sprintf("--context=%s", buildContext),
 		fmt.Sprintf("--destination=%s", imageDst),
 		fmt.Sprintf("-v=%s", logrus.GetLevel().String()),
 	}
 	args = append(args, docker.GetBuildArgs(artifact.DockerArtifact)...)
 
-	p, err := pods.Create(&v1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "kaniko",
-			Labels:       map[string]string{"skaffold-kaniko": "skaffold-kaniko"},
-			Namespace:    cfg.Namespace,
-		},
-		Spec: v1.PodSpec{
-			Containers: []v1.Container{
-				{
-					Name:            kanikoContainerName,
-					Image:           constants.DefaultKanikoImage,
-					ImagePullPolicy: v1.PullIfNotPresent,
-					Args:            args,
-					VolumeMounts: []v1.VolumeMount{{
-						Name:      constants.DefaultKanikoSecretName,
-						MountPath: "/secret",
-					}},
-					Env: []v1.EnvVar{{
-						Name:  "GOOGLE_APPLICATION_CREDENTIALS",
-						Value: "/secret/kaniko-secret",
-					}},
-				},
-			},
-			Volumes: []v1.Volume{{
-				Name: constants.DefaultKanikoSecretName,
-				VolumeSource: v1.VolumeSource{
-					Secret: &v1.SecretVolumeSource{
-						SecretName: cfg.PullSecretName,
-					},
-				},
-			}},
-			RestartPolicy: v1.RestartPolicyNever,
-		},
-	})
+	p, err := pods.Create(kanikoPod(cfg, args))
 	if err != nil {
 		return "", errors.Wrap(err, "creating kaniko pod")
 	}
@@ -117,6 +98,16 @@ func runKaniko(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact,
 		return "", errors.Wrap(err, "parsing timeout")
 	}
 
+	if cfg.GCSBucket == "" {
+		if err := kubernetes.WaitForPodInitContainerRunning(pods, p.Name, initContainerName, timeout); err != nil {
+			return "", errors.Wrap(err, "waiting for pod to initialize")
+		}
+
+		if err := copyBuildContext(ctx, artifact, p, kubeContext); err != nil {
+			return "", errors.Wrap(err, "copying build context")
+		}
+	}
+
 	if err := kubernetes.WaitForPodComplete(pods, p.Name, timeout); err != nil {
 		return "", errors.Wrap(err, "waiting for pod to complete")
 	}
@@ -126,6 +117,97 @@ func runKaniko(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact,
 	return imageDst, nil
 }
 
+func kanikoPod(cfg *v1alpha3.KanikoBuild, args []string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kaniko",
+			Labels:       map[string]string{"skaffold-kaniko": "skaffold-kaniko"},
+			Namespace:    cfg.Namespace,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:            kanikoContainerName,
+					Image:           constants.DefaultKanikoImage,
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Args:            args,
+				},
+			},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+
+	// Without a secret, kaniko can only push to registries that don't need credentials.
+	if cfg.PullSecretName != "" {
+		pod.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{{
+			Name:      constants.DefaultKanikoSecretName,
+			MountPath: "/secret",
+		}}
+		pod.Spec.Containers[0].Env = []v1.EnvVar{{
+			Name:  "GOOGLE_APPLICATION_CREDENTIALS",
+			Value: "/secret/kaniko-secret",
+		}}
+		pod.Spec.Volumes = []v1.Volume{{
+			Name: constants.DefaultKanikoSecretName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: cfg.PullSecretName,
+				},
+			},
+		}}
+	}
+
+	if cfg.GCSBucket != "" {
+		return pod
+	}
+
+	// The init container waits for the build context to be copied
+	// into a volume shared with the kaniko container.
+	contextMount := v1.VolumeMount{
+		Name:      contextVolumeName,
+		MountPath: contextMountPath,
+	}
+	pod.Spec.InitContainers = []v1.Container{{
+		Name:            initContainerName,
+		Image:           constants.DefaultBusyboxImage,
+		ImagePullPolicy: v1.PullIfNotPresent,
+		Command:         []string{"sh", "-c", fmt.Sprintf("while [ ! -f %s ]; do sleep 1; done", contextReadyFile)},
+		VolumeMounts:    []v1.VolumeMount{contextMount},
+	}}
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, contextMount)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: contextVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+
+	return pod
+}
+
+// copyBuildContext streams the tarred build context to the init container
+// and then tells it that the context is ready.
+func copyBuildContext(ctx context.Context, artifact *v1alpha3.Artifact, p *v1.Pod, kubeContext string) error {
+	r, w := io.Pipe()
+	defer r.Close()
+	go func() {
+		w.CloseWithError(docker.CreateDockerTarGzContext(w, artifact.Workspace, artifact.DockerArtifact))
+	}()
+
+	untar := exec.CommandContext(ctx, "kubectl", "--context", kubeContext, "exec", "-i", p.Name, "--namespace", p.Namespace, "-c", initContainerName, "--", "tar", "-xzf", "-", "-C", contextMountPath)
+	untar.Stdin = r
+	if err := util.RunCmd(untar); err != nil {
+		return errors.Wrap(err, "sending build context")
+	}
+
+	ready := exec.CommandContext(ctx, "kubectl", "--context", kubeContext, "exec", p.Name, "--namespace", p.Namespace, "-c", initContainerName, "--", "touch", contextReadyFile)
+	if err := util.RunCmd(ready); err != nil {
+		return errors.Wrap(err, "notifying init container")
+	}
+
+	return nil
+}
+
 func streamLogs(out io.Writer, name string, pods corev1.PodInterface) func() {
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestKanikoPod(t *testing.T) {
+	var tests = []struct {
+		description            string
+		cfg                    *v1alpha3.KanikoBuild
+		expectedInitContainers int
+		expectedVolumes        []string
+		expectedMounts         []string
+	}{
+		{
+			description:     "context in gcs",
+			cfg:             &v1alpha3.KanikoBuild{GCSBucket: "bucket", PullSecretName: "secret"},
+			expectedVolumes: []string{"kaniko-secret"},
+			expectedMounts:  []string{"/secret"},
+		},
+		{
+			description:            "local context",
+			cfg:                    &v1alpha3.KanikoBuild{PullSecretName: "secret"},
+			expectedInitContainers: 1,
+			expectedVolumes:        []string{"kaniko-secret", contextVolumeName},
+			expectedMounts:         []string{"/secret", contextMountPath},
+		},
+		{
+			description:            "local context without secret",
+			cfg:                    &v1alpha3.KanikoBuild{},
+			expectedInitContainers: 1,
+			expectedVolumes:        []string{contextVolumeName},
+			expectedMounts:         []string{contextMountPath},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pod := kanikoPod(test.cfg, []string{"--context=ctx"})
+
+			var volumes, mounts []string
+			for _, v := range pod.Spec.Volumes {
+				volumes = append(volumes, v.Name)
+			}
+			for _, m := range pod.Spec.Containers[0].VolumeMounts {
+				mounts = append(mounts, m.MountPath)
+			}
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedInitContainers, len(pod.Spec.InitContainers))
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedVolumes, volumes)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedMounts, mounts)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"--context=ctx"}, pod.Spec.Containers[0].Args)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.cfg.PullSecretName != "", len(pod.Spec.Containers[0].Env) > 0)
+		})
+	}
+}
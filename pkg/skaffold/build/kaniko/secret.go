@@ -35,6 +35,11 @@ func (b *Builder) setupSecret() (func(), error) {
 
 	secrets := client.CoreV1().Secrets(b.Namespace)
 
+	if b.PullSecretName == "" {
+		logrus.Debug("No pull secret configured.")
+		return func() {}, nil
+	}
+
 	if b.PullSecret == "" {
 		logrus.Debug("No pull secret specified. Checking for one in the cluster.")
 
@@ -18,18 +18,21 @@ package kaniko
 
 import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 )
 
 // Builder builds docker artifacts on Kubernetes, using Kaniko.
 type Builder struct {
-	*v1alpha2.KanikoBuild
+	*v1alpha3.KanikoBuild
+
+	kubeContext string
 }
 
 // NewBuilder creates a new Builder that builds artifacts with Kaniko.
-func NewBuilder(cfg *v1alpha2.KanikoBuild) *Builder {
+func NewBuilder(cfg *v1alpha3.KanikoBuild, kubeContext string) *Builder {
 	return &Builder{
 		KanikoBuild: cfg,
+		kubeContext: kubeContext,
 	}
 }
 
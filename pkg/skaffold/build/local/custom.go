@@ -0,0 +1,53 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/custom"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+func (b *Builder) buildCustom(ctx context.Context, out io.Writer, artifact *v1alpha3.Artifact) (string, error) {
+	initialTag := fmt.Sprintf("%s:%s", artifact.ImageName, util.RandomID())
+
+	if err := runCustom(ctx, out, artifact, initialTag, false); err != nil {
+		return "", err
+	}
+
+	return initialTag, nil
+}
+
+func runCustom(ctx context.Context, out io.Writer, artifact *v1alpha3.Artifact, image string, pushImage bool) error {
+	cmd, err := custom.BuildCommand(ctx, artifact.Workspace, artifact.CustomArtifact, image, pushImage)
+	if err != nil {
+		return errors.Wrap(err, "creating build command")
+	}
+
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrap(err, "running build script")
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/jib"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+func isJib(artifact *v1alpha3.Artifact) bool {
+	return artifact.JibMavenArtifact != nil || artifact.JibGradleArtifact != nil
+}
+
+func (b *Builder) buildJibMaven(ctx context.Context, out io.Writer, workspace string, a *v1alpha3.JibMavenArtifact) (string, error) {
+	initialTag := util.RandomID()
+
+	cmd := jib.MavenCommand(ctx, workspace, a, "prepare-package", "jib:dockerBuild", "-Dimage="+initialTag)
+	if err := runJib(out, cmd); err != nil {
+		return "", errors.Wrap(err, "running maven build")
+	}
+
+	return fmt.Sprintf("%s:latest", initialTag), nil
+}
+
+func (b *Builder) buildJibGradle(ctx context.Context, out io.Writer, workspace string, a *v1alpha3.JibGradleArtifact) (string, error) {
+	initialTag := util.RandomID()
+
+	cmd := jib.GradleCommand(ctx, workspace, a, "jibDockerBuild", "--image="+initialTag)
+	if err := runJib(out, cmd); err != nil {
+		return "", errors.Wrap(err, "running gradle build")
+	}
+
+	return fmt.Sprintf("%s:latest", initialTag), nil
+}
+
+// jibCommandAndPush returns the command that lets Jib push the image
+// directly to the registry, without going through the local docker daemon.
+func jibCommandAndPush(ctx context.Context, artifact *v1alpha3.Artifact, image string) *exec.Cmd {
+	if artifact.JibMavenArtifact != nil {
+		return jib.MavenCommand(ctx, artifact.Workspace, artifact.JibMavenArtifact, "prepare-package", "jib:build", "-Dimage="+image)
+	}
+	return jib.GradleCommand(ctx, artifact.Workspace, artifact.JibGradleArtifact, "jib", "--image="+image)
+}
+
+func runJib(out io.Writer, cmd *exec.Cmd) error {
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	return util.RunCmd(cmd)
+}
@@ -22,16 +22,20 @@ import (
 	"io"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // Build runs a docker build on the host and tags the resulting image with
 // its checksum. It streams build progress to the writer argument.
-func (b *Builder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]build.Artifact, error) {
+func (b *Builder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha3.Artifact) ([]build.Artifact, error) {
 	if b.localCluster {
 		if _, err := color.Default.Fprintf(out, "Found [%s] context, using local docker daemon.\n", b.kubeContext); err != nil {
 			return nil, errors.Wrap(err, "writing status")
@@ -39,40 +43,116 @@ func (b *Builder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, a
 	}
 	defer b.api.Close()
 
-	// TODO(dgageot): parallel builds
-	return build.InSequence(ctx, out, tagger, artifacts, b.buildArtifact)
+	if b.cache != nil {
+		defer func() {
+			if err := b.cache.Save(); err != nil {
+				logrus.Warnln("Unable to save the artifact cache:", err)
+			}
+		}()
+	}
+
+	concurrency := constants.DefaultLocalConcurrency
+	if b.cfg.Concurrency != nil {
+		concurrency = *b.cfg.Concurrency
+	}
+
+	if concurrency == 1 {
+		return build.InSequence(ctx, out, tagger, artifacts, b.buildArtifact)
+	}
+	return build.InParallel(ctx, out, tagger, artifacts, b.buildArtifact, concurrency)
 }
 
-func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha2.Artifact) (string, error) {
-	initialTag, err := b.runBuildForArtifact(ctx, out, artifact)
-	if err != nil {
-		return "", errors.Wrap(err, "build artifact")
+func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+	if b.cache == nil {
+		return b.buildAndTag(ctx, out, tagger, artifact)
 	}
 
-	digest, err := docker.Digest(ctx, b.api, initialTag)
+	hash, err := cache.Hash(artifact, fmt.Sprintf("push=%t", b.pushImages))
 	if err != nil {
-		return "", errors.Wrapf(err, "getting digest: %s", initialTag)
+		logrus.Warnf("Unable to hash [%s], skipping the artifact cache: %s", artifact.ImageName, err)
+		return b.buildAndTag(ctx, out, tagger, artifact)
 	}
-	if digest == "" {
-		return "", fmt.Errorf("digest not found")
+
+	if entry, present := b.cache.Get(hash); present {
+		tag, err := b.retrieveCachedArtifact(ctx, out, tagger, artifact, entry)
+		if err == nil {
+			color.Default.Fprintf(out, "Found [%s] in cache\n", artifact.ImageName)
+			return tag, nil
+		}
+		logrus.Debugf("Cached image for [%s] can't be used: %s", artifact.ImageName, err)
 	}
 
-	if b.alreadyTagged == nil {
-		b.alreadyTagged = make(map[string]string)
+	tag, err := b.buildAndTag(ctx, out, tagger, artifact)
+	if err != nil {
+		return "", err
 	}
-	if tag, present := b.alreadyTagged[digest]; present {
+
+	entry, err := b.cacheEntry(ctx, artifact, tag)
+	if err != nil {
+		logrus.Warnf("Unable to cache [%s]: %s", artifact.ImageName, err)
 		return tag, nil
 	}
 
+	b.cache.Put(hash, entry)
+	return tag, nil
+}
+
+func (b *Builder) cacheEntry(ctx context.Context, artifact *v1alpha3.Artifact, tag string) (cache.Entry, error) {
+	var entry cache.Entry
+
+	if b.pushImages {
+		remoteDigest, err := docker.RemoteDigest(tag)
+		if err != nil {
+			return entry, errors.Wrap(err, "getting remote digest")
+		}
+		entry.RemoteDigest = remoteDigest
+
+		// Images pushed directly are not in the local docker daemon.
+		if pushesDirectly(artifact) {
+			entry.Digest = remoteDigest
+			return entry, nil
+		}
+	}
+
+	digest, err := docker.Digest(ctx, b.api, tag)
+	if err != nil {
+		return entry, errors.Wrap(err, "getting digest")
+	}
+	if digest == "" {
+		return entry, fmt.Errorf("digest not found")
+	}
+	entry.Digest = digest
+
+	return entry, nil
+}
+
+// retrieveCachedArtifact tags, and pushes if needed, the image previously built
+// from the same inputs. It fails if the image is neither in the registry nor
+// in the local docker daemon anymore.
+func (b *Builder) retrieveCachedArtifact(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact, entry cache.Entry) (string, error) {
 	tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.Options{
 		ImageName: artifact.ImageName,
-		Digest:    digest,
+		Digest:    entry.Digest,
 	})
 	if err != nil {
 		return "", errors.Wrap(err, "generating tag")
 	}
 
-	if err := b.api.ImageTag(ctx, initialTag, tag); err != nil {
+	if b.pushImages {
+		if remoteDigest, err := docker.RemoteDigest(tag); err == nil && remoteDigest == entry.RemoteDigest {
+			return tag, nil
+		}
+	}
+
+	localDigest, err := docker.Digest(ctx, b.api, entry.Digest)
+	if err != nil {
+		return "", errors.Wrap(err, "getting local digest")
+	}
+	if localDigest == "" {
+		return "", fmt.Errorf("image %s not found", entry.Digest)
+	}
+
+	if err := b.api.ImageTag(ctx, entry.Digest, tag); err != nil {
 		return "", errors.Wrap(err, "tagging")
 	}
 
@@ -82,12 +162,86 @@ func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, tagger tag.T
 		}
 	}
 
-	b.alreadyTagged[digest] = tag
-
 	return tag, nil
 }
 
-func (b *Builder) runBuildForArtifact(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (string, error) {
+func (b *Builder) buildAndTag(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+	if b.pushImages && pushesDirectly(artifact) {
+		return b.buildAndPushDirectly(ctx, out, tagger, artifact)
+	}
+
+	initialTag, err := b.runBuildForArtifact(ctx, out, artifact)
+	if err != nil {
+		return "", errors.Wrap(err, "build artifact")
+	}
+
+	digest, err := docker.Digest(ctx, b.api, initialTag)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting digest: %s", initialTag)
+	}
+	if digest == "" {
+		return "", fmt.Errorf("digest not found")
+	}
+
+	return b.tagOnce(ctx, digest, func() (string, error) {
+		tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.Options{
+			ImageName: artifact.ImageName,
+			Digest:    digest,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "generating tag")
+		}
+
+		if err := b.api.ImageTag(ctx, initialTag, tag); err != nil {
+			return "", errors.Wrap(err, "tagging")
+		}
+
+		if b.pushImages {
+			if err := docker.RunPush(ctx, b.api, tag, out); err != nil {
+				return "", errors.Wrap(err, "pushing")
+			}
+		}
+
+		return tag, nil
+	})
+}
+
+// tagOnce tags, and pushes, an image with the given digest only once, even when it's
+// built for several artifacts at the same time. Images with different digests are
+// tagged and pushed in parallel. A failed tagging is tried again the next time.
+func (b *Builder) tagOnce(ctx context.Context, digest string, tagAndPush func() (string, error)) (string, error) {
+	b.tagsMu.Lock()
+	if b.alreadyTagged == nil {
+		b.alreadyTagged = make(map[string]*tagging)
+	}
+	t, present := b.alreadyTagged[digest]
+	if !present {
+		t = &tagging{done: make(chan struct{})}
+		b.alreadyTagged[digest] = t
+	}
+	b.tagsMu.Unlock()
+
+	if present {
+		select {
+		case <-t.done:
+			return t.tag, t.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	t.tag, t.err = tagAndPush()
+	if t.err != nil {
+		b.tagsMu.Lock()
+		delete(b.alreadyTagged, digest)
+		b.tagsMu.Unlock()
+	}
+	close(t.done)
+
+	return t.tag, t.err
+}
+
+func (b *Builder) runBuildForArtifact(ctx context.Context, out io.Writer, artifact *v1alpha3.Artifact) (string, error) {
 	switch {
 	case artifact.DockerArtifact != nil:
 		return b.buildDocker(ctx, out, artifact.Workspace, artifact.DockerArtifact)
@@ -95,7 +249,57 @@ func (b *Builder) runBuildForArtifact(ctx context.Context, out io.Writer, artifa
 	case artifact.BazelArtifact != nil:
 		return b.buildBazel(ctx, out, artifact.Workspace, artifact.BazelArtifact)
 
+	case artifact.JibMavenArtifact != nil:
+		return b.buildJibMaven(ctx, out, artifact.Workspace, artifact.JibMavenArtifact)
+
+	case artifact.JibGradleArtifact != nil:
+		return b.buildJibGradle(ctx, out, artifact.Workspace, artifact.JibGradleArtifact)
+
+	case artifact.CustomArtifact != nil:
+		return b.buildCustom(ctx, out, artifact)
+
 	default:
 		return "", fmt.Errorf("undefined artifact type: %+v", artifact.ArtifactType)
 	}
 }
+
+// pushesDirectly tells if the artifact's builder can push images to the
+// registry without going through the local docker daemon.
+func pushesDirectly(artifact *v1alpha3.Artifact) bool {
+	return isJib(artifact) || artifact.CustomArtifact != nil
+}
+
+// buildAndPushDirectly is used for the builders that can push images
+// to the registry without going through the local docker daemon.
+func (b *Builder) buildAndPushDirectly(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+	initialTag := fmt.Sprintf("%s:%s", artifact.ImageName, util.RandomID())
+
+	if artifact.CustomArtifact != nil {
+		if err := runCustom(ctx, out, artifact, initialTag, true); err != nil {
+			return "", errors.Wrap(err, "build artifact")
+		}
+	} else {
+		if err := runJib(out, jibCommandAndPush(ctx, artifact, initialTag)); err != nil {
+			return "", errors.Wrap(err, "build artifact")
+		}
+	}
+
+	digest, err := docker.RemoteDigest(initialTag)
+	if err != nil {
+		return "", errors.Wrap(err, "getting digest")
+	}
+
+	tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.Options{
+		ImageName: artifact.ImageName,
+		Digest:    digest,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "generating tag")
+	}
+
+	if err := docker.AddTag(initialTag, tag); err != nil {
+		return "", errors.Wrap(err, "tagging image")
+	}
+
+	return tag, nil
+}
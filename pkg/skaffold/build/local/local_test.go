@@ -22,12 +22,15 @@ import (
 	"io"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	"github.com/docker/docker/api/types"
@@ -68,11 +71,11 @@ func TestLocalRun(t *testing.T) {
 
 	var tests = []struct {
 		description  string
-		config       *v1alpha2.LocalBuild
+		config       *v1alpha3.LocalBuild
 		out          io.Writer
 		api          docker.APIClient
 		tagger       tag.Tagger
-		artifacts    []*v1alpha2.Artifact
+		artifacts    []*v1alpha3.Artifact
 		expected     []build.Artifact
 		localCluster bool
 		shouldErr    bool
@@ -80,15 +83,15 @@ func TestLocalRun(t *testing.T) {
 		{
 			description: "single build",
 			out:         ioutil.Discard,
-			config: &v1alpha2.LocalBuild{
+			config: &v1alpha3.LocalBuild{
 				SkipPush: util.BoolPtr(false),
 			},
-			artifacts: []*v1alpha2.Artifact{
+			artifacts: []*v1alpha3.Artifact{
 				{
 					ImageName: "gcr.io/test/image",
 					Workspace: tmp,
-					ArtifactType: v1alpha2.ArtifactType{
-						DockerArtifact: &v1alpha2.DockerArtifact{},
+					ArtifactType: v1alpha3.ArtifactType{
+						DockerArtifact: &v1alpha3.DockerArtifact{},
 					},
 				},
 			},
@@ -104,16 +107,16 @@ func TestLocalRun(t *testing.T) {
 		{
 			description: "subset build",
 			out:         ioutil.Discard,
-			config: &v1alpha2.LocalBuild{
+			config: &v1alpha3.LocalBuild{
 				SkipPush: util.BoolPtr(true),
 			},
 			tagger: &tag.ChecksumTagger{},
-			artifacts: []*v1alpha2.Artifact{
+			artifacts: []*v1alpha3.Artifact{
 				{
 					ImageName: "gcr.io/test/image",
 					Workspace: tmp,
-					ArtifactType: v1alpha2.ArtifactType{
-						DockerArtifact: &v1alpha2.DockerArtifact{},
+					ArtifactType: v1alpha3.ArtifactType{
+						DockerArtifact: &v1alpha3.DockerArtifact{},
 					},
 				},
 			},
@@ -128,14 +131,15 @@ func TestLocalRun(t *testing.T) {
 		{
 			description:  "local cluster bad writer",
 			out:          &testutil.BadWriter{},
-			config:       &v1alpha2.LocalBuild{},
+			config:       &v1alpha3.LocalBuild{},
 			shouldErr:    true,
 			localCluster: true,
 		},
 		{
 			description: "error image build",
+			config:      &v1alpha3.LocalBuild{},
 			out:         ioutil.Discard,
-			artifacts:   []*v1alpha2.Artifact{{}},
+			artifacts:   []*v1alpha3.Artifact{{}},
 			tagger:      &tag.ChecksumTagger{},
 			api: testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{
 				ErrImageBuild: true,
@@ -144,8 +148,9 @@ func TestLocalRun(t *testing.T) {
 		},
 		{
 			description: "error image tag",
+			config:      &v1alpha3.LocalBuild{},
 			out:         ioutil.Discard,
-			artifacts:   []*v1alpha2.Artifact{{}},
+			artifacts:   []*v1alpha3.Artifact{{}},
 			tagger:      &tag.ChecksumTagger{},
 			api: testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{
 				ErrImageTag: true,
@@ -154,16 +159,18 @@ func TestLocalRun(t *testing.T) {
 		},
 		{
 			description: "bad writer",
+			config:      &v1alpha3.LocalBuild{},
 			out:         &testutil.BadWriter{},
-			artifacts:   []*v1alpha2.Artifact{{}},
+			artifacts:   []*v1alpha3.Artifact{{}},
 			tagger:      &tag.ChecksumTagger{},
 			api:         testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{}),
 			shouldErr:   true,
 		},
 		{
 			description: "error image inspect",
+			config:      &v1alpha3.LocalBuild{},
 			out:         &testutil.BadWriter{},
-			artifacts:   []*v1alpha2.Artifact{{}},
+			artifacts:   []*v1alpha3.Artifact{{}},
 			tagger:      &tag.ChecksumTagger{},
 			api: testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{
 				ErrImageInspect: true,
@@ -172,8 +179,9 @@ func TestLocalRun(t *testing.T) {
 		},
 		{
 			description: "error tagger",
+			config:      &v1alpha3.LocalBuild{},
 			out:         ioutil.Discard,
-			artifacts:   []*v1alpha2.Artifact{{}},
+			artifacts:   []*v1alpha3.Artifact{{}},
 			tagger:      &FakeTagger{Err: fmt.Errorf("")},
 			api:         testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{}),
 			shouldErr:   true,
@@ -193,3 +201,117 @@ func TestLocalRun(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalBuildCache(t *testing.T) {
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	ioutil.WriteFile(filepath.Join(tmp, "Dockerfile"), []byte("FROM scratch"), 0640)
+
+	artifactCache, err := cache.Load(filepath.Join(tmp, "cache"))
+	testutil.CheckError(t, false, err)
+
+	artifacts := []*v1alpha3.Artifact{{
+		ImageName: "gcr.io/test/image",
+		Workspace: tmp,
+		ArtifactType: v1alpha3.ArtifactType{
+			DockerArtifact: &v1alpha3.DockerArtifact{DockerfilePath: "Dockerfile"},
+		},
+	}}
+	expected := []build.Artifact{{
+		ImageName: "gcr.io/test/image",
+		Tag:       "gcr.io/test/image:imageid",
+	}}
+
+	// First build populates the cache
+	l := Builder{
+		cfg:   &v1alpha3.LocalBuild{},
+		api:   testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{}),
+		cache: artifactCache,
+	}
+	res, err := l.Build(context.Background(), ioutil.Discard, &tag.ChecksumTagger{}, artifacts)
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, res)
+
+	// Second build reuses the image, without building it
+	artifactCache, err = cache.Load(filepath.Join(tmp, "cache"))
+	testutil.CheckError(t, false, err)
+
+	l = Builder{
+		cfg: &v1alpha3.LocalBuild{},
+		api: testutil.NewFakeImageAPIClient(map[string]string{"sha256:imageid": "imageid"}, &testutil.FakeImageAPIOptions{
+			ErrImageBuild: true,
+		}),
+		cache: artifactCache,
+	}
+	res, err = l.Build(context.Background(), ioutil.Discard, &tag.ChecksumTagger{}, artifacts)
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, res)
+
+	// Changed artifacts are rebuilt
+	ioutil.WriteFile(filepath.Join(tmp, "Dockerfile"), []byte("FROM busybox"), 0640)
+
+	res, err = l.Build(context.Background(), ioutil.Discard, &tag.ChecksumTagger{}, artifacts)
+	testutil.CheckError(t, true, err)
+}
+
+func TestTagOnce(t *testing.T) {
+	b := &Builder{}
+
+	// Two images with different digests are pushed at the same time.
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	push := func(tag string) func() (string, error) {
+		return func() (string, error) {
+			started <- struct{}{}
+			<-release
+			return tag, nil
+		}
+	}
+
+	results := make(chan string, 3)
+	for _, digest := range []string{"sha256:first", "sha256:second"} {
+		go func(digest string) {
+			tag, _ := b.tagOnce(context.Background(), digest, push("image@"+digest))
+			results <- tag
+		}(digest)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(10 * time.Second):
+			t.Fatal("pushes of different digests should run in parallel")
+		}
+	}
+
+	// The same digest waits for the push in flight instead of pushing again.
+	go func() {
+		tag, _ := b.tagOnce(context.Background(), "sha256:first", func() (string, error) {
+			t.Error("the same digest shouldn't be pushed twice")
+			return "", nil
+		})
+		results <- tag
+	}()
+
+	close(release)
+
+	var tags []string
+	for i := 0; i < 3; i++ {
+		tags = append(tags, <-results)
+	}
+	sort.Strings(tags)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"image@sha256:first", "image@sha256:first", "image@sha256:second"}, tags)
+}
+
+func TestTagOnceRetriesFailures(t *testing.T) {
+	b := &Builder{}
+
+	_, err := b.tagOnce(context.Background(), "sha256:digest", func() (string, error) {
+		return "", fmt.Errorf("push failed")
+	})
+	testutil.CheckError(t, true, err)
+
+	tag, err := b.tagOnce(context.Background(), "sha256:digest", func() (string, error) {
+		return "image@sha256:digest", nil
+	})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "image@sha256:digest", tag)
+}
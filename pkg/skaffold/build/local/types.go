@@ -19,28 +19,40 @@ package local
 import (
 	"context"
 	"fmt"
+	"sync"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 // Builder uses the host docker daemon to build and tag the image.
 type Builder struct {
-	cfg *v1alpha2.LocalBuild
+	cfg *v1alpha3.LocalBuild
 
 	api          docker.APIClient
 	localCluster bool
 	pushImages   bool
 	kubeContext  string
+	cache        *cache.Cache
 
-	alreadyTagged map[string]string
+	tagsMu        sync.Mutex
+	alreadyTagged map[string]*tagging
+}
+
+// tagging is the tagging, and pushing, of a built image. done is closed once it's over.
+type tagging struct {
+	done chan struct{}
+	tag  string
+	err  error
 }
 
 // NewBuilder returns an new instance of a local Builder.
-func NewBuilder(cfg *v1alpha2.LocalBuild, kubeContext string) (*Builder, error) {
+// Artifacts are not cached if artifactCache is nil.
+func NewBuilder(cfg *v1alpha3.LocalBuild, kubeContext string, artifactCache *cache.Cache) (*Builder, error) {
 	api, err := docker.NewAPIClient()
 	if err != nil {
 		return nil, errors.Wrap(err, "getting docker client")
@@ -61,6 +73,7 @@ func NewBuilder(cfg *v1alpha2.LocalBuild, kubeContext string) (*Builder, error)
 		api:          api,
 		localCluster: localCluster,
 		pushImages:   pushImages,
+		cache:        artifactCache,
 	}, nil
 }
 
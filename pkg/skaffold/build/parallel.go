@@ -21,54 +21,86 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/pkg/errors"
 )
 
 const bufferedLinesPerArtifact = 10000
 
-type artifactBuilder func(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha2.Artifact) (string, error)
+type artifactBuilder func(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error)
 
 // InParallel builds a list of artifacts in parallel but prints the logs in sequential order.
-func InParallel(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact, buildArtifact artifactBuilder) ([]Artifact, error) {
+// At most concurrency artifacts are built at the same time, or all of them if concurrency is 0.
+// As soon as a build fails, the other builds are cancelled.
+func InParallel(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha3.Artifact, buildArtifact artifactBuilder, concurrency int) ([]Artifact, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	n := len(artifacts)
+	if concurrency <= 0 || concurrency > n {
+		concurrency = n
+	}
+
 	tags := make([]string, n)
 	errs := make([]error, n)
 	outputs := make([]chan (string), n)
+	sem := make(chan struct{}, concurrency)
 
-	// Run builds in //
-	for index := range artifacts {
-		i := index
-		lines := make(chan (string), bufferedLinesPerArtifact)
-		outputs[i] = lines
-
-		r, w := io.Pipe()
-
-		go func() {
-			// Log to the pipe, output will be collected and printed later
-			fmt.Fprintf(w, "Building [%s]...\n", artifacts[i].ImageName)
-
-			tags[i], errs[i] = buildArtifact(ctx, w, tagger, artifacts[i])
-			w.Close()
-		}()
-
-		go func() {
-			scanner := bufio.NewScanner(r)
-			for scanner.Scan() {
-				lines <- scanner.Text()
-			}
-			close(lines)
-		}()
+	for i := range artifacts {
+		outputs[i] = make(chan (string), bufferedLinesPerArtifact)
 	}
 
+	// Run builds in //. They take a slot in the order their logs are printed in.
+	// Otherwise, builds blocked on printing their logs could hold all the slots,
+	// while the artifact whose logs are being printed waits for one.
+	go func() {
+		for index := range artifacts {
+			i := index
+			lines := outputs[i]
+
+			r, w := io.Pipe()
+			sem <- struct{}{}
+
+			go func() {
+				defer w.Close()
+				defer func() { <-sem }()
+
+				if ctx.Err() != nil {
+					errs[i] = ctx.Err()
+					return
+				}
+
+				// Log to the pipe, output will be collected and printed later
+				fmt.Fprintf(w, "Building [%s]...\n", artifacts[i].ImageName)
+				event.BuildInProgress(artifacts[i].ImageName)
+
+				tags[i], errs[i] = buildArtifact(ctx, w, tagger, artifacts[i])
+				if errs[i] != nil {
+					event.BuildFailed(artifacts[i].ImageName, errs[i])
+					cancel()
+				} else {
+					event.BuildComplete(artifacts[i].ImageName)
+				}
+			}()
+
+			go func() {
+				scanner := bufio.NewScanner(r)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+				close(lines)
+			}()
+		}
+	}()
+
 	// Print logs and collect results in order.
 	var built []Artifact
+	var failures []error
 
 	for i, artifact := range artifacts {
 		for line := range outputs[i] {
@@ -76,7 +108,11 @@ func InParallel(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts
 		}
 
 		if errs[i] != nil {
-			return nil, errors.Wrapf(errs[i], "building [%s]", artifact.ImageName)
+			// Builds cancelled because of another failure are not reported
+			if errors.Cause(errs[i]) != context.Canceled {
+				failures = append(failures, errors.Wrapf(errs[i], "building [%s]", artifact.ImageName))
+			}
+			continue
 		}
 
 		built = append(built, Artifact{
@@ -85,5 +121,30 @@ func InParallel(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts
 		})
 	}
 
+	switch {
+	case len(failures) == 1:
+		return nil, failures[0]
+	case len(failures) > 1:
+		return nil, buildFailures(failures)
+	case len(built) < n:
+		return nil, errors.Wrap(ctx.Err(), "building artifacts")
+	}
+
 	return built, nil
 }
+
+// buildFailures lists all the failed builds.
+// Its cause is the cause of the first failure.
+type buildFailures []error
+
+func (f buildFailures) Error() string {
+	var messages []string
+	for _, err := range f {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d artifacts failed to build:\n%s", len(f), strings.Join(messages, "\n"))
+}
+
+func (f buildFailures) Cause() error {
+	return errors.Cause(f[0])
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/pkg/errors"
+)
+
+var errCompilation = errors.New("compilation failed")
+
+func TestInParallel(t *testing.T) {
+	artifacts := []*v1alpha3.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+		{ImageName: "image3"},
+	}
+
+	var running, maxRunning int32
+	builder := func(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+
+		// Make the first artifact the slowest to build
+		if artifact.ImageName == "image1" {
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		fmt.Fprintf(out, "Built %s\n", artifact.ImageName)
+		return artifact.ImageName + ":tag", nil
+	}
+
+	var out bytes.Buffer
+	built, err := InParallel(context.Background(), &out, nil, artifacts, builder, 2)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []Artifact{
+		{ImageName: "image1", Tag: "image1:tag"},
+		{ImageName: "image2", Tag: "image2:tag"},
+		{ImageName: "image3", Tag: "image3:tag"},
+	}, built)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "Building [image1]...\nBuilt image1\nBuilding [image2]...\nBuilt image2\nBuilding [image3]...\nBuilt image3\n", out.String())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int32(2), maxRunning)
+}
+
+func TestInParallelMoreOutputThanBuffered(t *testing.T) {
+	var artifacts []*v1alpha3.Artifact
+	var expected []Artifact
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("image%d", i)
+		artifacts = append(artifacts, &v1alpha3.Artifact{ImageName: name})
+		expected = append(expected, Artifact{ImageName: name, Tag: name + ":tag"})
+	}
+
+	builder := func(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+		for i := 0; i < bufferedLinesPerArtifact+100; i++ {
+			fmt.Fprintf(out, "%s line %d\n", artifact.ImageName, i)
+		}
+		return artifact.ImageName + ":tag", nil
+	}
+
+	type result struct {
+		built []Artifact
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		built, err := InParallel(context.Background(), ioutil.Discard, nil, artifacts, builder, 2)
+		done <- result{built, err}
+	}()
+
+	select {
+	case r := <-done:
+		testutil.CheckErrorAndDeepEqual(t, false, r.err, expected, r.built)
+	case <-time.After(30 * time.Second):
+		t.Fatal("builds are deadlocked")
+	}
+}
+
+func TestInParallelFailFast(t *testing.T) {
+	artifacts := []*v1alpha3.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+		{ImageName: "image3"},
+	}
+
+	var started sync.WaitGroup
+	started.Add(len(artifacts))
+
+	builder := func(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+		started.Done()
+		started.Wait()
+
+		switch artifact.ImageName {
+		case "image1":
+			// Blocks until cancelled
+			<-ctx.Done()
+			return "", ctx.Err()
+		case "image2":
+			return "", errCompilation
+		default:
+			return "", errors.New("missing base image")
+		}
+	}
+
+	var out bytes.Buffer
+	_, err := InParallel(context.Background(), &out, nil, artifacts, builder, 0)
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, "2 artifacts failed to build:\nbuilding [image2]: compilation failed\nbuilding [image3]: missing base image", err.Error())
+	if errors.Cause(err) != errCompilation {
+		t.Errorf("Expected the first failure as cause. Got %v", errors.Cause(err))
+	}
+}
+
+func TestInParallelSingleFailure(t *testing.T) {
+	artifacts := []*v1alpha3.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+	}
+
+	builder := func(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha3.Artifact) (string, error) {
+		if artifact.ImageName == "image1" {
+			return "", errCompilation
+		}
+		return "image2:tag", nil
+	}
+
+	var out bytes.Buffer
+	_, err := InParallel(context.Background(), &out, nil, artifacts, builder, 1)
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, "building [image1]: compilation failed", err.Error())
+	if errors.Cause(err) != errCompilation {
+		t.Errorf("Expected the first failure as cause. Got %v", errors.Cause(err))
+	}
+}
@@ -20,18 +20,18 @@ import (
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 const (
 	minimalConfig = `
-apiVersion: skaffold/v1alpha2
+apiVersion: skaffold/v1alpha3
 kind: Config
 `
 	simpleConfig = `
-apiVersion: skaffold/v1alpha2
+apiVersion: skaffold/v1alpha3
 kind: Config
 build:
   tagPolicy:
@@ -42,7 +42,7 @@ deploy:
   kubectl: {}
 `
 	completeConfig = `
-apiVersion: skaffold/v1alpha2
+apiVersion: skaffold/v1alpha3
 kind: Config
 build:
   tagPolicy:
@@ -65,14 +65,20 @@ deploy:
    - svc.yaml
 `
 	minimalKanikoConfig = `
-apiVersion: skaffold/v1alpha2
+apiVersion: skaffold/v1alpha3
 kind: Config
 build:
   kaniko:
     gcsBucket: demo
+`
+	kanikoConfigWithoutBucket = `
+apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  kaniko: {}
 `
 	completeKanikoConfig = `
-apiVersion: skaffold/v1alpha2
+apiVersion: skaffold/v1alpha3
 kind: Config
 build:
   kaniko:
@@ -101,7 +107,7 @@ func TestParseConfig(t *testing.T) {
 			config:      minimalConfig,
 			expected: config(
 				withLocalBuild(
-					withTagPolicy(v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}}),
+					withTagPolicy(v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}}),
 				),
 			),
 		},
@@ -110,7 +116,7 @@ func TestParseConfig(t *testing.T) {
 			config:      simpleConfig,
 			expected: config(
 				withLocalBuild(
-					withTagPolicy(v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}}),
+					withTagPolicy(v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}}),
 					withDockerArtifact("example", ".", "Dockerfile"),
 				),
 				withKubectlDeploy("k8s/*.yaml"),
@@ -121,7 +127,7 @@ func TestParseConfig(t *testing.T) {
 			config:      completeConfig,
 			expected: config(
 				withGCBBuild("ID",
-					withTagPolicy(v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}}),
+					withTagPolicy(v1alpha3.TagPolicy{ShaTagger: &v1alpha3.ShaTagger{}}),
 					withDockerArtifact("image1", "./examples/app1", "Dockerfile.dev"),
 					withBazelArtifact("image2", "./examples/app2", "//:example.tar"),
 				),
@@ -133,7 +139,16 @@ func TestParseConfig(t *testing.T) {
 			config:      minimalKanikoConfig,
 			expected: config(
 				withKanikoBuild("demo", "kaniko-secret", "default", "", "20m",
-					withTagPolicy(v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}}),
+					withTagPolicy(v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}}),
+				),
+			),
+		},
+		{
+			description: "Kaniko config without bucket",
+			config:      kanikoConfigWithoutBucket,
+			expected: config(
+				withKanikoBuild("", "", "default", "", "20m",
+					withTagPolicy(v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}}),
 				),
 			),
 		},
@@ -142,7 +157,7 @@ func TestParseConfig(t *testing.T) {
 			config:      completeKanikoConfig,
 			expected: config(
 				withKanikoBuild("demo", "secret-name", "nskaniko", "/secret.json", "120m",
-					withTagPolicy(v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}}),
+					withTagPolicy(v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}}),
 				),
 			),
 		},
@@ -162,16 +177,16 @@ func TestParseConfig(t *testing.T) {
 }
 
 func config(ops ...func(*SkaffoldConfig)) *SkaffoldConfig {
-	cfg := &SkaffoldConfig{APIVersion: "skaffold/v1alpha2", Kind: "Config"}
+	cfg := &SkaffoldConfig{APIVersion: "skaffold/v1alpha3", Kind: "Config"}
 	for _, op := range ops {
 		op(cfg)
 	}
 	return cfg
 }
 
-func withLocalBuild(ops ...func(*v1alpha2.BuildConfig)) func(*SkaffoldConfig) {
+func withLocalBuild(ops ...func(*v1alpha3.BuildConfig)) func(*SkaffoldConfig) {
 	return func(cfg *SkaffoldConfig) {
-		b := v1alpha2.BuildConfig{BuildType: v1alpha2.BuildType{LocalBuild: &v1alpha2.LocalBuild{}}}
+		b := v1alpha3.BuildConfig{BuildType: v1alpha3.BuildType{LocalBuild: &v1alpha3.LocalBuild{}}}
 		for _, op := range ops {
 			op(&b)
 		}
@@ -179,9 +194,9 @@ func withLocalBuild(ops ...func(*v1alpha2.BuildConfig)) func(*SkaffoldConfig) {
 	}
 }
 
-func withGCBBuild(id string, ops ...func(*v1alpha2.BuildConfig)) func(*SkaffoldConfig) {
+func withGCBBuild(id string, ops ...func(*v1alpha3.BuildConfig)) func(*SkaffoldConfig) {
 	return func(cfg *SkaffoldConfig) {
-		b := v1alpha2.BuildConfig{BuildType: v1alpha2.BuildType{GoogleCloudBuild: &v1alpha2.GoogleCloudBuild{ProjectID: id}}}
+		b := v1alpha3.BuildConfig{BuildType: v1alpha3.BuildType{GoogleCloudBuild: &v1alpha3.GoogleCloudBuild{ProjectID: id}}}
 		for _, op := range ops {
 			op(&b)
 		}
@@ -189,9 +204,9 @@ func withGCBBuild(id string, ops ...func(*v1alpha2.BuildConfig)) func(*SkaffoldC
 	}
 }
 
-func withKanikoBuild(bucket, secretName, namespace, secret string, timeout string, ops ...func(*v1alpha2.BuildConfig)) func(*SkaffoldConfig) {
+func withKanikoBuild(bucket, secretName, namespace, secret string, timeout string, ops ...func(*v1alpha3.BuildConfig)) func(*SkaffoldConfig) {
 	return func(cfg *SkaffoldConfig) {
-		b := v1alpha2.BuildConfig{BuildType: v1alpha2.BuildType{KanikoBuild: &v1alpha2.KanikoBuild{
+		b := v1alpha3.BuildConfig{BuildType: v1alpha3.BuildType{KanikoBuild: &v1alpha3.KanikoBuild{
 			GCSBucket:      bucket,
 			PullSecretName: secretName,
 			Namespace:      namespace,
@@ -207,23 +222,24 @@ func withKanikoBuild(bucket, secretName, namespace, secret string, timeout strin
 
 func withKubectlDeploy(manifests ...string) func(*SkaffoldConfig) {
 	return func(cfg *SkaffoldConfig) {
-		cfg.Deploy = v1alpha2.DeployConfig{
-			DeployType: v1alpha2.DeployType{
-				KubectlDeploy: &v1alpha2.KubectlDeploy{
+		cfg.Deploy = v1alpha3.DeployConfig{
+			DeployType: v1alpha3.DeployType{
+				KubectlDeploy: &v1alpha3.KubectlDeploy{
 					Manifests: manifests,
 				},
 			},
+			Order: []string{"kubectl"},
 		}
 	}
 }
 
-func withDockerArtifact(image, workspace, dockerfile string) func(*v1alpha2.BuildConfig) {
-	return func(cfg *v1alpha2.BuildConfig) {
-		cfg.Artifacts = append(cfg.Artifacts, &v1alpha2.Artifact{
+func withDockerArtifact(image, workspace, dockerfile string) func(*v1alpha3.BuildConfig) {
+	return func(cfg *v1alpha3.BuildConfig) {
+		cfg.Artifacts = append(cfg.Artifacts, &v1alpha3.Artifact{
 			ImageName: image,
 			Workspace: workspace,
-			ArtifactType: v1alpha2.ArtifactType{
-				DockerArtifact: &v1alpha2.DockerArtifact{
+			ArtifactType: v1alpha3.ArtifactType{
+				DockerArtifact: &v1alpha3.DockerArtifact{
 					DockerfilePath: dockerfile,
 				},
 			},
@@ -231,13 +247,13 @@ func withDockerArtifact(image, workspace, dockerfile string) func(*v1alpha2.Buil
 	}
 }
 
-func withBazelArtifact(image, workspace, target string) func(*v1alpha2.BuildConfig) {
-	return func(cfg *v1alpha2.BuildConfig) {
-		cfg.Artifacts = append(cfg.Artifacts, &v1alpha2.Artifact{
+func withBazelArtifact(image, workspace, target string) func(*v1alpha3.BuildConfig) {
+	return func(cfg *v1alpha3.BuildConfig) {
+		cfg.Artifacts = append(cfg.Artifacts, &v1alpha3.Artifact{
 			ImageName: image,
 			Workspace: workspace,
-			ArtifactType: v1alpha2.ArtifactType{
-				BazelArtifact: &v1alpha2.BazelArtifact{
+			ArtifactType: v1alpha3.ArtifactType{
+				BazelArtifact: &v1alpha3.BazelArtifact{
 					BuildTarget: target,
 				},
 			},
@@ -245,6 +261,6 @@ func withBazelArtifact(image, workspace, target string) func(*v1alpha2.BuildConf
 	}
 }
 
-func withTagPolicy(tagPolicy v1alpha2.TagPolicy) func(*v1alpha2.BuildConfig) {
-	return func(cfg *v1alpha2.BuildConfig) { cfg.TagPolicy = tagPolicy }
+func withTagPolicy(tagPolicy v1alpha3.TagPolicy) func(*v1alpha3.BuildConfig) {
+	return func(cfg *v1alpha3.BuildConfig) { cfg.TagPolicy = tagPolicy }
 }
@@ -18,6 +18,9 @@ package config
 
 import (
 	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 )
 
 // SkaffoldOptions are options that are set by command line arguments not included
@@ -26,9 +29,35 @@ type SkaffoldOptions struct {
 	ConfigurationFile string
 	Cleanup           bool
 	Notification      bool
+	PortForward       bool
+	Trigger           string
+	TriggerPort       int
+	WatchDebounce     time.Duration
 	Profiles          []string
+	Modules           []string
+	Command           string
 	CustomTag         string
 	Namespace         string
+	CacheArtifacts    bool
+	CacheFile         string
+	PurgeCache        bool
+
+	ProfileAutoActivation bool
+
+	StatusCheck         bool
+	StatusCheckDeadline time.Duration
+
+	RPCPort      int
+	EventLogFile string
+
+	LogInclude []string
+	LogExclude []string
+	LogMatch   string
+	LogFormat  string
+	LogDir     string
+
+	RunID             string
+	LabelPodTemplates bool
 }
 
 // Labels returns a map of labels to be applied to all deployed
@@ -45,5 +74,8 @@ func (opts *SkaffoldOptions) Labels() map[string]string {
 	if len(opts.Profiles) > 0 {
 		labels["profiles"] = strings.Join(opts.Profiles, ",")
 	}
+	if opts.RunID != "" {
+		labels[constants.Labels.RunID] = opts.RunID
+	}
 	return labels
 }
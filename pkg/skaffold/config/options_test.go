@@ -52,17 +52,24 @@ func TestLabels(t *testing.T) {
 			options:        SkaffoldOptions{Profiles: []string{"profile1", "profile2"}},
 			expectedLabels: map[string]string{"profiles": "profile1,profile2"},
 		},
+		{
+			description:    "run id",
+			options:        SkaffoldOptions{RunID: "1234"},
+			expectedLabels: map[string]string{"skaffold-run-id": "1234"},
+		},
 		{
 			description: "all labels",
 			options: SkaffoldOptions{
 				Cleanup:   true,
 				Namespace: "namespace",
 				Profiles:  []string{"p1", "p2"},
+				RunID:     "1234",
 			},
 			expectedLabels: map[string]string{
-				"cleanup":   "true",
-				"namespace": "namespace",
-				"profiles":  "p1,p2",
+				"cleanup":         "true",
+				"namespace":       "namespace",
+				"profiles":        "p1,p2",
+				"skaffold-run-id": "1234",
 			},
 		},
 	}
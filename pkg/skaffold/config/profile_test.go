@@ -19,7 +19,8 @@ package config
 import (
 	"testing"
 
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 )
 
@@ -41,106 +42,106 @@ func TestApplyProfiles(t *testing.T) {
 			description: "build type",
 			profile:     "profile",
 			config: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{ImageName: "image"},
 					},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{},
-				Profiles: []v1alpha2.Profile{
+				Deploy: v1alpha3.DeployConfig{},
+				Profiles: []v1alpha3.Profile{
 					{
 						Name: "profile",
-						Build: v1alpha2.BuildConfig{
-							BuildType: v1alpha2.BuildType{
-								GoogleCloudBuild: &v1alpha2.GoogleCloudBuild{},
+						Build: v1alpha3.BuildConfig{
+							BuildType: v1alpha3.BuildType{
+								GoogleCloudBuild: &v1alpha3.GoogleCloudBuild{},
 							},
 						},
 					},
 				},
 			},
 			expected: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{
 							ImageName: "image",
 							Workspace: ".",
-							ArtifactType: v1alpha2.ArtifactType{
-								DockerArtifact: &v1alpha2.DockerArtifact{
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{
 									DockerfilePath: "Dockerfile",
 								},
 							},
 						},
 					},
-					BuildType: v1alpha2.BuildType{
-						GoogleCloudBuild: &v1alpha2.GoogleCloudBuild{},
+					BuildType: v1alpha3.BuildType{
+						GoogleCloudBuild: &v1alpha3.GoogleCloudBuild{},
 					},
-					TagPolicy: v1alpha2.TagPolicy{
-						GitTagger: &v1alpha2.GitTagger{},
+					TagPolicy: v1alpha3.TagPolicy{
+						GitTagger: &v1alpha3.GitTagger{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{},
+				Deploy: v1alpha3.DeployConfig{},
 			},
 		},
 		{
 			description: "tag policy",
 			profile:     "dev",
 			config: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{ImageName: "image"},
 					},
-					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+					TagPolicy: v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}},
 				},
-				Deploy: v1alpha2.DeployConfig{},
-				Profiles: []v1alpha2.Profile{
+				Deploy: v1alpha3.DeployConfig{},
+				Profiles: []v1alpha3.Profile{
 					{
 						Name: "dev",
-						Build: v1alpha2.BuildConfig{
-							TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
+						Build: v1alpha3.BuildConfig{
+							TagPolicy: v1alpha3.TagPolicy{ShaTagger: &v1alpha3.ShaTagger{}},
 						},
 					},
 				},
 			},
 			expected: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{
 							ImageName: "image",
 							Workspace: ".",
-							ArtifactType: v1alpha2.ArtifactType{
-								DockerArtifact: &v1alpha2.DockerArtifact{
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{
 									DockerfilePath: "Dockerfile",
 								},
 							},
 						},
 					},
-					TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+					TagPolicy: v1alpha3.TagPolicy{ShaTagger: &v1alpha3.ShaTagger{}},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{},
+				Deploy: v1alpha3.DeployConfig{},
 			},
 		},
 		{
 			description: "artifacts",
 			profile:     "profile",
 			config: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{ImageName: "image"},
 					},
-					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+					TagPolicy: v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}},
 				},
-				Deploy: v1alpha2.DeployConfig{},
-				Profiles: []v1alpha2.Profile{
+				Deploy: v1alpha3.DeployConfig{},
+				Profiles: []v1alpha3.Profile{
 					{
 						Name: "profile",
-						Build: v1alpha2.BuildConfig{
-							Artifacts: []*v1alpha2.Artifact{
+						Build: v1alpha3.BuildConfig{
+							Artifacts: []*v1alpha3.Artifact{
 								{ImageName: "image"},
 								{ImageName: "imageProd"},
 							},
@@ -149,13 +150,13 @@ func TestApplyProfiles(t *testing.T) {
 				},
 			},
 			expected: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{
 							ImageName: "image",
 							Workspace: ".",
-							ArtifactType: v1alpha2.ArtifactType{
-								DockerArtifact: &v1alpha2.DockerArtifact{
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{
 									DockerfilePath: "Dockerfile",
 								},
 							},
@@ -163,65 +164,171 @@ func TestApplyProfiles(t *testing.T) {
 						{
 							ImageName: "imageProd",
 							Workspace: ".",
-							ArtifactType: v1alpha2.ArtifactType{
-								DockerArtifact: &v1alpha2.DockerArtifact{
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{
 									DockerfilePath: "Dockerfile",
 								},
 							},
 						},
 					},
-					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+					TagPolicy: v1alpha3.TagPolicy{GitTagger: &v1alpha3.GitTagger{}},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{},
+				Deploy: v1alpha3.DeployConfig{},
 			},
 		},
 		{
 			description: "deploy",
 			profile:     "profile",
 			config: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{},
-				Deploy: v1alpha2.DeployConfig{
-					DeployType: v1alpha2.DeployType{
-						KubectlDeploy: &v1alpha2.KubectlDeploy{},
+				Build: v1alpha3.BuildConfig{},
+				Deploy: v1alpha3.DeployConfig{
+					DeployType: v1alpha3.DeployType{
+						KubectlDeploy: &v1alpha3.KubectlDeploy{},
 					},
 				},
-				Profiles: []v1alpha2.Profile{
+				Profiles: []v1alpha3.Profile{
 					{
 						Name: "profile",
-						Deploy: v1alpha2.DeployConfig{
-							DeployType: v1alpha2.DeployType{
-								HelmDeploy: &v1alpha2.HelmDeploy{},
+						Deploy: v1alpha3.DeployConfig{
+							DeployType: v1alpha3.DeployType{
+								HelmDeploy: &v1alpha3.HelmDeploy{},
 							},
 						},
 					},
 				},
 			},
 			expected: SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					TagPolicy: v1alpha2.TagPolicy{
-						GitTagger: &v1alpha2.GitTagger{},
+				Build: v1alpha3.BuildConfig{
+					TagPolicy: v1alpha3.TagPolicy{
+						GitTagger: &v1alpha3.GitTagger{},
 					},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{
-					DeployType: v1alpha2.DeployType{
-						HelmDeploy: &v1alpha2.HelmDeploy{},
+				Deploy: v1alpha3.DeployConfig{
+					DeployType: v1alpha3.DeployType{
+						HelmDeploy: &v1alpha3.HelmDeploy{},
 					},
+					Order: []string{"helm"},
 				},
 			},
 		},
+		{
+			description: "patches",
+			profile:     "patches",
+			config: SkaffoldConfig{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
+						{
+							ImageName: "web",
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{DockerfilePath: "Dockerfile.dev"},
+							},
+						},
+						{ImageName: "worker"},
+						{ImageName: "test"},
+					},
+				},
+				Profiles: []v1alpha3.Profile{
+					{
+						Name: "patches",
+						Patches: []v1alpha3.JSONPatch{
+							{Op: "add", Path: "/build/artifacts/0/docker/buildArgs", Value: map[string]string{"ENV": "prod"}},
+							{Op: "add", Path: "/build/artifacts/1/workspace", Value: "worker"},
+							{Op: "remove", Path: "/build/artifacts/2"},
+						},
+					},
+				},
+			},
+			expected: SkaffoldConfig{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
+						{
+							ImageName: "web",
+							Workspace: ".",
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{
+									DockerfilePath: "Dockerfile.dev",
+									BuildArgs:      map[string]*string{"ENV": util.StringPtr("prod")},
+								},
+							},
+						},
+						{
+							ImageName: "worker",
+							Workspace: "worker",
+							ArtifactType: v1alpha3.ArtifactType{
+								DockerArtifact: &v1alpha3.DockerArtifact{
+									DockerfilePath: "Dockerfile",
+								},
+							},
+						},
+					},
+					TagPolicy: v1alpha3.TagPolicy{
+						GitTagger: &v1alpha3.GitTagger{},
+					},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
+					},
+				},
+			},
+		},
+		{
+			description: "patch out of range",
+			profile:     "patches",
+			config: SkaffoldConfig{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{{ImageName: "web"}},
+				},
+				Profiles: []v1alpha3.Profile{
+					{
+						Name:    "patches",
+						Patches: []v1alpha3.JSONPatch{{Op: "replace", Path: "/build/artifacts/1/imageName", Value: "worker"}},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "patch missing value",
+			profile:     "patches",
+			config: SkaffoldConfig{
+				Profiles: []v1alpha3.Profile{
+					{
+						Name:    "patches",
+						Patches: []v1alpha3.JSONPatch{{Op: "remove", Path: "/deploy/kubectl/manifests"}},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "patch with unknown field",
+			profile:     "patches",
+			config: SkaffoldConfig{
+				Profiles: []v1alpha3.Profile{
+					{
+						Name:    "patches",
+						Patches: []v1alpha3.JSONPatch{{Op: "add", Path: "/build/unknown", Value: "value"}},
+					},
+				},
+			},
+			shouldErr: true,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
 			err := test.config.ApplyProfiles([]string{test.profile})
 
-			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, test.config)
+			if test.shouldErr {
+				testutil.CheckError(t, true, err)
+				return
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, test.config)
 		})
 	}
 }
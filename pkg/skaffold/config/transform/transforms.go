@@ -23,7 +23,10 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha1"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func ToV1Alpha2(vc util.VersionedConfig) (util.VersionedConfig, error) {
@@ -115,3 +118,25 @@ func ToV1Alpha2(vc util.VersionedConfig) (util.VersionedConfig, error) {
 	}
 	return newConfig, nil
 }
+
+// ToV1Alpha3 upgrades a v1alpha2 configuration. v1alpha3 only adds
+// fields to v1alpha2 so the configuration is kept as is.
+func ToV1Alpha3(vc util.VersionedConfig) (util.VersionedConfig, error) {
+	if vc.GetVersion() != v1alpha2.Version {
+		return nil, fmt.Errorf("Incompatible version: %s", vc.GetVersion())
+	}
+	oldConfig := vc.(*v1alpha2.SkaffoldConfig)
+
+	buf, err := yaml.Marshal(oldConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling v1alpha2 config")
+	}
+
+	newConfig := &v1alpha3.SkaffoldConfig{}
+	if err := yaml.UnmarshalStrict(buf, newConfig); err != nil {
+		return nil, errors.Wrap(err, "converting to v1alpha3 config")
+	}
+	newConfig.APIVersion = v1alpha3.Version
+
+	return newConfig, nil
+}
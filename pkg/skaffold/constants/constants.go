@@ -44,13 +44,28 @@ const (
 
 	HelmOverridesFilename = "skaffold-overrides.yaml"
 
+	// HelmLabelsValue and HelmPodLabelsValue are the values that give the labels
+	// to the objects, and to the pod templates, of a helm chart.
+	HelmLabelsValue    = "commonLabels"
+	HelmPodLabelsValue = "podLabels"
+
 	DefaultKustomizationPath = "."
 
+	// DefaultLocalConcurrency is how many artifacts are built locally at the same time
+	// when the configuration doesn't set `concurrency`.
+	DefaultLocalConcurrency = 4
+
 	DefaultKanikoImage      = "gcr.io/kaniko-project/executor:v0.2.0@sha256:bebe80bb97950d88b8d8eab315a58e0bc50307135cf25147d7e0b8f3db50a84a"
 	DefaultKanikoSecretName = "kaniko-secret"
 	DefaultKanikoTimeout    = "20m"
+	DefaultBusyboxImage     = "busybox"
 
 	UpdateCheckEnvironmentVariable = "SKAFFOLD_UPDATE_CHECK"
+
+	// DefaultSkaffoldDir is the directory, under the user's home, where skaffold keeps its state
+	DefaultSkaffoldDir = ".skaffold"
+	// DefaultCacheFile is the file, under DefaultSkaffoldDir, where built artifacts are cached
+	DefaultCacheFile = "cache"
 )
 
 var DefaultKubectlManifests = []string{"k8s/*.yaml"}
@@ -62,6 +77,7 @@ var Labels = struct {
 	Deployer         string
 	Builder          string
 	DockerAPIVersion string
+	RunID            string
 	DefaultLabels    map[string]string
 }{
 	DefaultLabels: map[string]string{
@@ -71,4 +87,5 @@ var Labels = struct {
 	Deployer:         "skaffold-deployer",
 	Builder:          "skaffold-builder",
 	DockerAPIVersion: "docker-api-version",
+	RunID:            "skaffold-run-id",
 }
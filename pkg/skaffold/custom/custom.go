@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// BuildCommand returns the command that builds the image for a custom artifact.
+// The script is run in the workspace and receives the image to build as
+// environment variables:
+//   - IMAGE: fully qualified name of the image, tag included
+//   - IMAGE_REPO: name of the image, without the tag
+//   - IMAGE_TAG: tag of the image
+//   - PUSH_IMAGE: true if the script is expected to push the image
+//   - BUILD_CONTEXT: absolute path of the workspace
+// The script is killed when the context is cancelled.
+func BuildCommand(ctx context.Context, workspace string, a *v1alpha3.CustomArtifact, image string, pushImage bool) (*exec.Cmd, error) {
+	absWorkspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting absolute path of workspace")
+	}
+
+	repo, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i+1:]
+	}
+
+	cmd := shellCommand(ctx, a.BuildCommand)
+	cmd.Dir = workspace
+	cmd.Env = append(os.Environ(),
+		"IMAGE="+image,
+		"IMAGE_REPO="+repo,
+		"IMAGE_TAG="+tag,
+		"PUSH_IMAGE="+strconv.FormatBool(pushImage),
+		"BUILD_CONTEXT="+absWorkspace,
+	)
+
+	return cmd, nil
+}
+
+// GetDependencies finds the source dependencies for the given custom artifact.
+// They are either listed in the configuration, as paths or glob patterns, or
+// printed by a command, one per line. Without any of those, every file in the
+// workspace is a dependency. All paths are relative to the workspace.
+func GetDependencies(workspace string, a *v1alpha3.CustomArtifact) ([]string, error) {
+	absWorkspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting absolute path of workspace")
+	}
+
+	var patterns []string
+	switch {
+	case a.Dependencies == nil:
+		patterns = []string{"."}
+
+	case a.Dependencies.Command != "":
+		cmd := shellCommand(context.Background(), a.Dependencies.Command)
+		cmd.Dir = workspace
+		stdout, err := util.RunCmdOut(cmd)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting custom dependencies")
+		}
+
+		for _, line := range strings.Split(string(stdout), "\n") {
+			if path := strings.TrimSpace(line); path != "" {
+				patterns = append(patterns, path)
+			}
+		}
+
+	default:
+		patterns = a.Dependencies.Paths
+	}
+
+	var deps []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(absWorkspace, pattern)
+		}
+
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+
+		for _, path := range paths {
+			if err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.IsDir() {
+					return nil
+				}
+
+				rel, err := filepath.Rel(absWorkspace, file)
+				if err != nil {
+					return errors.Wrapf(err, "finding %s relative to workspace", file)
+				}
+				deps = append(deps, rel)
+				return nil
+			}); err != nil {
+				return nil, errors.Wrapf(err, "listing files in %s", path)
+			}
+		}
+	}
+
+	return util.UniqueStrSlice(deps), nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestBuildCommand(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	cmd, err := BuildCommand(context.Background(), tmpDir, &v1alpha3.CustomArtifact{BuildCommand: "./build.sh"}, "localhost:5000/app:v1", true)
+	testutil.CheckError(t, false, err)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"sh", "-c", "./build.sh"}, cmd.Args)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, tmpDir, cmd.Dir)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"IMAGE=localhost:5000/app:v1",
+		"IMAGE_REPO=localhost:5000/app",
+		"IMAGE_TAG=v1",
+		"PUSH_IMAGE=true",
+		"BUILD_CONTEXT=" + tmpDir,
+	}, cmd.Env[len(cmd.Env)-5:])
+}
+
+func TestBuildCommandCancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on windows")
+	}
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, err := BuildCommand(ctx, tmpDir, &v1alpha3.CustomArtifact{BuildCommand: "sleep 30"}, "app:v1", false)
+	testutil.CheckError(t, false, err)
+
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	err = util.RunCmd(cmd)
+
+	testutil.CheckError(t, true, err)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("build script still running %v after the context was cancelled", elapsed)
+	}
+}
+
+func TestGetDependencies(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, file := range []string{"Makefile", "main.go", "cmd/app/main.go", "docs/README.md"} {
+		path := filepath.Join(tmpDir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(""), 0644)
+	}
+
+	var tests = []struct {
+		description string
+		artifact    *v1alpha3.CustomArtifact
+		command     string
+		stdout      string
+		shouldErr   bool
+		expected    []string
+	}{
+		{
+			description: "whole workspace",
+			artifact:    &v1alpha3.CustomArtifact{},
+			expected:    []string{"Makefile", filepath.Join("cmd", "app", "main.go"), filepath.Join("docs", "README.md"), "main.go"},
+		},
+		{
+			description: "paths",
+			artifact: &v1alpha3.CustomArtifact{
+				Dependencies: &v1alpha3.CustomDependencies{
+					Paths: []string{"Makefile", "*.go", "cmd", "missing"},
+				},
+			},
+			expected: []string{"Makefile", filepath.Join("cmd", "app", "main.go"), "main.go"},
+		},
+		{
+			description: "command",
+			artifact: &v1alpha3.CustomArtifact{
+				Dependencies: &v1alpha3.CustomDependencies{
+					Command: "make deps",
+				},
+			},
+			command:  "sh -c make deps",
+			stdout:   "main.go\n" + filepath.Join(tmpDir, "docs") + "\n",
+			expected: []string{filepath.Join("docs", "README.md"), "main.go"},
+		},
+		{
+			description: "invalid pattern",
+			artifact: &v1alpha3.CustomArtifact{
+				Dependencies: &v1alpha3.CustomDependencies{
+					Paths: []string{"["},
+				},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmdOut(test.command, test.stdout, nil)
+
+			deps, err := GetDependencies(tmpDir, test.artifact)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, deps)
+		})
+	}
+}
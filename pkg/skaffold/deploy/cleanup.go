@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+type withLabelCleanup struct {
+	Deployer
+
+	labels   map[string]string
+	releases Deployer
+}
+
+// WithLabelCleanup creates a deployer whose cleanup deletes the objects that have
+// the given labels, instead of the objects described by the current manifests.
+// Helm releases can't be found by their labels so, if releases is not nil,
+// its own cleanup is run first.
+func WithLabelCleanup(d Deployer, labels map[string]string, releases Deployer) Deployer {
+	return &withLabelCleanup{
+		Deployer: d,
+		labels:   labels,
+		releases: releases,
+	}
+}
+
+func (w *withLabelCleanup) Cleanup(ctx context.Context, out io.Writer) error {
+	if w.releases != nil {
+		if err := w.releases.Cleanup(ctx, out); err != nil {
+			return err
+		}
+	}
+
+	return DeleteLabeled(out, w.labels)
+}
+
+// DeleteLabeled deletes the objects of any kind, in any namespace, that have the given labels.
+func DeleteLabeled(out io.Writer, labels map[string]string) error {
+	client, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+
+	dynClient, err := kubernetes.DynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s dynamic client")
+	}
+
+	return deleteLabeled(out, client.Discovery(), dynClient, labels)
+}
+
+func deleteLabeled(out io.Writer, disco discovery.DiscoveryInterface, client dynamic.Interface, labels map[string]string) error {
+	resources, err := discovery.ServerPreferredResources(disco)
+	if err != nil {
+		// Some aggregated APIs may be unavailable. The other resources can still be deleted.
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return errors.Wrap(err, "listing resource types")
+		}
+		logrus.Warnln("Listing resource types:", err)
+	}
+	resources = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, resources)
+
+	selector := k8slabels.SelectorFromSet(labels).String()
+	propagation := metav1.DeletePropagationBackground
+
+	deleted := 0
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return errors.Wrapf(err, "parsing group version %s", list.GroupVersion)
+		}
+
+		for _, resource := range list.APIResources {
+			gvr := gv.WithResource(resource.Name)
+
+			objs, err := client.Resource(gvr).List(metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				logrus.Debugf("listing %s: %s", gvr.String(), err)
+				continue
+			}
+
+			for _, obj := range objs.Items {
+				err := client.Resource(gvr).Namespace(obj.GetNamespace()).Delete(obj.GetName(), &metav1.DeleteOptions{
+					PropagationPolicy: &propagation,
+				})
+				switch {
+				case apierrors.IsNotFound(err):
+					// Already deleted, either through another version of the api or by the garbage collector.
+				case err != nil:
+					return errors.Wrapf(err, "deleting %s %s", resource.Kind, obj.GetName())
+				default:
+					fmt.Fprintf(out, "%s %q deleted\n", qualifiedName(gv, resource), obj.GetName())
+					deleted++
+				}
+			}
+		}
+	}
+
+	if deleted == 0 {
+		fmt.Fprintf(out, "No resources found with labels %s\n", selector)
+	}
+
+	return nil
+}
+
+// qualifiedName returns the name of a resource, as printed by kubectl.
+func qualifiedName(gv schema.GroupVersion, resource metav1.APIResource) string {
+	name := strings.ToLower(resource.Kind)
+	if gv.Group != "" {
+		name += "." + gv.Group
+	}
+	return name
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeDynamicClient stores objects by resource and only supports List and Delete.
+type fakeDynamicClient struct {
+	objects map[schema.GroupVersionResource][]unstructured.Unstructured
+	deleted []string
+}
+
+type fakeResource struct {
+	dynamic.NamespaceableResourceInterface
+
+	client    *fakeDynamicClient
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+func (c *fakeDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeResource{client: c, gvr: gvr}
+}
+
+func (r *fakeResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &fakeResource{client: r.client, gvr: r.gvr, namespace: namespace}
+}
+
+func (r *fakeResource) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	for _, obj := range r.client.objects[r.gvr] {
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			list.Items = append(list.Items, obj)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeResource) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	for i, obj := range r.client.objects[r.gvr] {
+		if obj.GetNamespace() == r.namespace && obj.GetName() == name {
+			r.client.objects[r.gvr] = append(r.client.objects[r.gvr][:i], r.client.objects[r.gvr][i+1:]...)
+			r.client.deleted = append(r.client.deleted, r.namespace+"/"+name)
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(r.gvr.GroupResource(), name)
+}
+
+func object(namespace, name string, labels map[string]string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestDeleteLabeled(t *testing.T) {
+	verbs := metav1.Verbs{"list", "delete"}
+	disco := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "services", Kind: "Service", Namespaced: true, Verbs: verbs},
+					{Name: "services/status", Kind: "Service", Namespaced: true, Verbs: verbs},
+					{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: metav1.Verbs{"create"}},
+				},
+			},
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: verbs},
+				},
+			},
+		},
+	}}
+
+	mine := map[string]string{"skaffold-run-id": "1234"}
+	other := map[string]string{"skaffold-run-id": "5678"}
+	client := &fakeDynamicClient{objects: map[schema.GroupVersionResource][]unstructured.Unstructured{
+		{Version: "v1", Resource: "services"}: {
+			object("default", "web", mine),
+			object("default", "db", other),
+		},
+		{Group: "apps", Version: "v1", Resource: "deployments"}: {
+			object("default", "web", mine),
+			object("staging", "web", mine),
+			object("default", "db", other),
+		},
+		{Version: "v1", Resource: "bindings"}: {
+			object("default", "web", mine),
+		},
+	}}
+
+	var out bytes.Buffer
+	err := deleteLabeled(&out, disco, client, mine)
+
+	// The resource types are listed in no particular order
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(lines)
+	sort.Strings(client.deleted)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"default/web", "default/web", "staging/web"}, client.deleted)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{`deployment.apps "web" deleted`, `deployment.apps "web" deleted`, `service "web" deleted`}, lines)
+
+	out.Reset()
+	err = deleteLabeled(&out, disco, client, mine)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "No resources found with labels skaffold-run-id=1234\n", out.String())
+}
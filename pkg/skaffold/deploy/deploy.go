@@ -37,8 +37,8 @@ type Deployer interface {
 	Labels() map[string]string
 
 	// Deploy should ensure that the build results are deployed to the Kubernetes
-	// cluster.
-	Deploy(context.Context, io.Writer, []build.Artifact) ([]Artifact, error)
+	// cluster, with the labels given by the labellers.
+	Deploy(context.Context, io.Writer, []build.Artifact, []Labeller) ([]Artifact, error)
 
 	// Dependencies returns a list of files that the deployer depends on.
 	// In dev mode, a redeploy will be triggered
@@ -46,6 +46,10 @@ type Deployer interface {
 
 	// Cleanup deletes what was deployed by calling Deploy.
 	Cleanup(context.Context, io.Writer) error
+
+	// Render writes the manifests that Deploy would apply, with their
+	// images replaced by the build results, without deploying them.
+	Render(context.Context, io.Writer, []build.Artifact) error
 }
 
 func joinTagsToBuildResult(builds []build.Artifact, params map[string]string) (map[string]build.Artifact, error) {
@@ -22,8 +22,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -32,7 +34,7 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -40,19 +42,22 @@ import (
 )
 
 type HelmDeployer struct {
-	*v1alpha2.HelmDeploy
+	*v1alpha3.HelmDeploy
 
-	kubeContext string
-	namespace   string
+	kubeContext       string
+	namespace         string
+	labelPodTemplates bool
 }
 
 // NewHelmDeployer returns a new HelmDeployer for a DeployConfig filled
-// with the needed configuration for `helm`
-func NewHelmDeployer(cfg *v1alpha2.HelmDeploy, kubeContext string, namespace string) *HelmDeployer {
+// with the needed configuration for `helm`. With labelPodTemplates,
+// the labels are also set on the pod templates.
+func NewHelmDeployer(cfg *v1alpha3.HelmDeploy, kubeContext string, namespace string, labelPodTemplates bool) *HelmDeployer {
 	return &HelmDeployer{
-		HelmDeploy:  cfg,
-		kubeContext: kubeContext,
-		namespace:   namespace,
+		HelmDeploy:        cfg,
+		kubeContext:       kubeContext,
+		namespace:         namespace,
+		labelPodTemplates: labelPodTemplates,
 	}
 }
 
@@ -62,10 +67,11 @@ func (h *HelmDeployer) Labels() map[string]string {
 	}
 }
 
-func (h *HelmDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]Artifact, error) {
+func (h *HelmDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []Labeller) ([]Artifact, error) {
+	labels := merge(labellers...)
 	deployResults := []Artifact{}
 	for _, r := range h.Releases {
-		results, err := h.deployRelease(out, r, builds)
+		results, err := h.deployRelease(out, r, builds, labels)
 		if err != nil {
 			releaseName, _ := evaluateReleaseName(r.Name)
 			return deployResults, errors.Wrapf(err, "deploying %s", releaseName)
@@ -75,6 +81,17 @@ func (h *HelmDeployer) Deploy(ctx context.Context, out io.Writer, builds []build
 	return deployResults, nil
 }
 
+// Render writes the manifests of each release, as rendered by `helm template`.
+func (h *HelmDeployer) Render(ctx context.Context, out io.Writer, builds []build.Artifact) error {
+	for _, r := range h.Releases {
+		if err := h.renderRelease(out, r, builds); err != nil {
+			releaseName, _ := evaluateReleaseName(r.Name)
+			return errors.Wrapf(err, "rendering %s", releaseName)
+		}
+	}
+	return nil
+}
+
 func (h *HelmDeployer) Dependencies() ([]string, error) {
 	var deps []string
 	for _, release := range h.Releases {
@@ -101,16 +118,22 @@ func (h *HelmDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 }
 
 func (h *HelmDeployer) helm(out io.Writer, arg ...string) error {
+	return h.helmWithStderr(out, out, arg...)
+}
+
+func (h *HelmDeployer) helmWithStderr(out, stderr io.Writer, arg ...string) error {
 	args := append([]string{"--kube-context", h.kubeContext}, arg...)
 
 	cmd := exec.Command("helm", args...)
 	cmd.Stdout = out
-	cmd.Stderr = out
+	cmd.Stderr = stderr
 
 	return util.RunCmd(cmd)
 }
 
-func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, builds []build.Artifact) ([]Artifact, error) {
+// deployRelease installs or upgrades the chart of a release. The labels are given to the
+// chart as values, so that the objects are created with their labels.
+func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha3.HelmRelease, builds []build.Artifact, labels map[string]string) ([]Artifact, error) {
 	isInstalled := true
 
 	releaseName, err := evaluateReleaseName(r.Name)
@@ -121,21 +144,9 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 		color.Red.Fprintf(out, "Helm release %s not installed. Installing...\n", releaseName)
 		isInstalled = false
 	}
-	params, err := joinTagsToBuildResult(builds, r.Values)
+	setOpts, err := h.setOpts(out, r, builds)
 	if err != nil {
-		return nil, errors.Wrap(err, "matching build results to chart values")
-	}
-
-	var setOpts []string
-	for k, v := range params {
-		setOpts = append(setOpts, "--set")
-		if r.ImageStrategy.HelmImageConfig.HelmConventionConfig != nil {
-			tagSplit := strings.Split(v.Tag, ":")
-			imageRepositoryTag := fmt.Sprintf("%s.repository=%s,%s.tag=%s", k, tagSplit[0], k, tagSplit[1])
-			setOpts = append(setOpts, imageRepositoryTag)
-		} else {
-			setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v.Tag))
-		}
+		return nil, err
 	}
 
 	// First build dependencies.
@@ -151,6 +162,135 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 		args = append(args, "upgrade", releaseName)
 	}
 
+	if r.Packaged == nil && r.Version != "" {
+		args = append(args, "--version", r.Version)
+	}
+	chart, err := h.chart(r)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, chart)
+
+	ns := h.releaseNamespace(r)
+	if ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+
+	valuesArgs, cleanup, err := valuesFilesArgs(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	args = append(args, valuesArgs...)
+
+	labelsArgs, cleanupLabels, err := labelsValuesArgs(labels, h.labelPodTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupLabels()
+	args = append(args, labelsArgs...)
+
+	if r.Wait {
+		args = append(args, "--wait")
+	}
+	args = append(args, setOpts...)
+
+	helmErr := h.helm(out, args...)
+	return h.getDeployResults(ns, releaseName), helmErr
+}
+
+// renderRelease writes the manifests of a release, rendered by `helm template`
+// with the same values as `helm install` or `helm upgrade` would use.
+func (h *HelmDeployer) renderRelease(out io.Writer, r v1alpha3.HelmRelease, builds []build.Artifact) error {
+	releaseName, err := evaluateReleaseName(r.Name)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse the release name template")
+	}
+	setOpts, err := h.setOpts(ioutil.Discard, r, builds)
+	if err != nil {
+		return err
+	}
+
+	var depOut bytes.Buffer
+	if err := h.helm(&depOut, "dep", "build", r.ChartPath); err != nil {
+		return errors.Wrapf(err, "building helm dependencies (%s)", strings.TrimSpace(depOut.String()))
+	}
+
+	chart, cleanup, err := h.localChart(r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return h.template(out, r, chart, releaseName, setOpts)
+}
+
+// template writes the manifests of a release, rendered by `helm template` from a local chart.
+func (h *HelmDeployer) template(out io.Writer, r v1alpha3.HelmRelease, chart string, releaseName string, setOpts []string) error {
+	args := []string{"template", chart, "--name", releaseName}
+
+	if ns := h.releaseNamespace(r); ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+
+	valuesArgs, cleanup, err := valuesFilesArgs(r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	args = append(args, valuesArgs...)
+	args = append(args, setOpts...)
+
+	// Keep helm's warnings out of the rendered manifests
+	var stderr bytes.Buffer
+	if err := h.helmWithStderr(out, &stderr, args...); err != nil {
+		return errors.Wrapf(err, "running helm template (%s)", strings.TrimSpace(stderr.String()))
+	}
+	if warnings := strings.TrimSpace(stderr.String()); warnings != "" {
+		logrus.Warnln(warnings)
+	}
+
+	return nil
+}
+
+// localChart returns the path of the chart of a release on the filesystem.
+// `helm template` can't read a chart from a repository, so such a chart
+// is fetched first. The returned function removes the fetched chart.
+func (h *HelmDeployer) localChart(r v1alpha3.HelmRelease) (string, func(), error) {
+	cleanup := func() {}
+
+	chart, err := h.chart(r)
+	if err != nil {
+		return "", cleanup, err
+	}
+	if r.Packaged != nil {
+		return chart, cleanup, nil
+	}
+	if _, err := os.Stat(chart); err == nil {
+		return chart, cleanup, nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "helm-chart")
+	if err != nil {
+		return "", cleanup, errors.Wrap(err, "creating temporary directory")
+	}
+	cleanup = func() { os.RemoveAll(tmpDir) }
+
+	args := []string{"fetch", chart, "--untar", "--untardir", tmpDir}
+	if r.Version != "" {
+		args = append(args, "--version", r.Version)
+	}
+	var fetchOut bytes.Buffer
+	if err := h.helm(&fetchOut, args...); err != nil {
+		cleanup()
+		return "", func() {}, errors.Wrapf(err, "fetching chart %s (%s)", chart, strings.TrimSpace(fetchOut.String()))
+	}
+
+	return filepath.Join(tmpDir, path.Base(chart)), cleanup, nil
+}
+
+// chart returns the chart to deploy for a release.
+func (h *HelmDeployer) chart(r v1alpha3.HelmRelease) (string, error) {
 	// There are 2 strategies:
 	// 1) Deploy chart directly from filesystem path or from repository
 	//    (like stable/kubernetes-dashboard). Version only applies to a
@@ -159,42 +299,44 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 	//    that packaged chart. This way user can apply any version and appVersion
 	//    for the chart.
 	if r.Packaged == nil {
-		if r.Version != "" {
-			args = append(args, "--version", r.Version)
-		}
-		args = append(args, r.ChartPath)
-	} else {
-		chartPath, err := h.packageChart(r)
-		if err != nil {
-			return nil, errors.WithMessage(err, "cannot package chart")
-		}
-		args = append(args, chartPath)
+		return r.ChartPath, nil
 	}
 
-	var ns string
-	if h.namespace != "" {
-		ns = h.namespace
-	} else if r.Namespace != "" {
-		ns = r.Namespace
+	chartPath, err := h.packageChart(r)
+	if err != nil {
+		return "", errors.WithMessage(err, "cannot package chart")
 	}
-	if ns != "" {
-		args = append(args, "--namespace", ns)
+	return chartPath, nil
+}
+
+func (h *HelmDeployer) releaseNamespace(r v1alpha3.HelmRelease) string {
+	if h.namespace != "" {
+		return h.namespace
 	}
+	return r.Namespace
+}
+
+// valuesFilesArgs returns the `-f` arguments for the overrides and the values file
+// of a release. The returned function removes the temporary overrides file.
+func valuesFilesArgs(r v1alpha3.HelmRelease) ([]string, func(), error) {
+	var args []string
+	cleanup := func() {}
+
 	if len(r.Overrides) != 0 {
 		overrides, err := yaml.Marshal(r.Overrides)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot marshal overrides to create overrides values.yaml")
+			return nil, cleanup, errors.Wrap(err, "cannot marshal overrides to create overrides values.yaml")
 		}
 		overridesFile, err := os.Create(constants.HelmOverridesFilename)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot create file %s", constants.HelmOverridesFilename)
+			return nil, cleanup, errors.Wrapf(err, "cannot create file %s", constants.HelmOverridesFilename)
 		}
-		defer func() {
+		cleanup = func() {
 			overridesFile.Close()
 			os.Remove(constants.HelmOverridesFilename)
-		}()
+		}
 		if _, err := overridesFile.WriteString(string(overrides)); err != nil {
-			return nil, errors.Wrapf(err, "failed to write file %s", constants.HelmOverridesFilename)
+			return nil, cleanup, errors.Wrapf(err, "failed to write file %s", constants.HelmOverridesFilename)
 		}
 		args = append(args, "-f", constants.HelmOverridesFilename)
 	}
@@ -202,6 +344,70 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 		args = append(args, "-f", r.ValuesFilePath)
 	}
 
+	return args, cleanup, nil
+}
+
+// labelsValuesArgs returns the `-f` arguments for a values file that gives the labels to
+// a release as `commonLabels` and, with podTemplates, as `podLabels`. Helm can't label the
+// objects of a chart itself: only the charts that set these values on their objects, and
+// on their pod templates, are labelled. The returned function removes the values file.
+func labelsValuesArgs(labels map[string]string, podTemplates bool) ([]string, func(), error) {
+	cleanup := func() {}
+
+	allLabels := map[string]string{}
+	for k, v := range constants.Labels.DefaultLabels {
+		allLabels[k] = v
+	}
+	for k, v := range labels {
+		allLabels[k] = v
+	}
+
+	values := map[string]map[string]string{
+		constants.HelmLabelsValue: allLabels,
+	}
+	if podTemplates {
+		values[constants.HelmPodLabelsValue] = allLabels
+	}
+	content, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, cleanup, errors.Wrap(err, "marshalling labels")
+	}
+
+	labelsFile, err := ioutil.TempFile("", "skaffold-labels")
+	if err != nil {
+		return nil, cleanup, errors.Wrap(err, "creating labels values file")
+	}
+	cleanup = func() {
+		labelsFile.Close()
+		os.Remove(labelsFile.Name())
+	}
+	if _, err := labelsFile.Write(content); err != nil {
+		return nil, cleanup, errors.Wrapf(err, "writing labels values file %s", labelsFile.Name())
+	}
+
+	return []string{"-f", labelsFile.Name()}, cleanup, nil
+}
+
+// setOpts returns the `--set` arguments that pass the built images
+// and the templated values to a release.
+func (h *HelmDeployer) setOpts(out io.Writer, r v1alpha3.HelmRelease, builds []build.Artifact) ([]string, error) {
+	params, err := joinTagsToBuildResult(builds, r.Values)
+	if err != nil {
+		return nil, errors.Wrap(err, "matching build results to chart values")
+	}
+
+	var setOpts []string
+	for k, v := range params {
+		setOpts = append(setOpts, "--set")
+		if r.ImageStrategy.HelmImageConfig.HelmConventionConfig != nil {
+			tagSplit := strings.Split(v.Tag, ":")
+			imageRepositoryTag := fmt.Sprintf("%s.repository=%s,%s.tag=%s", k, tagSplit[0], k, tagSplit[1])
+			setOpts = append(setOpts, imageRepositoryTag)
+		} else {
+			setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v.Tag))
+		}
+	}
+
 	setValues := r.SetValues
 	if setValues == nil {
 		setValues = map[string]string{}
@@ -235,13 +441,8 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 		setOpts = append(setOpts, "--set")
 		setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v))
 	}
-	if r.Wait {
-		args = append(args, "--wait")
-	}
-	args = append(args, setOpts...)
 
-	helmErr := h.helm(out, args...)
-	return h.getDeployResults(ns, releaseName), helmErr
+	return setOpts, nil
 }
 
 // imageName if the given string includes a fully qualified docker image name then lets trim just the tag part out
@@ -260,7 +461,7 @@ func extractTag(imageName string) string {
 
 // packageChart packages the chart and returns path to the chart archive file.
 // If this function returns an error, it will always be wrapped.
-func (h *HelmDeployer) packageChart(r v1alpha2.HelmRelease) (string, error) {
+func (h *HelmDeployer) packageChart(r v1alpha3.HelmRelease) (string, error) {
 	tmp := os.TempDir()
 	packageArgs := []string{"package", r.ChartPath, "--destination", tmp}
 	if r.Packaged.Version != "" {
@@ -302,7 +503,6 @@ func (h *HelmDeployer) getReleaseInfo(release string) (*bufio.Reader, error) {
 }
 
 // Retrieve info about all releases using helm get
-// Skaffold labels will be applied to each deployed k8s object
 // Since helm isn't always consistent with retrieving results, don't return errors here
 func (h *HelmDeployer) getDeployResults(namespace string, release string) []Artifact {
 	b, err := h.getReleaseInfo(release)
@@ -313,7 +513,7 @@ func (h *HelmDeployer) getDeployResults(namespace string, release string) []Arti
 	return parseReleaseInfo(namespace, b)
 }
 
-func (h *HelmDeployer) deleteRelease(out io.Writer, r v1alpha2.HelmRelease) error {
+func (h *HelmDeployer) deleteRelease(out io.Writer, r v1alpha3.HelmRelease) error {
 	releaseName, err := evaluateReleaseName(r.Name)
 	if err != nil {
 		return errors.Wrap(err, "cannot parse the release name template")
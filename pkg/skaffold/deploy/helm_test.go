@@ -24,11 +24,13 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	"github.com/sirupsen/logrus"
@@ -48,8 +50,8 @@ var testBuildsFoo = []build.Artifact{
 	},
 }
 
-var testDeployConfig = &v1alpha2.HelmDeploy{
-	Releases: []v1alpha2.HelmRelease{
+var testDeployConfig = &v1alpha3.HelmDeploy{
+	Releases: []v1alpha3.HelmRelease{
 		{
 			Name:      "skaffold-helm",
 			ChartPath: "examples/test",
@@ -66,8 +68,8 @@ var testDeployConfig = &v1alpha2.HelmDeploy{
 	},
 }
 
-var testDeployHelmStyleConfig = &v1alpha2.HelmDeploy{
-	Releases: []v1alpha2.HelmRelease{
+var testDeployHelmStyleConfig = &v1alpha3.HelmDeploy{
+	Releases: []v1alpha3.HelmRelease{
 		{
 			Name:      "skaffold-helm",
 			ChartPath: "examples/test",
@@ -80,17 +82,17 @@ var testDeployHelmStyleConfig = &v1alpha2.HelmDeploy{
 			SetValues: map[string]string{
 				"some.key": "somevalue",
 			},
-			ImageStrategy: v1alpha2.HelmImageStrategy{
-				HelmImageConfig: v1alpha2.HelmImageConfig{
-					HelmConventionConfig: &v1alpha2.HelmConventionConfig{},
+			ImageStrategy: v1alpha3.HelmImageStrategy{
+				HelmImageConfig: v1alpha3.HelmImageConfig{
+					HelmConventionConfig: &v1alpha3.HelmConventionConfig{},
 				},
 			},
 		},
 	},
 }
 
-var testDeployConfigParameterUnmatched = &v1alpha2.HelmDeploy{
-	Releases: []v1alpha2.HelmRelease{
+var testDeployConfigParameterUnmatched = &v1alpha3.HelmDeploy{
+	Releases: []v1alpha3.HelmRelease{
 		{
 			Name:      "skaffold-helm",
 			ChartPath: "examples/test",
@@ -101,15 +103,15 @@ var testDeployConfigParameterUnmatched = &v1alpha2.HelmDeploy{
 	},
 }
 
-var testDeployFooWithPackaged = &v1alpha2.HelmDeploy{
-	Releases: []v1alpha2.HelmRelease{
+var testDeployFooWithPackaged = &v1alpha3.HelmDeploy{
+	Releases: []v1alpha3.HelmRelease{
 		{
 			Name:      "foo",
 			ChartPath: "testdata/foo",
 			Values: map[string]string{
 				"image": "foo",
 			},
-			Packaged: &v1alpha2.HelmPackaged{
+			Packaged: &v1alpha3.HelmPackaged{
 				Version:    "0.1.2",
 				AppVersion: "1.2.3",
 			},
@@ -117,8 +119,8 @@ var testDeployFooWithPackaged = &v1alpha2.HelmDeploy{
 	},
 }
 
-var testDeployWithTemplatedName = &v1alpha2.HelmDeploy{
-	Releases: []v1alpha2.HelmRelease{
+var testDeployWithTemplatedName = &v1alpha3.HelmDeploy{
+	Releases: []v1alpha3.HelmRelease{
 		{
 			Name:      "{{.USER}}-skaffold-helm",
 			ChartPath: "examples/test",
@@ -232,13 +234,13 @@ func TestHelmDeploy(t *testing.T) {
 		{
 			description: "deploy success",
 			cmd:         &MockHelm{t: t},
-			deployer:    NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace),
+			deployer:    NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
 			builds:      testBuilds,
 		},
 		{
 			description: "deploy error unmatched parameter",
 			cmd:         &MockHelm{t: t},
-			deployer:    NewHelmDeployer(testDeployConfigParameterUnmatched, testKubeContext, testNamespace),
+			deployer:    NewHelmDeployer(testDeployConfigParameterUnmatched, testKubeContext, testNamespace, false),
 			builds:      testBuilds,
 			shouldErr:   true,
 		},
@@ -258,7 +260,7 @@ func TestHelmDeploy(t *testing.T) {
 				},
 				upgradeResult: fmt.Errorf("should not have called upgrade"),
 			},
-			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace),
+			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
 			builds:   testBuilds,
 		},
 		{
@@ -278,7 +280,7 @@ func TestHelmDeploy(t *testing.T) {
 				},
 				upgradeResult: fmt.Errorf("should not have called upgrade"),
 			},
-			deployer: NewHelmDeployer(testDeployHelmStyleConfig, testKubeContext, testNamespace),
+			deployer: NewHelmDeployer(testDeployHelmStyleConfig, testKubeContext, testNamespace, false),
 			builds:   testBuilds,
 		},
 		{
@@ -287,7 +289,7 @@ func TestHelmDeploy(t *testing.T) {
 				t:             t,
 				installResult: fmt.Errorf("should not have called install"),
 			},
-			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace),
+			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
 			builds:   testBuilds,
 		},
 		{
@@ -297,7 +299,7 @@ func TestHelmDeploy(t *testing.T) {
 				upgradeResult: fmt.Errorf("unexpected error"),
 			},
 			shouldErr: true,
-			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace),
+			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
 			builds:    testBuilds,
 		},
 		{
@@ -307,7 +309,7 @@ func TestHelmDeploy(t *testing.T) {
 				depResult: fmt.Errorf("unexpected error"),
 			},
 			shouldErr: true,
-			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace),
+			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
 			builds:    testBuilds,
 		},
 		{
@@ -321,6 +323,7 @@ func TestHelmDeploy(t *testing.T) {
 				testDeployFooWithPackaged,
 				testKubeContext,
 				testNamespace,
+				false,
 			),
 			builds: testBuildsFoo,
 		},
@@ -335,13 +338,14 @@ func TestHelmDeploy(t *testing.T) {
 				testDeployFooWithPackaged,
 				testKubeContext,
 				testNamespace,
+				false,
 			),
 			builds: testBuildsFoo,
 		},
 		{
 			description: "deploy and get templated release name",
 			cmd:         &MockHelm{t: t},
-			deployer:    NewHelmDeployer(testDeployWithTemplatedName, testKubeContext, testNamespace),
+			deployer:    NewHelmDeployer(testDeployWithTemplatedName, testKubeContext, testNamespace, false),
 			builds:      testBuilds,
 		},
 	}
@@ -351,12 +355,169 @@ func TestHelmDeploy(t *testing.T) {
 			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
 			util.DefaultExecCommand = tt.cmd
 
-			_, err := tt.deployer.Deploy(context.Background(), &bytes.Buffer{}, tt.builds)
+			_, err := tt.deployer.Deploy(context.Background(), &bytes.Buffer{}, tt.builds, nil)
 			testutil.CheckError(t, tt.shouldErr, err)
 		})
 	}
 }
 
+func TestHelmDeployLabels(t *testing.T) {
+	var tests = []struct {
+		description       string
+		labelPodTemplates bool
+		expected          string
+	}{
+		{
+			description: "label the objects",
+			expected:    "commonLabels:\n  deployed-with: skaffold\n  skaffold-deployer: helm\n",
+		},
+		{
+			description:       "label the pod templates",
+			labelPodTemplates: true,
+			expected:          "commonLabels:\n  deployed-with: skaffold\n  skaffold-deployer: helm\npodLabels:\n  deployed-with: skaffold\n  skaffold-deployer: helm\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			deployer := NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, tt.labelPodTemplates)
+
+			var chart, values string
+			cmd := &MockHelm{
+				t: t,
+				upgradeMatcher: func(cmd *exec.Cmd) bool {
+					chart = cmd.Args[5]
+					for i, arg := range cmd.Args[:len(cmd.Args)-1] {
+						if arg == "-f" && cmd.Args[i+1] != constants.HelmOverridesFilename {
+							values = readFile(t, cmd.Args[i+1])
+						}
+					}
+					return true
+				},
+			}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = cmd
+
+			_, err := deployer.Deploy(context.Background(), &bytes.Buffer{}, testBuilds, []Labeller{deployer})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, "examples/test", chart)
+			testutil.CheckErrorAndDeepEqual(t, false, err, tt.expected, values)
+		})
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading %s: %v", path, err)
+	}
+	return string(content)
+}
+
+func TestHelmRender(t *testing.T) {
+	var tests = []struct {
+		description string
+		cmd         util.Command
+		deployer    *HelmDeployer
+		builds      []build.Artifact
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "render with the image values",
+			cmd: &MockHelm{
+				t:           t,
+				templateOut: bytes.NewBufferString("kind: Deployment\n"),
+				templateMatcher: func(cmd *exec.Cmd) bool {
+					args := strings.Join(cmd.Args[3:], " ")
+					return filepath.Base(cmd.Args[4]) == "test" &&
+						strings.HasPrefix(strings.Join(cmd.Args[5:], " "), "--name skaffold-helm --namespace testNamespace -f skaffold-overrides.yaml") &&
+						strings.Contains(args, "--set image="+testBuilds[0].Tag) &&
+						strings.Contains(args, "--set some.key=somevalue")
+				},
+			},
+			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
+			builds:   testBuilds,
+			expected: "kind: Deployment\n",
+		},
+		{
+			description: "keep warnings out of the manifests",
+			cmd: &MockHelm{
+				t:           t,
+				templateOut: bytes.NewBufferString("kind: Deployment\n"),
+				templateErr: bytes.NewBufferString("WARNING: deprecated chart\n"),
+			},
+			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
+			builds:   testBuilds,
+			expected: "kind: Deployment\n",
+		},
+		{
+			description: "render the packaged chart",
+			cmd: &MockHelm{
+				t:          t,
+				packageOut: bytes.NewBufferString("Packaged to " + os.TempDir() + "foo-0.1.2.tgz"),
+				templateMatcher: func(cmd *exec.Cmd) bool {
+					return cmd.Args[4] == filepath.Join(os.TempDir(), "foo-0.1.2.tgz")
+				},
+			},
+			deployer: NewHelmDeployer(testDeployFooWithPackaged, testKubeContext, testNamespace, false),
+			builds:   testBuildsFoo,
+		},
+		{
+			description: "fetch the chart of a repository",
+			cmd: &MockHelm{
+				t: t,
+				fetchMatcher: func(cmd *exec.Cmd) bool {
+					return strings.HasPrefix(strings.Join(cmd.Args[3:], " "), "fetch examples/test --untar --untardir ")
+				},
+				templateMatcher: func(cmd *exec.Cmd) bool {
+					return filepath.Base(cmd.Args[4]) == "test"
+				},
+			},
+			deployer: NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
+			builds:   testBuilds,
+		},
+		{
+			description: "fetch error",
+			cmd: &MockHelm{
+				t:           t,
+				fetchResult: fmt.Errorf("chart not found"),
+			},
+			shouldErr: true,
+			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
+			builds:    testBuilds,
+		},
+		{
+			description: "missing build result",
+			cmd:         &MockHelm{t: t},
+			deployer:    NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
+			shouldErr:   true,
+		},
+		{
+			description: "template error",
+			cmd: &MockHelm{
+				t:              t,
+				templateResult: fmt.Errorf("unexpected error"),
+			},
+			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace, false),
+			builds:    testBuilds,
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = tt.cmd
+
+			var out bytes.Buffer
+			err := tt.deployer.Render(context.Background(), &out, tt.builds)
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expected, out.String())
+		})
+	}
+}
+
 type CommandMatcher func(*exec.Cmd) bool
 
 type MockHelm struct {
@@ -370,8 +531,16 @@ type MockHelm struct {
 	upgradeMatcher CommandMatcher
 	depResult      error
 
+	templateOut     io.Reader
+	templateErr     io.Reader
+	templateResult  error
+	templateMatcher CommandMatcher
+
 	packageOut    io.Reader
 	packageResult error
+
+	fetchResult  error
+	fetchMatcher CommandMatcher
 }
 
 func (m *MockHelm) RunCmdOut(c *exec.Cmd) ([]byte, error) {
@@ -412,6 +581,21 @@ func (m *MockHelm) RunCmd(c *exec.Cmd) error {
 		return m.upgradeResult
 	case "dep":
 		return m.depResult
+	case "template":
+		if m.templateMatcher != nil && !m.templateMatcher(c) {
+			m.t.Errorf("template matcher failed to match cmd")
+		}
+		if m.templateOut != nil {
+			if _, err := io.Copy(c.Stdout, m.templateOut); err != nil {
+				m.t.Errorf("Failed to copy stdout")
+			}
+		}
+		if m.templateErr != nil {
+			if _, err := io.Copy(c.Stderr, m.templateErr); err != nil {
+				m.t.Errorf("Failed to copy stderr")
+			}
+		}
+		return m.templateResult
 	case "package":
 		if m.packageOut != nil {
 			if _, err := io.Copy(c.Stdout, m.packageOut); err != nil {
@@ -419,6 +603,11 @@ func (m *MockHelm) RunCmd(c *exec.Cmd) error {
 			}
 		}
 		return m.packageResult
+	case "fetch":
+		if m.fetchMatcher != nil && !m.fetchMatcher(c) {
+			m.t.Errorf("fetch matcher failed to match cmd")
+		}
+		return m.fetchResult
 	default:
 		m.t.Errorf("Unknown helm command: %+v", c)
 		return nil
@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
@@ -28,7 +29,7 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy/kubectl"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -40,19 +41,22 @@ var warner Warner = &logrusWarner{}
 
 // KubectlDeployer deploys workflows using kubectl CLI.
 type KubectlDeployer struct {
-	*v1alpha2.KubectlDeploy
+	*v1alpha3.KubectlDeploy
 
 	kubectl            kubectl.CLI
 	workingDir         string
+	labelPodTemplates  bool
 	previousDeployment manifestList
 }
 
 // NewKubectlDeployer returns a new KubectlDeployer for a DeployConfig filled
-// with the needed configuration for `kubectl apply`
-func NewKubectlDeployer(workingDir string, cfg *v1alpha2.KubectlDeploy, kubeContext string, namespace string) *KubectlDeployer {
+// with the needed configuration for `kubectl apply`. With labelPodTemplates,
+// the labels are also set on the pod templates.
+func NewKubectlDeployer(workingDir string, cfg *v1alpha3.KubectlDeploy, kubeContext string, namespace string, labelPodTemplates bool) *KubectlDeployer {
 	return &KubectlDeployer{
-		KubectlDeploy: cfg,
-		workingDir:    workingDir,
+		KubectlDeploy:     cfg,
+		workingDir:        workingDir,
+		labelPodTemplates: labelPodTemplates,
 		kubectl: kubectl.CLI{
 			Namespace:   namespace,
 			KubeContext: kubeContext,
@@ -67,9 +71,9 @@ func (k *KubectlDeployer) Labels() map[string]string {
 	}
 }
 
-// Deploy templates the provided manifests with a simple `find and replace` and
-// runs `kubectl apply` on those manifests
-func (k *KubectlDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]Artifact, error) {
+// Deploy templates the provided manifests with a simple `find and replace`, sets
+// their labels and runs `kubectl apply` on those manifests
+func (k *KubectlDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []Labeller) ([]Artifact, error) {
 	manifests, err := k.readManifests()
 	if err != nil {
 		return nil, errors.Wrap(err, "reading manifests")
@@ -84,6 +88,11 @@ func (k *KubectlDeployer) Deploy(ctx context.Context, out io.Writer, builds []bu
 		return nil, errors.Wrap(err, "replacing images in manifests")
 	}
 
+	manifests, err = manifests.setLabels(merge(labellers...), k.labelPodTemplates)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting labels in manifests")
+	}
+
 	// Only redeploy modified or new manifests
 	// TODO(dgageot): should we delete a manifest that was deployed and is not anymore?
 	updated := k.previousDeployment.diff(manifests)
@@ -101,6 +110,21 @@ func (k *KubectlDeployer) Deploy(ctx context.Context, out io.Writer, builds []bu
 	return parseManifestsForDeploys(updated)
 }
 
+// Render writes the manifests with their images replaced.
+func (k *KubectlDeployer) Render(ctx context.Context, out io.Writer, builds []build.Artifact) error {
+	manifests, err := k.readManifests()
+	if err != nil {
+		return errors.Wrap(err, "reading manifests")
+	}
+
+	manifests, err = manifests.replaceImages(builds)
+	if err != nil {
+		return errors.Wrap(err, "replacing images in manifests")
+	}
+
+	return writeManifests(out, manifests)
+}
+
 // Cleanup deletes what was deployed by calling Deploy.
 func (k *KubectlDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 	manifests, err := k.readManifests()
@@ -218,6 +242,18 @@ func (l *manifestList) String() string {
 	return str
 }
 
+func writeManifests(out io.Writer, manifests manifestList) error {
+	if manifests.Empty() {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(out, manifests.String()); err != nil {
+		return errors.Wrap(err, "writing manifests")
+	}
+
+	return nil
+}
+
 func (l *manifestList) Empty() bool {
 	return len(*l) == 0
 }
@@ -289,35 +325,69 @@ func (l *manifestList) replaceImages(builds []build.Artifact) (manifestList, err
 }
 
 func recursiveReplaceImage(i interface{}, replacements map[string]*replacement) {
+	recursiveVisitImages(i, func(m map[interface{}]interface{}, k interface{}, image string, parsed *docker.ImageReference) {
+		if img, present := replacements[parsed.BaseName]; present {
+			if parsed.FullyQualified {
+				if img.tag == image {
+					img.found = true
+				}
+			} else {
+				m[k] = img.tag
+				img.found = true
+			}
+		}
+	})
+}
+
+// recursiveVisitImages calls a visitor for each `image` field found in a
+// kubernetes manifest.
+func recursiveVisitImages(i interface{}, visit func(m map[interface{}]interface{}, k interface{}, image string, parsed *docker.ImageReference)) {
 	switch t := i.(type) {
 	case []interface{}:
 		for _, v := range t {
-			recursiveReplaceImage(v, replacements)
+			recursiveVisitImages(v, visit)
 		}
 	case map[interface{}]interface{}:
 		for k, v := range t {
 			if k.(string) != "image" {
-				recursiveReplaceImage(v, replacements)
+				recursiveVisitImages(v, visit)
 				continue
 			}
 
-			image := v.(string)
+			image, ok := v.(string)
+			if !ok {
+				continue
+			}
 			parsed, err := docker.ParseReference(image)
 			if err != nil {
 				warner.Warnf("Couldn't parse image: %s", v)
 				continue
 			}
 
-			if img, present := replacements[parsed.BaseName]; present {
-				if parsed.FullyQualified {
-					if img.tag == image {
-						img.found = true
-					}
-				} else {
-					t[k] = img.tag
-					img.found = true
-				}
-			}
+			visit(t, k, image, parsed)
 		}
 	}
 }
+
+// ParseImagesFromKubernetesYaml returns the names of the images referenced
+// in a kubernetes manifest file, without their tags.
+func ParseImagesFromKubernetesYaml(path string) ([]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading kubernetes manifest")
+	}
+
+	var images []string
+	for _, doc := range bytes.Split(contents, []byte("\n---")) {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(doc, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+
+		recursiveVisitImages(m, func(_ map[interface{}]interface{}, _ interface{}, _ string, parsed *docker.ImageReference) {
+			images = append(images, parsed.BaseName)
+		})
+	}
+
+	return images, nil
+}
@@ -27,7 +27,7 @@ import (
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	"github.com/pkg/errors"
@@ -69,7 +69,7 @@ func (l *fakeWarner) Warnf(format string, args ...interface{}) {
 func TestKubectlDeploy(t *testing.T) {
 	var tests = []struct {
 		description string
-		cfg         *v1alpha2.KubectlDeploy
+		cfg         *v1alpha3.KubectlDeploy
 		builds      []build.Artifact
 		command     util.Command
 		shouldErr   bool
@@ -77,7 +77,7 @@ func TestKubectlDeploy(t *testing.T) {
 		{
 			description: "parameter mismatch",
 			shouldErr:   true,
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
 			},
 			builds: []build.Artifact{
@@ -90,7 +90,7 @@ func TestKubectlDeploy(t *testing.T) {
 		{
 			description: "missing manifest file",
 			shouldErr:   true,
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
 			},
 			builds: []build.Artifact{
@@ -102,7 +102,7 @@ func TestKubectlDeploy(t *testing.T) {
 		},
 		{
 			description: "deploy success",
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
 			},
 			command: testutil.NewFakeCmd("kubectl --context kubecontext --namespace testNamespace apply -f -", nil),
@@ -116,7 +116,7 @@ func TestKubectlDeploy(t *testing.T) {
 		{
 			description: "deploy command error",
 			shouldErr:   true,
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
 			},
 			command: testutil.NewFakeCmd("kubectl --context kubecontext --namespace testNamespace apply -f -", fmt.Errorf("")),
@@ -130,9 +130,9 @@ func TestKubectlDeploy(t *testing.T) {
 		{
 			description: "additional flags",
 			shouldErr:   true,
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
-				Flags: v1alpha2.KubectlFlags{
+				Flags: v1alpha3.KubectlFlags{
 					Global: []string{"-v=0"},
 					Apply:  []string{"--overwrite=true"},
 					Delete: []string{"ignored"},
@@ -161,8 +161,8 @@ func TestKubectlDeploy(t *testing.T) {
 				util.DefaultExecCommand = test.command
 			}
 
-			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, testNamespace)
-			_, err := k.Deploy(context.Background(), &bytes.Buffer{}, test.builds)
+			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, testNamespace, false)
+			_, err := k.Deploy(context.Background(), &bytes.Buffer{}, test.builds, nil)
 
 			testutil.CheckError(t, test.shouldErr, err)
 		})
@@ -172,20 +172,20 @@ func TestKubectlDeploy(t *testing.T) {
 func TestKubectlCleanup(t *testing.T) {
 	var tests = []struct {
 		description string
-		cfg         *v1alpha2.KubectlDeploy
+		cfg         *v1alpha3.KubectlDeploy
 		command     util.Command
 		shouldErr   bool
 	}{
 		{
 			description: "cleanup success",
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
 			},
 			command: testutil.NewFakeCmd("kubectl --context kubecontext --namespace testNamespace delete --ignore-not-found=true -f -", nil),
 		},
 		{
 			description: "cleanup error",
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
 			},
 			command:   testutil.NewFakeCmd("kubectl --context kubecontext --namespace testNamespace delete --ignore-not-found=true -f -", errors.New("BUG")),
@@ -193,9 +193,9 @@ func TestKubectlCleanup(t *testing.T) {
 		},
 		{
 			description: "additional flags",
-			cfg: &v1alpha2.KubectlDeploy{
+			cfg: &v1alpha3.KubectlDeploy{
 				Manifests: []string{"test/deployment.yaml"},
-				Flags: v1alpha2.KubectlFlags{
+				Flags: v1alpha3.KubectlFlags{
 					Global: []string{"-v=0"},
 					Apply:  []string{"ignored"},
 					Delete: []string{"--grace-period=1"},
@@ -218,7 +218,7 @@ func TestKubectlCleanup(t *testing.T) {
 				util.DefaultExecCommand = test.command
 			}
 
-			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, testNamespace)
+			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, testNamespace, false)
 			err := k.Cleanup(context.Background(), &bytes.Buffer{})
 
 			testutil.CheckError(t, test.shouldErr, err)
@@ -226,6 +226,79 @@ func TestKubectlCleanup(t *testing.T) {
 	}
 }
 
+func TestKubectlRender(t *testing.T) {
+	var tests = []struct {
+		description string
+		cfg         *v1alpha3.KubectlDeploy
+		builds      []build.Artifact
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "render with replaced images",
+			cfg: &v1alpha3.KubectlDeploy{
+				Manifests: []string{"test/deployment.yaml"},
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "leeroy-web",
+					Tag:       "leeroy-web:123",
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: leeroy-web
+  name: leeroy-web
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: leeroy-web
+  template:
+    metadata:
+      labels:
+        app: leeroy-web
+    spec:
+      containers:
+      - image: leeroy-web:123
+        name: leeroy-web
+        ports:
+        - containerPort: 8080
+`,
+		},
+		{
+			description: "missing manifest file",
+			cfg: &v1alpha3.KubectlDeploy{
+				Manifests: []string{"test/missing.yaml"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "no manifest",
+			cfg:         &v1alpha3.KubectlDeploy{},
+		},
+	}
+
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	os.MkdirAll(filepath.Join(tmp, "test"), 0750)
+	ioutil.WriteFile(filepath.Join(tmp, "test", "deployment.yaml"), []byte(deploymentYAML), 0644)
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var out bytes.Buffer
+
+			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, testNamespace, false)
+			err := k.Render(context.Background(), &out, test.builds)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, out.String())
+		})
+	}
+}
+
 func TestReplaceImages(t *testing.T) {
 	manifests := manifestList{[]byte(`
 apiVersion: v1
@@ -26,20 +26,22 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy/kubectl"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 )
 
 type KustomizeDeployer struct {
-	*v1alpha2.KustomizeDeploy
+	*v1alpha3.KustomizeDeploy
 
-	kubectl kubectl.CLI
+	kubectl           kubectl.CLI
+	labelPodTemplates bool
 }
 
-func NewKustomizeDeployer(cfg *v1alpha2.KustomizeDeploy, kubeContext string, namespace string) *KustomizeDeployer {
+func NewKustomizeDeployer(cfg *v1alpha3.KustomizeDeploy, kubeContext string, namespace string, labelPodTemplates bool) *KustomizeDeployer {
 	return &KustomizeDeployer{
-		KustomizeDeploy: cfg,
+		KustomizeDeploy:   cfg,
+		labelPodTemplates: labelPodTemplates,
 		kubectl: kubectl.CLI{
 			Namespace:   namespace,
 			KubeContext: kubeContext,
@@ -54,7 +56,32 @@ func (k *KustomizeDeployer) Labels() map[string]string {
 	}
 }
 
-func (k *KustomizeDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]Artifact, error) {
+func (k *KustomizeDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []Labeller) ([]Artifact, error) {
+	manifestList, err := k.readManifests(builds)
+	if err != nil {
+		return nil, err
+	}
+	manifestList, err = manifestList.setLabels(merge(labellers...), k.labelPodTemplates)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting labels")
+	}
+	if err := k.kubectl.Run(manifestList.reader(), out, "apply", k.Flags.Apply, "-f", "-"); err != nil {
+		return nil, errors.Wrap(err, "running kubectl")
+	}
+	return parseManifestsForDeploys(manifestList)
+}
+
+// Render writes the kustomized manifests with their images replaced.
+func (k *KustomizeDeployer) Render(ctx context.Context, out io.Writer, builds []build.Artifact) error {
+	manifestList, err := k.readManifests(builds)
+	if err != nil {
+		return err
+	}
+
+	return writeManifests(out, manifestList)
+}
+
+func (k *KustomizeDeployer) readManifests(builds []build.Artifact) (manifestList, error) {
 	manifests, err := buildManifests(k.KustomizePath)
 	if err != nil {
 		return nil, errors.Wrap(err, "kustomize")
@@ -67,10 +94,7 @@ func (k *KustomizeDeployer) Deploy(ctx context.Context, out io.Writer, builds []
 	if err != nil {
 		return nil, errors.Wrap(err, "replacing images")
 	}
-	if err := k.kubectl.Run(manifestList.reader(), out, "apply", k.Flags.Apply, "-f", "-"); err != nil {
-		return nil, errors.Wrap(err, "running kubectl")
-	}
-	return parseManifestsForDeploys(manifestList)
+	return manifestList, nil
 }
 
 func newManifestList(r io.Reader) (manifestList, error) {
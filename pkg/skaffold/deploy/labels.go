@@ -17,27 +17,9 @@ limitations under the License.
 package deploy
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"time"
-
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
-	kubectx "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/context"
-
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/api/meta"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/types"
-	patch "k8s.io/apimachinery/pkg/util/strategicpatch"
-
-	"k8s.io/client-go/discovery"
-	"k8s.io/client-go/dynamic"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // Labeller can give key/value labels to set on deployed resources.
@@ -45,28 +27,6 @@ type Labeller interface {
 	Labels() map[string]string
 }
 
-type withLabels struct {
-	Deployer
-
-	labellers []Labeller
-}
-
-// WithLabels creates a deployer that sets labels on deployed resources.
-func WithLabels(d Deployer, labellers ...Labeller) Deployer {
-	return &withLabels{
-		Deployer:  d,
-		labellers: labellers,
-	}
-}
-
-func (w *withLabels) Deploy(ctx context.Context, out io.Writer, artifacts []build.Artifact) ([]Artifact, error) {
-	dRes, err := w.Deployer.Deploy(ctx, out, artifacts)
-
-	labelDeployResults(merge(w.labellers...), dRes)
-
-	return dRes, err
-}
-
 // merge merges the labels from multiple sources.
 func merge(sources ...Labeller) map[string]string {
 	merged := make(map[string]string)
@@ -82,115 +42,98 @@ func merge(sources ...Labeller) map[string]string {
 	return merged
 }
 
-// retry 3 times to give the object time to propagate to the API server
-const (
-	tries     = 3
-	sleeptime = 300 * time.Millisecond
-)
-
-func labelDeployResults(labels map[string]string, results []Artifact) {
-	// use the kubectl client to update all k8s objects with a skaffold watermark
-	dynClient, err := kubernetes.DynamicClient()
-	if err != nil {
-		logrus.Warnf("error retrieving kubernetes dynamic client: %s", err.Error())
-		return
-	}
+// setLabels sets the labels on each manifest before it's applied, so that the objects
+// are created or updated with their labels at once. With podTemplates, the labels are
+// also set on the pod templates of the workloads, which rolls out new pods each time the
+// labels change. The default labels don't replace the values found in the manifests.
+func (l *manifestList) setLabels(labels map[string]string, podTemplates bool) (manifestList, error) {
+	var updatedManifests manifestList
+
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
 
-	client, err := kubernetes.GetClientset()
-	if err != nil {
-		logrus.Warnf("error retrieving kubernetes client: %s", err.Error())
-		return
-	}
+		if len(m) == 0 {
+			continue
+		}
 
-	for _, res := range results {
-		err = nil
-		for i := 0; i < tries; i++ {
-			if err = updateRuntimeObject(dynClient, client.Discovery(), labels, res); err == nil {
-				break
+		objects := []map[interface{}]interface{}{m}
+		if m["kind"] == "List" {
+			objects = childMaps(m, "items")
+		}
+		for _, obj := range objects {
+			addLabels(obj, labels)
+			if template := podTemplate(obj); podTemplates && template != nil {
+				addLabels(template, labels)
 			}
-			time.Sleep(sleeptime)
 		}
+
+		updatedManifest, err := yaml.Marshal(m)
 		if err != nil {
-			logrus.Warnf("error adding label to runtime object: %s", err.Error())
+			return nil, errors.Wrap(err, "marshalling yaml")
 		}
+
+		updatedManifests = append(updatedManifests, updatedManifest)
 	}
+
+	return updatedManifests, nil
 }
 
-func addLabels(labels map[string]string, accessor metav1.Object) {
-	objLabels := accessor.GetLabels()
-	if objLabels == nil {
-		objLabels = make(map[string]string)
-	}
+// addLabels sets the labels in the metadata of an object or of a pod template.
+func addLabels(obj map[interface{}]interface{}, labels map[string]string) {
+	metadata := ensureChildMap(obj, "metadata")
+	objLabels := ensureChildMap(metadata, "labels")
+
 	for k, v := range constants.Labels.DefaultLabels {
 		if _, ok := objLabels[k]; !ok {
 			objLabels[k] = v
 		}
 	}
-	for key, value := range labels {
-		objLabels[key] = value
+	for k, v := range labels {
+		objLabels[k] = v
 	}
-	accessor.SetLabels(objLabels)
 }
 
-func updateRuntimeObject(client dynamic.Interface, disco discovery.DiscoveryInterface, labels map[string]string, res Artifact) error {
-	originalJSON, _ := json.Marshal(*res.Obj)
-	modifiedObj := (*res.Obj).DeepCopyObject()
-	accessor, err := meta.Accessor(modifiedObj)
-	if err != nil {
-		return errors.Wrap(err, "getting metadata accessor")
-	}
-	name := accessor.GetName()
-	namespace := accessor.GetNamespace()
-	addLabels(labels, accessor)
-
-	modifiedJSON, _ := json.Marshal(modifiedObj)
-	p, _ := patch.CreateTwoWayMergePatch(originalJSON, modifiedJSON, modifiedObj)
-	gvr, err := groupVersionResource(disco, modifiedObj.GetObjectKind().GroupVersionKind())
-	if err != nil {
-		return errors.Wrap(err, "getting group version resource from obj")
-	}
-	ns, err := resolveNamespace(namespace)
-	if err != nil {
-		return errors.Wrap(err, "resolving namespace")
-	}
-	if _, err := client.Resource(gvr).Namespace(ns).Patch(name, types.StrategicMergePatchType, p); err != nil {
-		return errors.Wrapf(err, "patching resource %s/%s", namespace, name)
+// podTemplate returns the pod template of a workload, or nil.
+// CronJobs have their pod template in their job template.
+func podTemplate(obj map[interface{}]interface{}) map[interface{}]interface{} {
+	spec := childMap(obj, "spec")
+	if jobTemplate := childMap(spec, "jobTemplate"); jobTemplate != nil {
+		spec = childMap(jobTemplate, "spec")
 	}
 
-	return nil
-}
-
-func resolveNamespace(ns string) (string, error) {
-	if ns != "" {
-		return ns, nil
-	}
-	cfg, err := kubectx.CurrentConfig()
-	if err != nil {
-		return "", errors.Wrap(err, "getting kubeconfig")
+	template := childMap(spec, "template")
+	if childMap(template, "spec") == nil {
+		return nil
 	}
+	return template
+}
 
-	current, present := cfg.Contexts[cfg.CurrentContext]
-	if present && current.Namespace != "" {
-		return current.Namespace, nil
-	}
-	return "default", nil
+func childMap(m map[interface{}]interface{}, key string) map[interface{}]interface{} {
+	child, _ := m[key].(map[interface{}]interface{})
+	return child
 }
 
-func groupVersionResource(disco discovery.DiscoveryInterface, gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
-	resources, err := disco.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
-	if err != nil {
-		return schema.GroupVersionResource{}, errors.Wrap(err, "getting server resources for group version")
-	}
+func childMaps(m map[interface{}]interface{}, key string) []map[interface{}]interface{} {
+	var children []map[interface{}]interface{}
 
-	for _, r := range resources.APIResources {
-		if r.Kind == gvk.Kind {
-			return schema.GroupVersionResource{
-				Group:    gvk.Group,
-				Version:  gvk.Version,
-				Resource: r.Name,
-			}, nil
+	items, _ := m[key].([]interface{})
+	for _, item := range items {
+		if child, ok := item.(map[interface{}]interface{}); ok {
+			children = append(children, child)
 		}
 	}
 
-	return schema.GroupVersionResource{}, fmt.Errorf("Could not find resource for %s", gvk.String())
+	return children
+}
+
+func ensureChildMap(m map[interface{}]interface{}, key string) map[interface{}]interface{} {
+	child := childMap(m, key)
+	if child == nil {
+		child = map[interface{}]interface{}{}
+		m[key] = child
+	}
+	return child
 }
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSetLabels(t *testing.T) {
+	var tests = []struct {
+		description  string
+		manifest     string
+		podTemplates bool
+		expected     string
+	}{
+		{
+			description: "object labels",
+			manifest: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    deployed-with: skaffold
+    skaffold-run-id: abc
+  name: web
+`,
+		},
+		{
+			description: "default labels are not overridden",
+			manifest: `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    deployed-with: kubectl
+    skaffold-run-id: old
+  name: web
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    deployed-with: kubectl
+    skaffold-run-id: abc
+  name: web
+`,
+		},
+		{
+			description: "pod templates are left alone by default",
+			manifest: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: web
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    deployed-with: skaffold
+    skaffold-run-id: abc
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: web
+`,
+		},
+		{
+			description:  "pod templates",
+			podTemplates: true,
+			manifest: `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          labels:
+            app: job
+        spec:
+          containers:
+          - image: job
+`,
+			expected: `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  labels:
+    deployed-with: skaffold
+    skaffold-run-id: abc
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          labels:
+            app: job
+            deployed-with: skaffold
+            skaffold-run-id: abc
+        spec:
+          containers:
+          - image: job
+`,
+		},
+		{
+			description: "list items",
+			manifest: `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: config
+`,
+			expected: `apiVersion: v1
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    labels:
+      deployed-with: skaffold
+      skaffold-run-id: abc
+    name: config
+kind: List
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			manifests := manifestList{[]byte(test.manifest)}
+			expected := manifestList{[]byte(test.expected)}
+
+			labelled, err := manifests.setLabels(map[string]string{"skaffold-run-id": "abc"}, test.podTemplates)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, expected.String(), labelled.String())
+		})
+	}
+}
+
+func TestSetLabelsInvalidManifest(t *testing.T) {
+	manifests := manifestList{[]byte("INVALID")}
+
+	_, err := manifests.setLabels(nil, false)
+
+	testutil.CheckError(t, true, err)
+}
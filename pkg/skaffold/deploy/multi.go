@@ -0,0 +1,127 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// MultiDeployer deploys with several deployers, one after the other.
+type MultiDeployer struct {
+	deployers []Deployer
+}
+
+// NewMultiDeployer returns a new MultiDeployer that composes
+// the given deployers, in order.
+func NewMultiDeployer(deployers ...Deployer) *MultiDeployer {
+	return &MultiDeployer{
+		deployers: deployers,
+	}
+}
+
+// Labels merges the labels of all the deployers. Values of a same
+// label are joined with a dash.
+func (m *MultiDeployer) Labels() map[string]string {
+	values := map[string][]string{}
+	var keys []string
+	for _, d := range m.deployers {
+		for k, v := range d.Labels() {
+			if _, present := values[k]; !present {
+				keys = append(keys, k)
+			}
+			if !util.StrSliceContains(values[k], v) {
+				values[k] = append(values[k], v)
+			}
+		}
+	}
+
+	labels := map[string]string{}
+	for _, k := range keys {
+		labels[k] = strings.Join(values[k], "-")
+	}
+	return labels
+}
+
+// Deploy runs each deployer in order and aggregates what they deployed.
+func (m *MultiDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []Labeller) ([]Artifact, error) {
+	var deployed []Artifact
+	for _, d := range m.deployers {
+		results, err := d.Deploy(ctx, out, builds, labellers)
+		deployed = append(deployed, results...)
+		if err != nil {
+			return deployed, err
+		}
+	}
+	return deployed, nil
+}
+
+// Dependencies merges the dependencies of all the deployers.
+func (m *MultiDeployer) Dependencies() ([]string, error) {
+	var deps []string
+	for _, d := range m.deployers {
+		result, err := d.Dependencies()
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, result...)
+	}
+	return util.UniqueStrSlice(deps), nil
+}
+
+// Cleanup runs the cleanup of each deployer, in reverse order.
+func (m *MultiDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	for i := len(m.deployers) - 1; i >= 0; i-- {
+		if err := m.deployers[i].Cleanup(ctx, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render writes the manifests of all the deployers, in order,
+// as a single yaml stream.
+func (m *MultiDeployer) Render(ctx context.Context, out io.Writer, builds []build.Artifact) error {
+	separate := false
+	for _, d := range m.deployers {
+		var buf bytes.Buffer
+		if err := d.Render(ctx, &buf, builds); err != nil {
+			return err
+		}
+
+		manifests := bytes.TrimSpace(buf.Bytes())
+		if len(manifests) == 0 {
+			continue
+		}
+		if separate {
+			if _, err := io.WriteString(out, "---\n"); err != nil {
+				return errors.Wrap(err, "writing manifests")
+			}
+		}
+		if _, err := out.Write(append(manifests, '\n')); err != nil {
+			return errors.Wrap(err, "writing manifests")
+		}
+		separate = true
+	}
+	return nil
+}
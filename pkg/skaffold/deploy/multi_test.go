@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeDeployer struct {
+	name      string
+	deps      []string
+	manifests string
+	err       error
+	calls     *[]string
+}
+
+func (f *fakeDeployer) Labels() map[string]string {
+	return map[string]string{constants.Labels.Deployer: f.name}
+}
+
+func (f *fakeDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []Labeller) ([]Artifact, error) {
+	*f.calls = append(*f.calls, "deploy "+f.name)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []Artifact{{Namespace: f.name}}, nil
+}
+
+func (f *fakeDeployer) Dependencies() ([]string, error) {
+	return f.deps, f.err
+}
+
+func (f *fakeDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	*f.calls = append(*f.calls, "cleanup "+f.name)
+	return f.err
+}
+
+func (f *fakeDeployer) Render(ctx context.Context, out io.Writer, builds []build.Artifact) error {
+	fmt.Fprint(out, f.manifests)
+	return f.err
+}
+
+func TestMultiDeployer(t *testing.T) {
+	var calls []string
+	m := NewMultiDeployer(
+		&fakeDeployer{name: "kubectl", deps: []string{"k8s/infra.yaml"}, manifests: "kind: Namespace\n", calls: &calls},
+		&fakeDeployer{name: "helm", deps: []string{"charts/app/values.yaml", "k8s/infra.yaml"}, calls: &calls},
+		&fakeDeployer{name: "kustomize", manifests: "kind: Deployment\n", calls: &calls},
+	)
+
+	deployed, err := m.Deploy(context.Background(), &bytes.Buffer{}, nil, nil)
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"kubectl", "helm", "kustomize"}, namespaces(deployed))
+
+	err = m.Cleanup(context.Background(), &bytes.Buffer{})
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{
+		"deploy kubectl", "deploy helm", "deploy kustomize",
+		"cleanup kustomize", "cleanup helm", "cleanup kubectl",
+	}, calls)
+
+	deps, err := m.Dependencies()
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"charts/app/values.yaml", "k8s/infra.yaml"}, deps)
+
+	var out bytes.Buffer
+	err = m.Render(context.Background(), &out, nil)
+	testutil.CheckErrorAndDeepEqual(t, false, err, "kind: Namespace\n---\nkind: Deployment\n", out.String())
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]string{constants.Labels.Deployer: "kubectl-helm-kustomize"}, m.Labels())
+}
+
+func TestMultiDeployerStopsOnError(t *testing.T) {
+	var calls []string
+	m := NewMultiDeployer(
+		&fakeDeployer{name: "kubectl", calls: &calls},
+		&fakeDeployer{name: "helm", err: errors.New("BUG"), calls: &calls},
+		&fakeDeployer{name: "kustomize", calls: &calls},
+	)
+
+	deployed, err := m.Deploy(context.Background(), &bytes.Buffer{}, nil, nil)
+
+	testutil.CheckError(t, true, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"kubectl"}, namespaces(deployed))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"deploy kubectl", "deploy helm"}, calls)
+}
+
+func namespaces(deployed []Artifact) []string {
+	var names []string
+	for _, a := range deployed {
+		names = append(names, a.Namespace)
+	}
+	return names
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// For testing
+var (
+	statusCheckInterval = 2 * time.Second
+	logLinesOnCrash     = int64(5)
+	lastLogLines        = podLogs
+)
+
+// workload is a deployed resource whose rollout can be followed.
+type workload struct {
+	kind      string
+	name      string
+	namespace string
+}
+
+func (w workload) String() string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(w.kind), w.name)
+}
+
+// rollout is the status of a workload at a given time.
+type rollout struct {
+	done     bool
+	pending  string
+	selector *metav1.LabelSelector
+}
+
+// StatusCheck waits for the deployed Deployments, StatefulSets, DaemonSets and
+// Jobs to finish their rollout. Problems found on their pods, like image pull
+// errors, crash loops or scheduling failures, are reported as soon as they are
+// seen. It fails if the rollouts are not complete before the deadline.
+func StatusCheck(ctx context.Context, out io.Writer, client kubernetes.Interface, deployed []Artifact, defaultNamespace string, deadline time.Duration) error {
+	workloads, err := workloadsToCheck(deployed, defaultNamespace)
+	if err != nil {
+		return errors.Wrap(err, "listing deployed workloads")
+	}
+	if len(workloads) == 0 {
+		return nil
+	}
+
+	color.Default.Fprintf(out, "Waiting for %d deployed resources to be ready...\n", len(workloads))
+
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	pending := map[workload]string{}
+	for _, w := range workloads {
+		pending[w] = "not checked yet"
+	}
+	reported := map[string]bool{}
+
+	ticker := time.NewTicker(statusCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, w := range workloads {
+			if _, present := pending[w]; !present {
+				continue
+			}
+
+			status, err := rolloutStatus(client, w)
+			if err != nil {
+				return errors.Wrapf(err, "checking %s", w)
+			}
+			if status.done {
+				color.Default.Fprintf(out, " - %s is ready.\n", w)
+				delete(pending, w)
+				continue
+			}
+			pending[w] = status.pending
+
+			problems, err := podProblems(client, w.namespace, status.selector)
+			if err != nil {
+				logrus.Debugf("Unable to list pods for %s: %s", w, err)
+			}
+			for _, problem := range problems {
+				if !reported[problem] {
+					reported[problem] = true
+					color.Red.Fprintf(out, " - %s: %s\n", w, problem)
+				}
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			var details []string
+			for _, w := range workloads {
+				if reason, present := pending[w]; present {
+					details = append(details, fmt.Sprintf("%s: %s", w, reason))
+				}
+			}
+			return fmt.Errorf("deployed resources not ready after %v:\n%s", deadline, strings.Join(details, "\n"))
+		case <-ticker.C:
+		}
+	}
+}
+
+func workloadsToCheck(deployed []Artifact, defaultNamespace string) ([]workload, error) {
+	seen := map[workload]bool{}
+	var workloads []workload
+
+	for _, artifact := range deployed {
+		if artifact.Obj == nil {
+			continue
+		}
+
+		obj := *artifact.Obj
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		switch kind {
+		case "Deployment", "StatefulSet", "DaemonSet", "Job":
+		default:
+			continue
+		}
+
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting metadata accessor")
+		}
+
+		namespace, err := artifactNamespace(artifact, defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		w := workload{kind: kind, name: accessor.GetName(), namespace: namespace}
+		if !seen[w] {
+			seen[w] = true
+			workloads = append(workloads, w)
+		}
+	}
+
+	return workloads, nil
+}
+
+// rolloutStatus follows the same rules as `kubectl rollout status`.
+func rolloutStatus(client kubernetes.Interface, w workload) (rollout, error) {
+	switch w.kind {
+	case "Deployment":
+		d, err := client.AppsV1().Deployments(w.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return getFailed(err)
+		}
+		return deploymentStatus(d)
+
+	case "StatefulSet":
+		s, err := client.AppsV1().StatefulSets(w.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return getFailed(err)
+		}
+		return statefulSetStatus(s), nil
+
+	case "DaemonSet":
+		d, err := client.AppsV1().DaemonSets(w.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return getFailed(err)
+		}
+		return daemonSetStatus(d), nil
+
+	case "Job":
+		j, err := client.BatchV1().Jobs(w.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return getFailed(err)
+		}
+		return jobStatus(j)
+
+	default:
+		return rollout{done: true}, nil
+	}
+}
+
+// getFailed fails the status check if the workload was deleted. Other errors
+// might be transient, so the workload is checked again until the deadline.
+func getFailed(err error) (rollout, error) {
+	if apierrors.IsNotFound(err) {
+		return rollout{}, err
+	}
+
+	logrus.Debugln("Unable to get the rollout status:", err)
+	return rollout{pending: fmt.Sprintf("unable to get its status: %s", err)}, nil
+}
+
+func deploymentStatus(d *appsv1.Deployment) (rollout, error) {
+	r := rollout{selector: d.Spec.Selector}
+
+	if d.Generation > d.Status.ObservedGeneration {
+		r.pending = "waiting for the rollout to start"
+		return r, nil
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return r, fmt.Errorf("rollout exceeded its progress deadline: %s", c.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		r.pending = fmt.Sprintf("%d out of %d new replicas have been updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		r.pending = fmt.Sprintf("%d old replicas are pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		r.pending = fmt.Sprintf("%d of %d updated replicas are available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	default:
+		r.done = true
+	}
+
+	return r, nil
+}
+
+func statefulSetStatus(s *appsv1.StatefulSet) rollout {
+	r := rollout{selector: s.Spec.Selector}
+
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+
+	switch {
+	case s.Generation > s.Status.ObservedGeneration:
+		r.pending = "waiting for the rollout to start"
+	case s.Status.ReadyReplicas < replicas:
+		r.pending = fmt.Sprintf("%d of %d replicas are ready", s.Status.ReadyReplicas, replicas)
+	case s.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType && s.Status.UpdateRevision != s.Status.CurrentRevision:
+		r.pending = fmt.Sprintf("%d of %d replicas have been updated", s.Status.UpdatedReplicas, replicas)
+	default:
+		r.done = true
+	}
+
+	return r
+}
+
+func daemonSetStatus(d *appsv1.DaemonSet) rollout {
+	r := rollout{selector: d.Spec.Selector}
+
+	switch {
+	case d.Generation > d.Status.ObservedGeneration:
+		r.pending = "waiting for the rollout to start"
+	case d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled:
+		r.pending = fmt.Sprintf("%d out of %d new pods have been updated", d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled)
+	case d.Status.NumberAvailable < d.Status.DesiredNumberScheduled:
+		r.pending = fmt.Sprintf("%d of %d updated pods are available", d.Status.NumberAvailable, d.Status.DesiredNumberScheduled)
+	default:
+		r.done = true
+	}
+
+	return r
+}
+
+func jobStatus(j *batchv1.Job) (rollout, error) {
+	r := rollout{selector: j.Spec.Selector}
+
+	for _, c := range j.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == v1.ConditionTrue {
+			return r, fmt.Errorf("job failed: %s", c.Message)
+		}
+	}
+
+	completions := int32(1)
+	if j.Spec.Completions != nil {
+		completions = *j.Spec.Completions
+	}
+
+	if j.Status.Succeeded >= completions {
+		r.done = true
+	} else {
+		r.pending = fmt.Sprintf("%d of %d completions", j.Status.Succeeded, completions)
+	}
+
+	return r, nil
+}
+
+// podProblems lists the reasons why the pods matching a selector are not healthy.
+func podProblems(client kubernetes.Interface, namespace string, selector *metav1.LabelSelector) ([]string, error) {
+	if selector == nil {
+		return nil, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing selector")
+	}
+	if s.Empty() {
+		s = labels.Nothing()
+	}
+
+	pods := client.CoreV1().Pods(namespace)
+	list, err := pods.List(metav1.ListOptions{LabelSelector: s.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing pods")
+	}
+
+	var problems []string
+	for _, pod := range list.Items {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && c.Reason == v1.PodReasonUnschedulable {
+				problems = append(problems, fmt.Sprintf("pod %s is unschedulable: %s", pod.Name, c.Message))
+			}
+		}
+
+		for _, c := range pod.Status.ContainerStatuses {
+			if c.State.Waiting == nil {
+				continue
+			}
+
+			switch reason := c.State.Waiting.Reason; reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError":
+				problems = append(problems, fmt.Sprintf("container %s in pod %s: %s: %s", c.Name, pod.Name, reason, c.State.Waiting.Message))
+
+			case "CrashLoopBackOff":
+				problem := fmt.Sprintf("container %s in pod %s is crashing (%d restarts)", c.Name, pod.Name, c.RestartCount)
+				if logs := lastLogLines(pods, pod.Name, c.Name); logs != "" {
+					problem += ", last logs:\n" + logs
+				}
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// podLogs returns the last log lines of a crashed container.
+func podLogs(pods corev1.PodInterface, name, container string) string {
+	r, err := pods.GetLogs(name, &v1.PodLogOptions{
+		Container: container,
+		Previous:  true,
+		TailLines: &logLinesOnCrash,
+	}).Stream()
+	if err != nil {
+		logrus.Debugf("Unable to get logs for %s/%s: %s", name, container, err)
+		return ""
+	}
+	defer r.Close()
+
+	logs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(logs), "\n")
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func deployment(name string, replicas, updated, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          updated,
+			UpdatedReplicas:   updated,
+			AvailableReplicas: available,
+		},
+	}
+}
+
+func deployed(objs ...runtime.Object) []Artifact {
+	var artifacts []Artifact
+	for i := range objs {
+		artifacts = append(artifacts, Artifact{Obj: &objs[i]})
+	}
+	return artifacts
+}
+
+func TestWorkloadsToCheck(t *testing.T) {
+	service := runtime.Object(&v1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "svc"},
+	})
+	job := runtime.Object(&batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{Kind: "Job", APIVersion: "batch/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "job"},
+	})
+	dep := runtime.Object(deployment("web", 1, 1, 1))
+
+	workloads, err := workloadsToCheck([]Artifact{
+		{Obj: &service},
+		{Obj: &job, Namespace: "jobs"},
+		{Obj: &dep, Namespace: "ignored"},
+		{Obj: &dep},
+	}, "default")
+
+	var names []string
+	for _, w := range workloads {
+		names = append(names, w.namespace+":"+w.String())
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"jobs:job/job", "ns:deployment/web"}, names)
+}
+
+func TestNamespaces(t *testing.T) {
+	service := runtime.Object(&v1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "svc"},
+	})
+	dep := runtime.Object(deployment("web", 1, 1, 1))
+
+	namespaces, err := Namespaces([]Artifact{
+		{Obj: &service},
+		{Obj: &service, Namespace: "release"},
+		{Obj: &dep, Namespace: "ignored"},
+		{Obj: &dep},
+		{Namespace: "release"},
+	}, "default")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"default", "release", "ns"}, namespaces)
+}
+
+func TestStatusCheck(t *testing.T) {
+	defer func(d time.Duration) { statusCheckInterval = d }(statusCheckInterval)
+	statusCheckInterval = 10 * time.Millisecond
+
+	defer func(f func(corev1.PodInterface, string, string) string) { lastLogLines = f }(lastLogLines)
+	lastLogLines = func(corev1.PodInterface, string, string) string { return "panic: missing config" }
+
+	completions := int32(1)
+	failedJob := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{Kind: "Job", APIVersion: "batch/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "ns"},
+		Spec:       batchv1.JobSpec{Completions: &completions},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Message: "BackoffLimitExceeded"}},
+		},
+	}
+
+	pod := func(name, app, reason string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: map[string]string{"app": app}},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:         "app",
+					RestartCount: 3,
+					State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason, Message: "details"}},
+				}},
+			},
+		}
+	}
+
+	var tests = []struct {
+		description    string
+		objects        []runtime.Object
+		pods           []runtime.Object
+		notFound       bool
+		failedGets     int
+		shouldErr      bool
+		expectedErr    string
+		expectedOutput []string
+	}{
+		{
+			description:    "ready",
+			objects:        []runtime.Object{deployment("web", 2, 2, 2)},
+			expectedOutput: []string{"deployment/web is ready"},
+		},
+		{
+			description: "image pull error",
+			objects:     []runtime.Object{deployment("web", 1, 1, 0)},
+			pods:        []runtime.Object{pod("web-1", "web", "ImagePullBackOff")},
+			shouldErr:   true,
+			expectedErr: "deployment/web: 0 of 1 updated replicas are available",
+			expectedOutput: []string{
+				"deployment/web: container app in pod web-1: ImagePullBackOff: details",
+			},
+		},
+		{
+			description: "crash loop",
+			objects:     []runtime.Object{deployment("web", 1, 1, 0)},
+			pods:        []runtime.Object{pod("web-1", "web", "CrashLoopBackOff")},
+			shouldErr:   true,
+			expectedOutput: []string{
+				"container app in pod web-1 is crashing (3 restarts), last logs:\npanic: missing config",
+			},
+		},
+		{
+			description:    "transient errors",
+			objects:        []runtime.Object{deployment("web", 1, 1, 1)},
+			failedGets:     2,
+			expectedOutput: []string{"deployment/web is ready"},
+		},
+		{
+			description: "transient errors until the deadline",
+			objects:     []runtime.Object{deployment("web", 1, 1, 1)},
+			failedGets:  100,
+			shouldErr:   true,
+			expectedErr: "deployment/web: unable to get its status",
+		},
+		{
+			description: "deleted",
+			objects:     []runtime.Object{deployment("web", 1, 1, 1)},
+			notFound:    true,
+			shouldErr:   true,
+			expectedErr: "not found",
+		},
+		{
+			description: "failed job",
+			objects:     []runtime.Object{failedJob},
+			shouldErr:   true,
+			expectedErr: "job failed: BackoffLimitExceeded",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			existing := test.pods
+			if !test.notFound {
+				existing = append(existing, test.objects...)
+			}
+			client := fake.NewSimpleClientset(existing...)
+
+			failedGets := test.failedGets
+			client.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if failedGets == 0 {
+					return false, nil, nil
+				}
+				failedGets--
+				return true, nil, apierrors.NewServiceUnavailable("try again later")
+			})
+
+			var out bytes.Buffer
+			err := StatusCheck(context.Background(), &out, client, deployed(test.objects...), "", 50*time.Millisecond)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if err != nil && !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("expected error to contain %q, got %q", test.expectedErr, err)
+			}
+			for _, expected := range test.expectedOutput {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected output to contain %q, got %q", expected, out.String())
+				}
+			}
+		})
+	}
+}
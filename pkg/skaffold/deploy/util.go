@@ -21,7 +21,10 @@ import (
 	"fmt"
 	"io"
 
+	kubectx "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/context"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
 
 	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -60,3 +63,59 @@ func parseReleaseInfo(namespace string, b *bufio.Reader) []Artifact {
 	}
 	return results
 }
+
+// Namespaces returns the namespaces of the deployed objects. The objects that
+// don't have one are in the default namespace.
+func Namespaces(deployed []Artifact, defaultNamespace string) ([]string, error) {
+	seen := map[string]bool{}
+	var namespaces []string
+
+	for _, artifact := range deployed {
+		namespace, err := artifactNamespace(artifact, defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces, nil
+}
+
+// artifactNamespace returns the namespace of a deployed object: the one found in its
+// metadata, the one it was deployed to or the default one, in that order.
+func artifactNamespace(artifact Artifact, defaultNamespace string) (string, error) {
+	if artifact.Obj != nil {
+		accessor, err := meta.Accessor(*artifact.Obj)
+		if err != nil {
+			return "", errors.Wrap(err, "getting metadata accessor")
+		}
+		if namespace := accessor.GetNamespace(); namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	if artifact.Namespace != "" {
+		return artifact.Namespace, nil
+	}
+	return resolveNamespace(defaultNamespace)
+}
+
+func resolveNamespace(ns string) (string, error) {
+	if ns != "" {
+		return ns, nil
+	}
+	cfg, err := kubectx.CurrentConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "getting kubeconfig")
+	}
+
+	current, present := cfg.Contexts[cfg.CurrentContext]
+	if present && current.Namespace != "" {
+		return current.Namespace, nil
+	}
+	return "default", nil
+}
@@ -24,7 +24,7 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 )
 
@@ -37,7 +37,7 @@ func TestDockerContext(t *testing.T) {
 		RetrieveImage = retrieveImage
 	}()
 
-	artifact := &v1alpha2.DockerArtifact{
+	artifact := &v1alpha3.DockerArtifact{
 		DockerfilePath: "Dockerfile",
 		BuildArgs:      map[string]*string{},
 	}
@@ -24,7 +24,7 @@ import (
 	"os"
 	"testing"
 
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	"github.com/google/go-cmp/cmp"
@@ -122,7 +122,7 @@ func TestRunBuildArtifact(t *testing.T) {
 		t.Run(test.description, func(t *testing.T) {
 			api := testutil.NewFakeImageAPIClient(test.tagToImageID, test.testOpts)
 
-			err := BuildArtifact(context.Background(), ioutil.Discard, api, ".", &v1alpha2.DockerArtifact{}, "finalimage")
+			err := BuildArtifact(context.Background(), ioutil.Discard, api, ".", &v1alpha3.DockerArtifact{}, "finalimage")
 
 			testutil.CheckError(t, test.shouldErr, err)
 		})
@@ -171,7 +171,7 @@ func TestDigest(t *testing.T) {
 }
 
 func TestGetBuildArgs(t *testing.T) {
-	artifact := &v1alpha2.DockerArtifact{
+	artifact := &v1alpha3.DockerArtifact{
 		BuildArgs: map[string]*string{
 			"key1": util.StringPtr("value1"),
 			"key2": nil,
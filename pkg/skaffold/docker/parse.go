@@ -26,7 +26,7 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 	"github.com/docker/docker/builder/dockerignore"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/google/go-containerregistry/pkg/v1"
@@ -185,7 +185,7 @@ func readDockerfile(workspace, absDockerfilePath string, buildArgs map[string]*s
 
 // GetDependencies finds the sources dependencies for the given docker artifact.
 // All paths are relative to the workspace.
-func GetDependencies(workspace string, a *v1alpha2.DockerArtifact) ([]string, error) {
+func GetDependencies(workspace string, a *v1alpha3.DockerArtifact) ([]string, error) {
 	absDockerfilePath, err := NormalizeDockerfilePath(workspace, a.DockerfilePath)
 	if err != nil {
 		return nil, errors.Wrap(err, "normalizing dockerfile path")
@@ -287,6 +287,29 @@ func GetDependencies(workspace string, a *v1alpha2.DockerArtifact) ([]string, er
 	return dependencies, nil
 }
 
+// ValidateDockerfile makes sure a file is a Dockerfile that can be parsed
+// and that has at least one FROM instruction.
+func ValidateDockerfile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	res, err := parser.Parse(f)
+	if err != nil || res == nil {
+		return false
+	}
+
+	for _, value := range res.AST.Children {
+		if value.Value == command.From {
+			return true
+		}
+	}
+
+	return false
+}
+
 func processBaseImage(value *parser.Node) ([]string, error) {
 	base := value.Next.Value
 	logrus.Debugf("Checking base image %s for ONBUILD triggers.", base)
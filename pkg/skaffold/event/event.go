@@ -0,0 +1,197 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+)
+
+// Status of a build or a deploy.
+const (
+	NotStarted = "Not Started"
+	InProgress = "In Progress"
+	Complete   = "Complete"
+	Failed     = "Failed"
+)
+
+// maxHistory is the number of past events sent to the clients that connect late.
+const maxHistory = 1000
+
+// Event is a change in the state of a pipeline.
+// Exactly one of BuildEvent, DeployEvent and LogEvent is set.
+type Event struct {
+	Timestamp   time.Time    `json:"timestamp"`
+	BuildEvent  *BuildEvent  `json:"buildEvent,omitempty"`
+	DeployEvent *DeployEvent `json:"deployEvent,omitempty"`
+	LogEvent    *LogEvent    `json:"logEvent,omitempty"`
+}
+
+// BuildEvent describes the progress of the build of an artifact.
+type BuildEvent struct {
+	Artifact string `json:"artifact"`
+	Status   string `json:"status"`
+	Err      string `json:"err,omitempty"`
+}
+
+// DeployEvent describes the progress of the deploy.
+type DeployEvent struct {
+	Status string `json:"status"`
+	Err    string `json:"err,omitempty"`
+}
+
+// LogEvent is a line logged by a deployed container.
+type LogEvent struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Message   string `json:"message"`
+}
+
+// State is the current state of a pipeline.
+type State struct {
+	// Build is the status of the build of each artifact.
+	Build  map[string]string `json:"build"`
+	Deploy string            `json:"deploy"`
+}
+
+type eventHandler struct {
+	mu        sync.Mutex
+	state     State
+	history   []Event
+	listeners map[chan Event]bool
+
+	// file writes the events synchronously, so that none are missing from the event log.
+	file func(Event)
+}
+
+var handler = newHandler()
+
+func newHandler() *eventHandler {
+	return &eventHandler{
+		state:     State{Build: map[string]string{}, Deploy: NotStarted},
+		listeners: map[chan Event]bool{},
+	}
+}
+
+// InitializeState resets the state of the pipeline to the artifacts
+// of a configuration, none of them being built.
+func InitializeState(artifacts []*v1alpha3.Artifact) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	handler.state = State{Build: map[string]string{}, Deploy: NotStarted}
+	for _, a := range artifacts {
+		handler.state.Build[a.ImageName] = NotStarted
+	}
+}
+
+// GetState returns a copy of the current state of the pipeline.
+func GetState() State {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	state := State{Build: map[string]string{}, Deploy: handler.state.Deploy}
+	for image, status := range handler.state.Build {
+		state.Build[image] = status
+	}
+	return state
+}
+
+// BuildInProgress notifies that an artifact is being built.
+func BuildInProgress(imageName string) {
+	handler.handle(Event{BuildEvent: &BuildEvent{Artifact: imageName, Status: InProgress}})
+}
+
+// BuildFailed notifies that an artifact failed to build.
+func BuildFailed(imageName string, err error) {
+	handler.handle(Event{BuildEvent: &BuildEvent{Artifact: imageName, Status: Failed, Err: err.Error()}})
+}
+
+// BuildComplete notifies that an artifact was built.
+func BuildComplete(imageName string) {
+	handler.handle(Event{BuildEvent: &BuildEvent{Artifact: imageName, Status: Complete}})
+}
+
+// DeployInProgress notifies that the deploy started.
+func DeployInProgress() {
+	handler.handle(Event{DeployEvent: &DeployEvent{Status: InProgress}})
+}
+
+// DeployFailed notifies that the deploy failed.
+func DeployFailed(err error) {
+	handler.handle(Event{DeployEvent: &DeployEvent{Status: Failed, Err: err.Error()}})
+}
+
+// DeployComplete notifies that the deploy is complete.
+func DeployComplete() {
+	handler.handle(Event{DeployEvent: &DeployEvent{Status: Complete}})
+}
+
+// Log notifies of a line logged by a container.
+func Log(pod, container, message string) {
+	handler.handle(Event{LogEvent: &LogEvent{Pod: pod, Container: container, Message: message}})
+}
+
+func (h *eventHandler) handle(event Event) {
+	event.Timestamp = time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case event.BuildEvent != nil:
+		h.state.Build[event.BuildEvent.Artifact] = event.BuildEvent.Status
+	case event.DeployEvent != nil:
+		h.state.Deploy = event.DeployEvent.Status
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > maxHistory {
+		h.history = h.history[len(h.history)-maxHistory:]
+	}
+
+	if h.file != nil {
+		h.file(event)
+	}
+	for listener := range h.listeners {
+		select {
+		case listener <- event:
+		default:
+			// Don't block the pipeline on a slow listener.
+		}
+	}
+}
+
+// subscribe returns the past events and a channel that receives the new ones.
+func (h *eventHandler) subscribe() ([]Event, chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	listener := make(chan Event, maxHistory)
+	h.listeners[listener] = true
+
+	return append([]Event(nil), h.history...), listener
+}
+
+func (h *eventHandler) unsubscribe(listener chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.listeners, listener)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestState(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	InitializeState([]*v1alpha3.Artifact{{ImageName: "web"}, {ImageName: "worker"}})
+	testutil.CheckErrorAndDeepEqual(t, false, nil, State{
+		Build:  map[string]string{"web": NotStarted, "worker": NotStarted},
+		Deploy: NotStarted,
+	}, GetState())
+
+	BuildInProgress("web")
+	BuildInProgress("worker")
+	BuildComplete("web")
+	BuildFailed("worker", errors.New("no space left"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, State{
+		Build:  map[string]string{"web": Complete, "worker": Failed},
+		Deploy: NotStarted,
+	}, GetState())
+
+	DeployInProgress()
+	DeployComplete()
+	Log("web-1234", "web", "Hello world!")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, State{
+		Build:  map[string]string{"web": Complete, "worker": Failed},
+		Deploy: Complete,
+	}, GetState())
+}
+
+func TestHistory(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	for i := 0; i < maxHistory+10; i++ {
+		Log("pod", "container", "line")
+	}
+	DeployFailed(errors.New("invalid manifest"))
+
+	history, listener := handler.subscribe()
+	defer handler.unsubscribe(listener)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, maxHistory, len(history))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &DeployEvent{Status: Failed, Err: "invalid manifest"}, history[maxHistory-1].DeployEvent)
+
+	BuildInProgress("web")
+	event := <-listener
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &BuildEvent{Artifact: "web", Status: InProgress}, event.BuildEvent)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Paths of the HTTP endpoints.
+const (
+	EventsPath = "/v1/events"
+	StatePath  = "/v1/state"
+)
+
+// StartServer serves the state of the pipeline and streams its events as
+// newline-delimited JSON on a local port. It returns a function that stops the server.
+func StartServer(port int) (func(), error) {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, errors.Wrap(err, "listening for event requests")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatePath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GetState())
+	})
+	mux.HandleFunc(EventsPath, func(w http.ResponseWriter, r *http.Request) {
+		history, listener := handler.subscribe()
+		defer handler.unsubscribe(listener)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		flusher, _ := w.(http.Flusher)
+
+		for _, event := range history {
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		}
+		for {
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+			select {
+			case event := <-listener:
+				if err := encoder.Encode(event); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			logrus.Warnln("Serving events:", err)
+		}
+	}()
+
+	return func() { server.Close() }, nil
+}
+
+// LogToFile writes the events to a file as newline-delimited JSON.
+// It returns a function that closes the file.
+func LogToFile(filename string) (func() error, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating event log file")
+	}
+
+	encoder := json.NewEncoder(f)
+	failed := false
+
+	handler.mu.Lock()
+	handler.file = func(event Event) {
+		if err := encoder.Encode(event); err != nil && !failed {
+			logrus.Warnln("Writing event log:", err)
+			failed = true
+		}
+	}
+	handler.mu.Unlock()
+
+	return func() error {
+		handler.mu.Lock()
+		handler.file = nil
+		handler.mu.Unlock()
+
+		return f.Close()
+	}, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	port := freePort(t)
+	stop, err := StartServer(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	InitializeState([]*v1alpha3.Artifact{{ImageName: "web"}})
+	BuildInProgress("web")
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, StatePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state State
+	err = json.NewDecoder(resp.Body).Decode(&state)
+	resp.Body.Close()
+	testutil.CheckErrorAndDeepEqual(t, false, err, State{Build: map[string]string{"web": InProgress}, Deploy: NotStarted}, state)
+
+	resp, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, EventsPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewScanner(resp.Body)
+
+	// Past events are sent first.
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &BuildEvent{Artifact: "web", Status: InProgress}, nextEvent(t, events).BuildEvent)
+
+	BuildComplete("web")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &BuildEvent{Artifact: "web", Status: Complete}, nextEvent(t, events).BuildEvent)
+}
+
+func nextEvent(t *testing.T, events *bufio.Scanner) Event {
+	if !events.Scan() {
+		t.Fatal("no more events", events.Err())
+	}
+
+	var event Event
+	if err := json.Unmarshal(events.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestLogToFile(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	filename := filepath.Join(tmpDir, "events.json")
+
+	stop, err := LogToFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	DeployInProgress()
+	Log("web-1234", "web", "Hello world!")
+	err = stop()
+	testutil.CheckError(t, false, err)
+	DeployComplete()
+
+	contents, err := ioutil.ReadFile(filename)
+	testutil.CheckError(t, false, err)
+
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, len(lines))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, strings.Contains(lines[0], `"deployEvent":{"status":"In Progress"}`))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, strings.Contains(lines[1], `"logEvent":{"pod":"web-1234","container":"web","message":"Hello world!"}`))
+}
@@ -0,0 +1,508 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package initializer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	bazelWorkspace = "WORKSPACE"
+	helmChart      = "Chart.yaml"
+	helmValues     = "values.yaml"
+	kustomization  = "kustomization.yaml"
+)
+
+// Config defines the behavior of `skaffold init`.
+type Config struct {
+	// ConfigFile is where the generated configuration is written.
+	ConfigFile string
+
+	// Artifacts are `buildFile=image` pairings. When set, skaffold init
+	// doesn't ask any question.
+	Artifacts []string
+
+	// Force overwrites an existing configuration file.
+	Force bool
+}
+
+// project lists what was found in the working tree.
+type project struct {
+	buildFiles     []string
+	manifests      []string
+	charts         []string
+	kustomizations []string
+}
+
+// DoInit scans the current directory for build files and kubernetes
+// manifests and generates a skaffold configuration from them.
+func DoInit(out io.Writer, in io.Reader, c Config) error {
+	if !c.Force {
+		if _, err := os.Stat(c.ConfigFile); err == nil {
+			return fmt.Errorf("pre-existing %s found, use --force to overwrite it", c.ConfigFile)
+		}
+	}
+
+	p, err := walk(".", c.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "scanning project")
+	}
+
+	deployCfg, images, err := generateDeployConfig(p)
+	if err != nil {
+		return err
+	}
+
+	interactive := len(c.Artifacts) == 0
+	reader := bufio.NewReader(in)
+
+	var artifacts []*v1alpha3.Artifact
+	if interactive {
+		artifacts, err = promptArtifacts(out, reader, images, p.buildFiles)
+	} else {
+		artifacts, err = parseArtifacts(c.Artifacts)
+	}
+	if err != nil {
+		return errors.Wrap(err, "pairing images with build files")
+	}
+
+	cfg := &config.SkaffoldConfig{
+		APIVersion: config.LatestVersion,
+		Kind:       "Config",
+		Build: v1alpha3.BuildConfig{
+			Artifacts: artifacts,
+		},
+		Deploy: deployCfg,
+	}
+
+	contents, err := marshalWithoutNulls(cfg)
+	if err != nil {
+		return errors.Wrap(err, "marshalling generated config")
+	}
+
+	if _, err := config.GetConfig(contents, false); err != nil {
+		return errors.Wrap(err, "validating generated config")
+	}
+
+	if interactive {
+		out.Write(contents)
+
+		color.Default.Fprintf(out, "Do you want to write this configuration to %s? [y/n]: ", c.ConfigFile)
+		answer, err := readLine(reader)
+		if err != nil {
+			return errors.Wrap(err, "reading answer")
+		}
+		if !strings.HasPrefix(strings.ToLower(answer), "y") {
+			return nil
+		}
+	}
+
+	if err := ioutil.WriteFile(c.ConfigFile, contents, 0644); err != nil {
+		return errors.Wrap(err, "writing config to file")
+	}
+
+	color.Default.Fprintf(out, "Configuration %s was written\n", c.ConfigFile)
+	return nil
+}
+
+// marshalWithoutNulls marshals a configuration without
+// the fields of union types that are not set.
+func marshalWithoutNulls(cfg *config.SkaffoldConfig) ([]byte, error) {
+	buf, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(removeNulls(m))
+}
+
+func removeNulls(i interface{}) interface{} {
+	switch t := i.(type) {
+	case yaml.MapSlice:
+		var m yaml.MapSlice
+		for _, item := range t {
+			if item.Value != nil {
+				m = append(m, yaml.MapItem{Key: item.Key, Value: removeNulls(item.Value)})
+			}
+		}
+		return m
+	case []interface{}:
+		for i, v := range t {
+			t[i] = removeNulls(v)
+		}
+	}
+	return i
+}
+
+// walk lists the build files, kubernetes manifests, helm charts
+// and kustomizations found under a root directory.
+func walk(root, configFile string) (*project, error) {
+	p := &project{}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := info.Name()
+
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, helmChart)); err == nil {
+				p.charts = append(p.charts, path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case name == bazelWorkspace:
+			p.buildFiles = append(p.buildFiles, path)
+
+		case isDockerfile(name):
+			if docker.ValidateDockerfile(path) {
+				p.buildFiles = append(p.buildFiles, path)
+			}
+
+		case name == kustomization:
+			p.kustomizations = append(p.kustomizations, filepath.Dir(path))
+
+		case isYaml(name) && filepath.Clean(path) != filepath.Clean(configFile):
+			if isKubernetesManifest(path) {
+				p.manifests = append(p.manifests, path)
+			}
+		}
+
+		return nil
+	})
+
+	return p, err
+}
+
+func isDockerfile(name string) bool {
+	return name == constants.DefaultDockerfilePath ||
+		strings.HasPrefix(name, constants.DefaultDockerfilePath+".") ||
+		strings.HasSuffix(strings.ToLower(name), ".dockerfile")
+}
+
+func isYaml(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// isKubernetesManifest checks that every document in a yaml file
+// has an apiVersion and a kind and is not a skaffold configuration.
+func isKubernetesManifest(path string) bool {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	found := false
+	for _, doc := range strings.Split(string(contents), "\n---") {
+		m := make(map[string]interface{})
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			return false
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		apiVersion, ok := m["apiVersion"].(string)
+		if !ok || strings.HasPrefix(apiVersion, "skaffold/") {
+			return false
+		}
+		if _, ok := m["kind"].(string); !ok {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
+
+// generateDeployConfig chooses a deployer and lists the images it deploys.
+// Kustomize is preferred over Helm, which is preferred over kubectl.
+func generateDeployConfig(p *project) (v1alpha3.DeployConfig, []string, error) {
+	var cfg v1alpha3.DeployConfig
+	var images []string
+
+	switch {
+	case len(p.kustomizations) > 0:
+		if len(p.kustomizations) > 1 {
+			logrus.Warnf("Found multiple kustomizations, using %s", p.kustomizations[0])
+		}
+
+		cfg.KustomizeDeploy = &v1alpha3.KustomizeDeploy{}
+		if p.kustomizations[0] != "." {
+			cfg.KustomizeDeploy.KustomizePath = filepath.ToSlash(p.kustomizations[0])
+		}
+
+		for _, manifest := range p.manifests {
+			found, err := deploy.ParseImagesFromKubernetesYaml(manifest)
+			if err != nil {
+				return cfg, nil, errors.Wrapf(err, "parsing images from %s", manifest)
+			}
+			images = append(images, found...)
+		}
+
+	case len(p.charts) > 0:
+		cfg.HelmDeploy = &v1alpha3.HelmDeploy{}
+
+		for _, chart := range p.charts {
+			values, err := imagesFromValues(filepath.Join(chart, helmValues))
+			if err != nil {
+				return cfg, nil, errors.Wrapf(err, "parsing images from chart %s", chart)
+			}
+
+			release := v1alpha3.HelmRelease{
+				Name:      filepath.Base(chart),
+				ChartPath: filepath.ToSlash(chart),
+			}
+			if len(values) > 0 {
+				release.Values = values
+			}
+			cfg.HelmDeploy.Releases = append(cfg.HelmDeploy.Releases, release)
+
+			for _, image := range values {
+				images = append(images, image)
+			}
+		}
+
+	case len(p.manifests) > 0:
+		cfg.KubectlDeploy = &v1alpha3.KubectlDeploy{}
+
+		for _, manifest := range p.manifests {
+			found, err := deploy.ParseImagesFromKubernetesYaml(manifest)
+			if err != nil {
+				return cfg, nil, errors.Wrapf(err, "parsing images from %s", manifest)
+			}
+			images = append(images, found...)
+			cfg.KubectlDeploy.Manifests = append(cfg.KubectlDeploy.Manifests, filepath.ToSlash(manifest))
+		}
+
+	default:
+		return cfg, nil, errors.New("one or more valid kubernetes manifests, helm charts or kustomizations is required to run skaffold")
+	}
+
+	return cfg, unique(images), nil
+}
+
+// imagesFromValues maps the `image` fields of a helm chart's values
+// to the images they reference.
+func imagesFromValues(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(contents, &m); err != nil {
+		return nil, errors.Wrap(err, "reading helm values")
+	}
+
+	values := map[string]string{}
+	recursiveFindImages("", m, values)
+	return values, nil
+}
+
+func recursiveFindImages(prefix string, m map[interface{}]interface{}, values map[string]string) {
+	for k, v := range m {
+		key := fmt.Sprintf("%v", k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch t := v.(type) {
+		case map[interface{}]interface{}:
+			recursiveFindImages(key, t, values)
+		case string:
+			if k != "image" {
+				continue
+			}
+			parsed, err := docker.ParseReference(t)
+			if err != nil {
+				logrus.Warnf("Couldn't parse image: %s", t)
+				continue
+			}
+			values[key] = parsed.BaseName
+		}
+	}
+}
+
+// promptArtifacts asks the user which build file builds each image.
+func promptArtifacts(out io.Writer, reader *bufio.Reader, images, buildFiles []string) ([]*v1alpha3.Artifact, error) {
+	var artifacts []*v1alpha3.Artifact
+
+	for _, image := range images {
+		if len(buildFiles) == 0 {
+			break
+		}
+
+		color.Default.Fprintf(out, "? Choose the builder to build image %s\n", image)
+		for i, buildFile := range buildFiles {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, buildFile)
+		}
+		fmt.Fprintf(out, "  %d) None (image not built from these sources)\n", len(buildFiles)+1)
+
+		choice, err := promptChoice(out, reader, len(buildFiles)+1)
+		if err != nil {
+			return nil, err
+		}
+		if choice == len(buildFiles) {
+			continue
+		}
+
+		buildFile := buildFiles[choice]
+		buildFiles = append(buildFiles[:choice:choice], buildFiles[choice+1:]...)
+
+		var target string
+		if filepath.Base(buildFile) == bazelWorkspace {
+			color.Default.Fprintf(out, "? Bazel target that builds image %s: ", image)
+			if target, err = readLine(reader); err != nil {
+				return nil, err
+			}
+		}
+
+		artifacts = append(artifacts, newArtifact(buildFile, target, image))
+	}
+
+	return artifacts, nil
+}
+
+func promptChoice(out io.Writer, reader *bufio.Reader, count int) (int, error) {
+	for {
+		fmt.Fprint(out, "Choice: ")
+
+		line, err := readLine(reader)
+		if err != nil {
+			return 0, err
+		}
+
+		choice, err := strconv.Atoi(line)
+		if err == nil && choice >= 1 && choice <= count {
+			return choice - 1, nil
+		}
+
+		fmt.Fprintf(out, "Please enter a number between 1 and %d\n", count)
+	}
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", errors.Wrap(err, "reading input")
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// parseArtifacts reads `buildFile=image` pairings. Bazel workspaces
+// also need a target: `path/WORKSPACE:target=image`.
+func parseArtifacts(pairs []string) ([]*v1alpha3.Artifact, error) {
+	var artifacts []*v1alpha3.Artifact
+
+	for _, pair := range pairs {
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 || i == len(pair)-1 {
+			return nil, fmt.Errorf("invalid artifact %s, expected buildFile=image", pair)
+		}
+		buildFile, image := pair[:i], pair[i+1:]
+
+		var target string
+		if j := strings.Index(buildFile, bazelWorkspace+":"); j != -1 && filepath.Base(buildFile[:j+len(bazelWorkspace)]) == bazelWorkspace {
+			buildFile, target = buildFile[:j+len(bazelWorkspace)], buildFile[j+len(bazelWorkspace)+1:]
+		} else if filepath.Base(buildFile) == bazelWorkspace {
+			return nil, fmt.Errorf("invalid artifact %s, expected %s:target=image", pair, buildFile)
+		}
+
+		if _, err := os.Stat(buildFile); err != nil {
+			return nil, errors.Wrapf(err, "invalid artifact %s", pair)
+		}
+
+		artifacts = append(artifacts, newArtifact(buildFile, target, image))
+	}
+
+	return artifacts, nil
+}
+
+func newArtifact(buildFile, target, image string) *v1alpha3.Artifact {
+	a := &v1alpha3.Artifact{
+		ImageName: image,
+	}
+
+	if workspace := filepath.Dir(buildFile); workspace != "." {
+		a.Workspace = filepath.ToSlash(workspace)
+	}
+
+	name := filepath.Base(buildFile)
+	if name == bazelWorkspace {
+		a.BazelArtifact = &v1alpha3.BazelArtifact{
+			BuildTarget: target,
+		}
+		return a
+	}
+
+	a.DockerArtifact = &v1alpha3.DockerArtifact{}
+	if name != constants.DefaultDockerfilePath {
+		a.DockerArtifact.DockerfilePath = name
+	}
+	return a
+}
+
+func unique(values []string) []string {
+	set := map[string]bool{}
+	var ret []string
+
+	for _, value := range values {
+		if !set[value] {
+			set[value] = true
+			ret = append(ret, value)
+		}
+	}
+
+	sort.Strings(ret)
+	return ret
+}
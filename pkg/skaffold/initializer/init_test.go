@@ -0,0 +1,318 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package initializer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: gcr.io/project/web:v1
+      - name: sidecar
+        image: gcr.io/project/sidecar
+`
+
+const service = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	writeFiles(t, tmpDir, map[string]string{
+		"Dockerfile":                    "FROM scratch",
+		"backend/Dockerfile.dev":        "FROM golang",
+		"docs/Dockerfile.md":            "not a dockerfile",
+		"bazel/WORKSPACE":               "",
+		"k8s/deployment.yaml":           deployment + "---\n" + service,
+		"k8s/config.yaml":               "key: value",
+		"skaffold.yaml":                 "apiVersion: skaffold/v1alpha3\nkind: Config",
+		"charts/app/Chart.yaml":         "name: app",
+		"charts/app/templates/pod.yaml": "{{ .Values.image }}",
+		"overlay/kustomization.yaml":    "resources: []",
+		".git/Dockerfile":               "FROM scratch",
+		"vendor/lib/Dockerfile":         "FROM scratch",
+	})
+
+	p, err := walk(tmpDir, filepath.Join(tmpDir, "skaffold.yaml"))
+
+	testutil.CheckError(t, false, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		filepath.Join(tmpDir, "Dockerfile"),
+		filepath.Join(tmpDir, "backend", "Dockerfile.dev"),
+		filepath.Join(tmpDir, "bazel", "WORKSPACE"),
+	}, p.buildFiles)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{filepath.Join(tmpDir, "k8s", "deployment.yaml")}, p.manifests)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{filepath.Join(tmpDir, "charts", "app")}, p.charts)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{filepath.Join(tmpDir, "overlay")}, p.kustomizations)
+}
+
+func TestGenerateDeployConfig(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	manifest := filepath.Join(tmpDir, "deployment.yaml")
+	chart := filepath.Join(tmpDir, "chart")
+	writeFiles(t, tmpDir, map[string]string{
+		"deployment.yaml":   deployment,
+		"chart/Chart.yaml":  "name: chart",
+		"chart/values.yaml": "image: gcr.io/project/web:v1\nworker:\n  image: gcr.io/project/worker\n  replicas: 2",
+	})
+
+	var tests = []struct {
+		description    string
+		project        *project
+		shouldErr      bool
+		expected       v1alpha3.DeployConfig
+		expectedImages []string
+	}{
+		{
+			description: "kubectl",
+			project:     &project{manifests: []string{manifest}},
+			expected: v1alpha3.DeployConfig{
+				DeployType: v1alpha3.DeployType{
+					KubectlDeploy: &v1alpha3.KubectlDeploy{
+						Manifests: []string{filepath.ToSlash(manifest)},
+					},
+				},
+			},
+			expectedImages: []string{"gcr.io/project/sidecar", "gcr.io/project/web"},
+		},
+		{
+			description: "helm",
+			project:     &project{charts: []string{chart}, manifests: []string{manifest}},
+			expected: v1alpha3.DeployConfig{
+				DeployType: v1alpha3.DeployType{
+					HelmDeploy: &v1alpha3.HelmDeploy{
+						Releases: []v1alpha3.HelmRelease{{
+							Name:      "chart",
+							ChartPath: filepath.ToSlash(chart),
+							Values: map[string]string{
+								"image":        "gcr.io/project/web",
+								"worker.image": "gcr.io/project/worker",
+							},
+						}},
+					},
+				},
+			},
+			expectedImages: []string{"gcr.io/project/web", "gcr.io/project/worker"},
+		},
+		{
+			description: "kustomize",
+			project:     &project{kustomizations: []string{"."}, charts: []string{chart}, manifests: []string{manifest}},
+			expected: v1alpha3.DeployConfig{
+				DeployType: v1alpha3.DeployType{
+					KustomizeDeploy: &v1alpha3.KustomizeDeploy{},
+				},
+			},
+			expectedImages: []string{"gcr.io/project/sidecar", "gcr.io/project/web"},
+		},
+		{
+			description: "nothing to deploy",
+			project:     &project{},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg, images, err := generateDeployConfig(test.project)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, cfg)
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expectedImages, images)
+		})
+	}
+}
+
+func TestParseArtifacts(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	writeFiles(t, tmpDir, map[string]string{
+		"Dockerfile":         "FROM scratch",
+		"web/Dockerfile.dev": "FROM scratch",
+		"bazel/WORKSPACE":    "",
+	})
+
+	var tests = []struct {
+		description string
+		pairs       []string
+		shouldErr   bool
+		expected    []*v1alpha3.Artifact
+	}{
+		{
+			description: "dockerfiles",
+			pairs: []string{
+				filepath.Join(tmpDir, "Dockerfile") + "=gcr.io/project/app",
+				filepath.Join(tmpDir, "web", "Dockerfile.dev") + "=gcr.io/project/web",
+			},
+			expected: []*v1alpha3.Artifact{
+				{
+					ImageName:    "gcr.io/project/app",
+					Workspace:    filepath.ToSlash(tmpDir),
+					ArtifactType: v1alpha3.ArtifactType{DockerArtifact: &v1alpha3.DockerArtifact{}},
+				},
+				{
+					ImageName:    "gcr.io/project/web",
+					Workspace:    filepath.ToSlash(filepath.Join(tmpDir, "web")),
+					ArtifactType: v1alpha3.ArtifactType{DockerArtifact: &v1alpha3.DockerArtifact{DockerfilePath: "Dockerfile.dev"}},
+				},
+			},
+		},
+		{
+			description: "bazel",
+			pairs:       []string{filepath.Join(tmpDir, "bazel", "WORKSPACE") + "://:app.tar=gcr.io/project/app"},
+			expected: []*v1alpha3.Artifact{
+				{
+					ImageName:    "gcr.io/project/app",
+					Workspace:    filepath.ToSlash(filepath.Join(tmpDir, "bazel")),
+					ArtifactType: v1alpha3.ArtifactType{BazelArtifact: &v1alpha3.BazelArtifact{BuildTarget: "//:app.tar"}},
+				},
+			},
+		},
+		{
+			description: "bazel without target",
+			pairs:       []string{filepath.Join(tmpDir, "bazel", "WORKSPACE") + "=gcr.io/project/app"},
+			shouldErr:   true,
+		},
+		{
+			description: "missing image",
+			pairs:       []string{filepath.Join(tmpDir, "Dockerfile")},
+			shouldErr:   true,
+		},
+		{
+			description: "unknown build file",
+			pairs:       []string{filepath.Join(tmpDir, "unknown", "Dockerfile") + "=gcr.io/project/app"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			artifacts, err := parseArtifacts(test.pairs)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, artifacts)
+		})
+	}
+}
+
+func TestDoInit(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifacts   []string
+		input       string
+		expected    []*v1alpha3.Artifact
+	}{
+		{
+			description: "non interactive",
+			artifacts:   []string{"web/Dockerfile=gcr.io/project/web"},
+			expected: []*v1alpha3.Artifact{{
+				ImageName:    "gcr.io/project/web",
+				Workspace:    "web",
+				ArtifactType: v1alpha3.ArtifactType{DockerArtifact: &v1alpha3.DockerArtifact{}},
+			}},
+		},
+		{
+			description: "interactive",
+			// sidecar isn't built, bad choice then web/Dockerfile for web, write the config
+			input: "3\n4\n1\ny\n",
+			expected: []*v1alpha3.Artifact{{
+				ImageName:    "gcr.io/project/web",
+				Workspace:    "web",
+				ArtifactType: v1alpha3.ArtifactType{DockerArtifact: &v1alpha3.DockerArtifact{}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			writeFiles(t, tmpDir, map[string]string{
+				"web/Dockerfile":      "FROM scratch",
+				"worker/Dockerfile":   "FROM scratch",
+				"k8s/deployment.yaml": deployment,
+			})
+
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(wd)
+
+			var out bytes.Buffer
+			err = DoInit(&out, strings.NewReader(test.input), Config{
+				ConfigFile: "skaffold.yaml",
+				Artifacts:  test.artifacts,
+			})
+			testutil.CheckError(t, false, err)
+
+			contents, err := ioutil.ReadFile("skaffold.yaml")
+			testutil.CheckError(t, false, err)
+
+			cfg, err := config.GetConfig(contents, false)
+			testutil.CheckError(t, false, err)
+
+			skaffoldConfig := cfg.(*config.SkaffoldConfig)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, skaffoldConfig.Build.Artifacts)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"k8s/deployment.yaml"}, skaffoldConfig.Deploy.KubectlDeploy.Manifests)
+
+			// Don't overwrite existing configuration
+			err = DoInit(&out, strings.NewReader(test.input), Config{
+				ConfigFile: "skaffold.yaml",
+				Artifacts:  test.artifacts,
+			})
+			testutil.CheckError(t, true, err)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// listing is the output of a build tool command that lists paths,
+// along with the modification times of the build files when it was run.
+type listing struct {
+	paths      []string
+	buildFiles map[string]time.Time
+}
+
+// Listing the paths starts a JVM, which is too slow to do each time
+// the files are watched. The listings are kept until a build file changes.
+var (
+	listingsMu sync.Mutex
+	listings   = map[string]listing{}
+)
+
+// getDependencies runs a build tool command that lists the files and
+// directories Jib uses to build an image, one path per line, unless
+// none of the build files changed since it last ran.
+// Directories are expanded and all paths are relative to the workspace.
+func getDependencies(workspace string, cmd *exec.Cmd, buildFiles []string) ([]string, error) {
+	absWorkspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting absolute path of workspace")
+	}
+
+	paths, err := listPaths(absWorkspace, cmd, buildFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []string
+	for _, path := range paths {
+		// Some of the listed directories, like resources, might not exist.
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(absWorkspace, file)
+			if err != nil {
+				return errors.Wrapf(err, "finding %s relative to workspace", file)
+			}
+			deps = append(deps, rel)
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "listing files in %s", path)
+		}
+	}
+
+	return util.UniqueStrSlice(deps), nil
+}
+
+// listPaths returns the absolute paths listed by a build tool command.
+func listPaths(absWorkspace string, cmd *exec.Cmd, buildFiles []string) ([]string, error) {
+	key := cmd.Dir + " " + strings.Join(cmd.Args, " ")
+	modTimes := modificationTimes(absWorkspace, buildFiles)
+
+	listingsMu.Lock()
+	defer listingsMu.Unlock()
+
+	if previous, present := listings[key]; present && sameModificationTimes(previous.buildFiles, modTimes) {
+		return previous.paths, nil
+	}
+
+	stdout, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		path := strings.TrimSpace(line)
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(absWorkspace, path)
+		}
+		paths = append(paths, path)
+	}
+
+	listings[key] = listing{paths: paths, buildFiles: modTimes}
+	return paths, nil
+}
+
+func sameModificationTimes(previous, current map[string]time.Time) bool {
+	if len(previous) != len(current) {
+		return false
+	}
+	for file, modTime := range current {
+		if previousModTime, present := previous[file]; !present || !previousModTime.Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// modificationTimes returns the modification times of the build files that exist.
+func modificationTimes(absWorkspace string, buildFiles []string) map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, file := range buildFiles {
+		info, err := os.Stat(filepath.Join(absWorkspace, file))
+		if err != nil {
+			continue
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes
+}
+
+// executable returns the build tool's wrapper script if the
+// workspace has one, or the build tool found on the path.
+func executable(workspace, wrapper, windowsWrapper, defaultExecutable string) string {
+	if runtime.GOOS == "windows" {
+		wrapper = windowsWrapper
+	}
+
+	path, err := filepath.Abs(filepath.Join(workspace, wrapper))
+	if err != nil {
+		return defaultExecutable
+	}
+	if _, err := os.Stat(path); err != nil {
+		return defaultExecutable
+	}
+
+	return path
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/pkg/errors"
+)
+
+// GetDependenciesGradle finds the source dependencies for the given jib-gradle artifact.
+// Gradle is only run again when a build or settings file changes. All paths are relative
+// to the workspace.
+func GetDependenciesGradle(workspace string, a *v1alpha3.JibGradleArtifact) ([]string, error) {
+	buildFiles := []string{"build.gradle", "settings.gradle", "build.gradle.kts", "settings.gradle.kts"}
+	if a.Project != "" {
+		dir := filepath.FromSlash(strings.Replace(a.Project, ":", "/", -1))
+		buildFiles = append(buildFiles, filepath.Join(dir, "build.gradle"), filepath.Join(dir, "build.gradle.kts"))
+	}
+
+	deps, err := getDependencies(workspace, GradleCommand(context.Background(), workspace, a, "_jibSkaffoldFiles", "-q"), buildFiles)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting jib-gradle dependencies")
+	}
+
+	return deps, nil
+}
+
+// GradleCommand creates a Gradle command that runs a task
+// on the artifact's project. The command is killed when the context is cancelled.
+func GradleCommand(ctx context.Context, workspace string, a *v1alpha3.JibGradleArtifact, task string, args ...string) *exec.Cmd {
+	if a.Project != "" {
+		task = fmt.Sprintf(":%s:%s", a.Project, task)
+	}
+
+	cmd := exec.CommandContext(ctx, executable(workspace, "gradlew", "gradlew.bat", "gradle"), append([]string{task}, args...)...)
+	cmd.Dir = workspace
+	return cmd
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/pkg/errors"
+)
+
+// GetDependenciesMaven finds the source dependencies for the given jib-maven artifact.
+// Maven is only run again when a pom.xml changes. All paths are relative to the workspace.
+func GetDependenciesMaven(workspace string, a *v1alpha3.JibMavenArtifact) ([]string, error) {
+	buildFiles := []string{"pom.xml"}
+	if a.Module != "" {
+		buildFiles = append(buildFiles, filepath.Join(a.Module, "pom.xml"))
+	}
+
+	deps, err := getDependencies(workspace, MavenCommand(context.Background(), workspace, a, "jib:_skaffold-files", "-q"), buildFiles)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting jib-maven dependencies")
+	}
+
+	return deps, nil
+}
+
+// MavenCommand creates a Maven command that runs the given goals on the
+// artifact's module, with the artifact's profile activated. The command is
+// killed when the context is cancelled.
+func MavenCommand(ctx context.Context, workspace string, a *v1alpha3.JibMavenArtifact, goals ...string) *exec.Cmd {
+	var args []string
+	if a.Profile != "" {
+		args = append(args, "--activate-profiles", a.Profile)
+	}
+	if a.Module != "" {
+		args = append(args, "--projects", a.Module, "--also-make")
+	}
+	args = append(args, goals...)
+
+	cmd := exec.CommandContext(ctx, executable(workspace, "mvnw", "mvnw.cmd", "mvn"), args...)
+	cmd.Dir = workspace
+	return cmd
+}
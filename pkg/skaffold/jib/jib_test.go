@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGetDependenciesMaven(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, file := range []string{"pom.xml", "src/main/java/App.java", "src/main/java/util/Util.java"} {
+		path := filepath.Join(tmpDir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(""), 0644)
+	}
+
+	var tests = []struct {
+		description string
+		artifact    *v1alpha3.JibMavenArtifact
+		command     string
+		stdout      string
+		err         error
+		shouldErr   bool
+		expected    []string
+	}{
+		{
+			description: "files and directories",
+			artifact:    &v1alpha3.JibMavenArtifact{},
+			command:     "mvn jib:_skaffold-files -q",
+			stdout: strings.Join([]string{
+				filepath.Join(tmpDir, "pom.xml"),
+				filepath.Join(tmpDir, "src", "main", "java"),
+				filepath.Join(tmpDir, "src", "main", "resources"),
+			}, "\n"),
+			expected: []string{
+				"pom.xml",
+				filepath.Join("src", "main", "java", "App.java"),
+				filepath.Join("src", "main", "java", "util", "Util.java"),
+			},
+		},
+		{
+			description: "module and profile",
+			artifact:    &v1alpha3.JibMavenArtifact{Module: "app", Profile: "dev"},
+			command:     "mvn --activate-profiles dev --projects app --also-make jib:_skaffold-files -q",
+			stdout:      filepath.Join(tmpDir, "pom.xml"),
+			expected:    []string{"pom.xml"},
+		},
+		{
+			description: "build failure",
+			artifact:    &v1alpha3.JibMavenArtifact{},
+			command:     "mvn jib:_skaffold-files -q",
+			stdout:      "",
+			err:         fmt.Errorf("BUILD FAILURE"),
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmdOut(test.command, test.stdout, test.err)
+			listings = map[string]listing{}
+
+			deps, err := GetDependenciesMaven(tmpDir, test.artifact)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, deps)
+		})
+	}
+}
+
+func TestGetDependenciesGradle(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, file := range []string{"build.gradle", "app/src/main/java/App.java"} {
+		path := filepath.Join(tmpDir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(""), 0644)
+	}
+
+	var tests = []struct {
+		description string
+		artifact    *v1alpha3.JibGradleArtifact
+		command     string
+		expected    []string
+	}{
+		{
+			description: "single project",
+			artifact:    &v1alpha3.JibGradleArtifact{},
+			command:     "gradle _jibSkaffoldFiles -q",
+			expected:    []string{filepath.Join("app", "src", "main", "java", "App.java"), "build.gradle"},
+		},
+		{
+			description: "sub-project",
+			artifact:    &v1alpha3.JibGradleArtifact{Project: "app"},
+			command:     "gradle :app:_jibSkaffoldFiles -q",
+			expected:    []string{filepath.Join("app", "src", "main", "java", "App.java"), "build.gradle"},
+		},
+	}
+
+	stdout := strings.Join([]string{
+		filepath.Join(tmpDir, "build.gradle"),
+		filepath.Join(tmpDir, "app", "src", "main"),
+	}, "\n")
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmdOut(test.command, stdout, nil)
+			listings = map[string]listing{}
+
+			deps, err := GetDependenciesGradle(tmpDir, test.artifact)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, deps)
+		})
+	}
+}
+
+func TestDependenciesAreListedAgainWhenBuildFilesChange(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, file := range []string{"build.gradle", "src/main/java/App.java"} {
+		path := filepath.Join(tmpDir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(""), 0644)
+	}
+	listings = map[string]listing{}
+
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("gradle _jibSkaffoldFiles -q", filepath.Join(tmpDir, "src"), nil)
+
+	deps, err := GetDependenciesGradle(tmpDir, &v1alpha3.JibGradleArtifact{})
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{filepath.Join("src", "main", "java", "App.java")}, deps)
+
+	// Gradle is not run again, but new source files are found
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("gradle _jibSkaffoldFiles -q", "", fmt.Errorf("shouldn't run gradle"))
+	ioutil.WriteFile(filepath.Join(tmpDir, "src", "main", "java", "Util.java"), []byte(""), 0644)
+
+	deps, err = GetDependenciesGradle(tmpDir, &v1alpha3.JibGradleArtifact{})
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{filepath.Join("src", "main", "java", "App.java"), filepath.Join("src", "main", "java", "Util.java")}, deps)
+
+	// Gradle is run again once a build file changes
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(tmpDir, "build.gradle"), later, later)
+
+	_, err = GetDependenciesGradle(tmpDir, &v1alpha3.JibGradleArtifact{})
+	testutil.CheckError(t, true, err)
+
+	ioutil.WriteFile(filepath.Join(tmpDir, "settings.gradle"), []byte(""), 0644)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("gradle _jibSkaffoldFiles -q", filepath.Join(tmpDir, "build.gradle"), nil)
+
+	deps, err = GetDependenciesGradle(tmpDir, &v1alpha3.JibGradleArtifact{})
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"build.gradle"}, deps)
+}
+
+func TestWrapper(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	cmd := MavenCommand(context.Background(), tmpDir, &v1alpha3.JibMavenArtifact{}, "package")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"mvn", "package"}, cmd.Args)
+
+	wrapper := filepath.Join(tmpDir, "gradlew")
+	ioutil.WriteFile(wrapper, []byte(""), 0755)
+	ioutil.WriteFile(wrapper+".bat", []byte(""), 0755)
+
+	cmd = GradleCommand(context.Background(), tmpDir, &v1alpha3.JibGradleArtifact{}, "jib")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{wrapper, "jib"}, cmd.Args)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, tmpDir, cmd.Dir)
+}
@@ -19,6 +19,7 @@ package kubernetes
 import (
 	"fmt"
 
+	kubectx "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/context"
 	"github.com/pkg/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -37,9 +38,18 @@ func GetClientset() (kubernetes.Interface, error) {
 	return kubernetes.NewForConfig(config)
 }
 
+// getClientConfig returns the client configuration for the kubectl
+// context that skaffold resolved when it started.
 func getClientConfig() (*restclient.Config, error) {
+	kubeContext, err := kubectx.CurrentContext()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current cluster context")
+	}
+
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
+		CurrentContext: kubeContext,
+	})
 	clientConfig, err := kubeConfig.ClientConfig()
 	if err != nil {
 		return nil, fmt.Errorf("Error creating kubeConfig: %s", err)
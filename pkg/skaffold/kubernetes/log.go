@@ -19,49 +19,139 @@ package kubernetes
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 // Client is for tests
 var Client = GetClientset
 var DynamicClient = GetDynamicClient
 
+// For testing
+var (
+	openLogs       = openContainerLogs
+	reconnectDelay = time.Second
+)
+
+// LogOptions configures how the logs are printed.
+type LogOptions struct {
+	// JSON prints each line as a json object, instead of prefixing it with its container.
+	JSON bool
+
+	// Dir is a directory where all the logs of each container are written to a separate file.
+	Dir string
+}
+
 // LogAggregator aggregates the logs for all the deployed pods.
 type LogAggregator struct {
 	output      io.Writer
 	podSelector PodSelector
+	namespace   string
 	colorPicker ColorPicker
+	options     LogOptions
 
 	muted             int32
 	startTime         time.Time
+	startedAt         time.Time
 	trackedContainers trackedContainers
+
+	filterLock sync.RWMutex
+	filter     LogFilter
+	compiled   *compiledFilter
 }
 
 // NewLogAggregator creates a new LogAggregator for a given output.
-func NewLogAggregator(out io.Writer, podSelector PodSelector, colorPicker ColorPicker) *LogAggregator {
+// It only looks for pods in the given namespace, or in all of them if it's empty.
+func NewLogAggregator(out io.Writer, podSelector PodSelector, namespace string, colorPicker ColorPicker, options LogOptions) *LogAggregator {
 	return &LogAggregator{
 		output:      out,
 		podSelector: podSelector,
+		namespace:   namespace,
 		colorPicker: colorPicker,
+		options:     options,
 		trackedContainers: trackedContainers{
-			ids: map[string]bool{},
+			ids:      map[string]bool{},
+			restarts: map[string]int32{},
 		},
+		compiled: &compiledFilter{},
 	}
 }
 
+// SetFilter changes the containers and the lines whose logs are printed.
+// It can be called while the logs are streamed.
+func (a *LogAggregator) SetFilter(filter LogFilter) error {
+	compiled, err := filter.compile()
+	if err != nil {
+		return err
+	}
+
+	a.filterLock.Lock()
+	a.filter = filter
+	a.compiled = compiled
+	a.filterLock.Unlock()
+
+	return nil
+}
+
+// Filter returns the current filter.
+func (a *LogAggregator) Filter() LogFilter {
+	a.filterLock.RLock()
+	defer a.filterLock.RUnlock()
+
+	return a.filter
+}
+
+func (a *LogAggregator) currentFilter() *compiledFilter {
+	a.filterLock.RLock()
+	defer a.filterLock.RUnlock()
+
+	return a.compiled
+}
+
+// logSource is a container whose logs are streamed.
+type logSource struct {
+	pod       *v1.Pod
+	container string
+	image     string
+	header    string
+	color     color.Color
+
+	// file receives all the logs of the container, if LogOptions.Dir is set.
+	file io.Writer
+}
+
+// Start streams the logs of the selected pods, from now on, until the context is cancelled.
+// Now is measured relatively to the cluster's clock, which can differ from the local one.
 func (a *LogAggregator) Start(ctx context.Context) error {
+	a.startedAt = time.Now()
+	return a.start(ctx)
+}
+
+// StartSince streams the logs of the selected pods, written after the given time, until the
+// context is cancelled. With a zero time, all the logs of the containers are streamed.
+func (a *LogAggregator) StartSince(ctx context.Context, since time.Time) error {
+	a.startTime = since
+	return a.start(ctx)
+}
+
+func (a *LogAggregator) start(ctx context.Context) error {
 	kubeclient, err := Client()
 	if err != nil {
 		return errors.Wrap(err, "getting k8s client")
@@ -71,11 +161,9 @@ func (a *LogAggregator) Start(ctx context.Context) error {
 	go func() {
 	retryLoop:
 		for {
-			a.startTime = time.Now()
-
-			watcher, err := client.Pods("").Watch(meta_v1.ListOptions{
-				IncludeUninitialized: true,
-			})
+			listOptions := a.podSelector.ListOptions()
+			listOptions.IncludeUninitialized = true
+			watcher, err := client.Pods(a.namespace).Watch(listOptions)
 
 			if err != nil {
 				logrus.Errorf("initializing pod watcher %s", err)
@@ -94,17 +182,20 @@ func (a *LogAggregator) Start(ctx context.Context) error {
 						continue retryLoop
 					}
 
-					if evt.Type != watch.Added && evt.Type != watch.Modified {
+					pod, ok := evt.Object.(*v1.Pod)
+					if !ok {
 						continue eventLoop
 					}
 
-					pod, ok := evt.Object.(*v1.Pod)
-					if !ok {
+					if evt.Type == watch.Deleted {
+						a.podSelector.Forget(pod)
+					}
+					if evt.Type != watch.Added && evt.Type != watch.Modified {
 						continue eventLoop
 					}
 
 					if a.podSelector.Select(pod) {
-						go a.streamLogs(ctx, pod)
+						go a.streamLogs(ctx, client.Pods(pod.Namespace), pod)
 					}
 				}
 			}
@@ -114,7 +205,7 @@ func (a *LogAggregator) Start(ctx context.Context) error {
 	return nil
 }
 
-func (a *LogAggregator) streamLogs(ctx context.Context, pod *v1.Pod) {
+func (a *LogAggregator) streamLogs(ctx context.Context, pods corev1.PodInterface, pod *v1.Pod) {
 	for _, container := range pod.Status.ContainerStatuses {
 		containerID := container.ContainerID
 		if containerID == "" || !container.Ready {
@@ -128,28 +219,202 @@ func (a *LogAggregator) streamLogs(ctx context.Context, pod *v1.Pod) {
 
 		logrus.Infof("Stream logs from pod: %s container: %s", pod.Name, container.Name)
 
-		tr, tw := io.Pipe()
-		go func() {
-			sinceSeconds := int64(time.Since(a.startTime).Seconds() + 0.5)
-			// 0s means all the logs
-			if sinceSeconds == 0 {
-				sinceSeconds = 1
-			}
+		source := &logSource{
+			pod:       pod,
+			container: container.Name,
+			image:     containerImage(pod, container.Name),
+			header:    prefix(pod, container),
+			color:     a.colorPicker.Pick(pod),
+		}
+		if a.trackedContainers.restarted(pod, container) {
+			a.printRestart(source, container)
+		}
+
+		go a.streamContainerLogs(ctx, pods, source, container.ContainerID)
+	}
+}
 
-			cmd := exec.CommandContext(ctx, "kubectl", "logs", fmt.Sprintf("--since=%ds", sinceSeconds), "-f", pod.Name, "-c", container.Name, "--namespace", pod.Namespace)
-			cmd.Stdout = tw
-			cmd.Run()
-		}()
+// streamContainerLogs streams the logs of a container until it stops running.
+// After a disconnection, it resumes from the last line that was printed.
+func (a *LogAggregator) streamContainerLogs(ctx context.Context, pods corev1.PodInterface, source *logSource, containerID string) {
+	defer a.trackedContainers.remove(containerID)
 
-		color := a.colorPicker.Pick(pod)
-		prefix := prefix(pod, container)
-		go func() {
-			if err := a.streamRequest(ctx, color, prefix, tr); err != nil {
+	if a.options.Dir != "" {
+		file, err := openLogFile(a.options.Dir, source)
+		if err != nil {
+			logrus.Warnln("Opening log file:", err)
+		} else {
+			defer file.Close()
+			source.file = file
+		}
+	}
+
+	position := logPosition{time: a.startTime}
+	for {
+		rc, err := openLogs(ctx, pods, source.pod.Name, a.logOptions(source.container, position, true))
+		if err != nil {
+			logrus.Debugf("opening log stream %s: %s", source.header, err)
+		} else {
+			position, err = a.streamRequest(ctx, source, position, rc)
+			rc.Close()
+			if err != nil {
 				logrus.Errorf("streaming request %s", err)
 			}
-			a.trackedContainers.remove(containerID)
-		}()
+		}
+
+		if ctx.Err() != nil || !isRunning(pods, source.pod.Name, containerID) {
+			logrus.Infof("%s exited", source.header)
+			return
+		}
+
+		logrus.Debugf("Reconnecting to log stream %s", source.header)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// Print prints the logs of the selected pods written after the given time, without
+// following them. With a zero time, all the logs of the containers are printed.
+func (a *LogAggregator) Print(ctx context.Context, since time.Time) error {
+	kubeclient, err := Client()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	client := kubeclient.CoreV1()
+
+	pods, err := client.Pods(a.namespace).List(a.podSelector.ListOptions())
+	if err != nil {
+		return errors.Wrap(err, "listing pods")
+	}
+
+	selected := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !a.podSelector.Select(pod) {
+			continue
+		}
+		selected++
+
+		for _, container := range pod.Status.ContainerStatuses {
+			// Containers that never started don't have logs.
+			if container.ContainerID == "" {
+				continue
+			}
+
+			source := &logSource{
+				pod:       pod,
+				container: container.Name,
+				image:     containerImage(pod, container.Name),
+				header:    prefix(pod, container),
+				color:     a.colorPicker.Pick(pod),
+			}
+			if err := a.printContainerLogs(ctx, client.Pods(pod.Namespace), source, since); err != nil {
+				return err
+			}
+		}
 	}
+
+	if selected == 0 {
+		logrus.Warnln("No deployed pods were found")
+	}
+
+	return nil
+}
+
+func (a *LogAggregator) printContainerLogs(ctx context.Context, pods corev1.PodInterface, source *logSource, since time.Time) error {
+	if a.options.Dir != "" {
+		file, err := openLogFile(a.options.Dir, source)
+		if err != nil {
+			return errors.Wrap(err, "opening log file")
+		}
+		defer file.Close()
+		source.file = file
+	}
+
+	from := logPosition{time: since}
+	rc, err := openLogs(ctx, pods, source.pod.Name, a.logOptions(source.container, from, false))
+	if err != nil {
+		return errors.Wrapf(err, "getting logs of %s", source.header)
+	}
+	defer rc.Close()
+
+	_, err = a.streamRequest(ctx, source, from, rc)
+	return err
+}
+
+// logPosition is where a log stream should resume from: the timestamp of
+// the last line that was read and how many lines were read with that timestamp.
+type logPosition struct {
+	time  time.Time
+	lines int
+}
+
+// logOptions returns the options to read the logs of a container from a given position.
+// With no position, after a call to Start, the logs are read from the time Start was called,
+// expressed as a duration so that it doesn't depend on the local clock.
+func (a *LogAggregator) logOptions(container string, from logPosition, follow bool) *v1.PodLogOptions {
+	options := &v1.PodLogOptions{
+		Container:  container,
+		Follow:     follow,
+		Timestamps: true,
+	}
+
+	switch {
+	case !from.time.IsZero():
+		sinceTime := meta_v1.NewTime(from.time)
+		options.SinceTime = &sinceTime
+	case !a.startedAt.IsZero():
+		sinceSeconds := int64(math.Ceil(time.Since(a.startedAt).Seconds()))
+		if sinceSeconds < 1 {
+			sinceSeconds = 1
+		}
+		options.SinceSeconds = &sinceSeconds
+	}
+
+	return options
+}
+
+func openContainerLogs(ctx context.Context, pods corev1.PodInterface, podName string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+	return pods.GetLogs(podName, options).Context(ctx).Stream()
+}
+
+// isRunning checks if a given container is still running.
+func isRunning(pods corev1.PodInterface, podName, containerID string) bool {
+	pod, err := pods.Get(podName, meta_v1.GetOptions{})
+	if err != nil {
+		logrus.Debugf("getting pod %s: %s", podName, err)
+		return false
+	}
+
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.ContainerID == containerID {
+			return container.State.Running != nil
+		}
+	}
+
+	return false
+}
+
+// openLogFile opens, in append mode, the file that receives all the logs of a container.
+func openLogFile(dir string, source *logSource) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s_%s_%s.log", source.pod.Namespace, source.pod.Name, source.container)
+	return os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+func containerImage(pod *v1.Pod, name string) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return container.Image
+		}
+	}
+	return ""
 }
 
 func prefix(pod *v1.Pod, container v1.ContainerStatus) string {
@@ -159,38 +424,128 @@ func prefix(pod *v1.Pod, container v1.ContainerStatus) string {
 	return fmt.Sprintf("[%s]", container.Name)
 }
 
-func (a *LogAggregator) streamRequest(ctx context.Context, headerColor color.Color, header string, rc io.Reader) error {
+func (a *LogAggregator) printRestart(source *logSource, container v1.ContainerStatus) {
+	reason := ""
+	if terminated := container.LastTerminationState.Terminated; terminated != nil {
+		reason = fmt.Sprintf(": %s, exit code %d", terminated.Reason, terminated.ExitCode)
+	}
+
+	if a.IsMuted() || !a.currentFilter().selects(source.pod, source.container, source.image) {
+		return
+	}
+	a.printLine(source, time.Now(), fmt.Sprintf("Container restarted (%d restarts)%s\n", container.RestartCount, reason))
+}
+
+// jsonLine is a line of logs printed as json.
+type jsonLine struct {
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Namespace string    `json:"namespace"`
+	Timestamp time.Time `json:"timestamp"`
+	Line      string    `json:"line"`
+}
+
+func (a *LogAggregator) printLine(source *logSource, timestamp time.Time, text string) error {
+	if a.options.JSON {
+		buf, err := json.Marshal(jsonLine{
+			Pod:       source.pod.Name,
+			Container: source.container,
+			Namespace: source.pod.Namespace,
+			Timestamp: timestamp,
+			Line:      strings.TrimSuffix(text, "\n"),
+		})
+		if err != nil {
+			return errors.Wrap(err, "marshalling log line")
+		}
+		if _, err := fmt.Fprintf(a.output, "%s\n", buf); err != nil {
+			return errors.Wrap(err, "writing pod log to out")
+		}
+		return nil
+	}
+
+	if _, err := source.color.Fprintf(a.output, "%s ", source.header); err != nil {
+		return errors.Wrap(err, "writing pod prefix header to out")
+	}
+	if _, err := fmt.Fprint(a.output, text); err != nil {
+		return errors.Wrap(err, "writing pod log to out")
+	}
+	return nil
+}
+
+// streamRequest prints the lines of a log stream that come after a given position.
+// The lines are expected to start with their timestamp. Since the stream can start before
+// the position, the lines up to the position are skipped. It returns the position of the
+// last line.
+func (a *LogAggregator) streamRequest(ctx context.Context, source *logSource, from logPosition, rc io.Reader) (logPosition, error) {
+	position := from
+	skipped := 0
+	resumed := false
+
 	r := bufio.NewReader(rc)
 	for {
 		select {
 		case <-ctx.Done():
-			logrus.Infof("%s interrupted", header)
-			return nil
+			logrus.Infof("%s interrupted", source.header)
+			return position, nil
 		default:
 		}
 
 		// Read up to newline
 		line, err := r.ReadBytes('\n')
 		if err == io.EOF {
-			break
+			return position, nil
 		}
 		if err != nil {
-			return errors.Wrap(err, "reading bytes from log stream")
+			return position, errors.Wrap(err, "reading bytes from log stream")
+		}
+
+		text := string(line)
+		timestamp := time.Now()
+		if parts := strings.SplitN(text, " ", 2); len(parts) == 2 {
+			if parsed, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+				if !resumed {
+					if parsed.Before(from.time) {
+						// Logged before the start.
+						continue
+					}
+					if parsed.Equal(from.time) && skipped < from.lines {
+						// Already printed before a reconnection.
+						skipped++
+						continue
+					}
+					resumed = true
+				}
+
+				if parsed.Equal(position.time) {
+					position.lines++
+				} else {
+					position = logPosition{time: parsed, lines: 1}
+				}
+				timestamp = parsed
+				text = parts[1]
+			}
+		}
+
+		event.Log(source.pod.Name, source.container, strings.TrimSuffix(text, "\n"))
+		if source.file != nil {
+			if _, err := io.WriteString(source.file, text); err != nil {
+				return position, errors.Wrap(err, "writing pod log to file")
+			}
 		}
 
 		if a.IsMuted() {
 			continue
 		}
 
-		if _, err := headerColor.Fprintf(a.output, "%s ", header); err != nil {
-			return errors.Wrap(err, "writing pod prefix header to out")
+		filter := a.currentFilter()
+		if !filter.selects(source.pod, source.container, source.image) || !filter.matches(text) {
+			continue
 		}
-		if _, err := fmt.Fprint(a.output, string(line)); err != nil {
-			return errors.Wrap(err, "writing pod log to out")
+
+		if err := a.printLine(source, timestamp, text); err != nil {
+			return position, err
 		}
 	}
-	logrus.Infof("%s exited", header)
-	return nil
 }
 
 // Mute mutes the logs.
@@ -211,6 +566,9 @@ func (a *LogAggregator) IsMuted() bool {
 type trackedContainers struct {
 	sync.Mutex
 	ids map[string]bool
+
+	// restarts is the restart count of each container, by pod and container name.
+	restarts map[string]int32
 }
 
 // add adds a containerID to be tracked. Return true if the container
@@ -230,48 +588,27 @@ func (t *trackedContainers) remove(id string) {
 	t.Unlock()
 }
 
-// PodSelector is used to choose which pods to log.
-type PodSelector interface {
-	Select(pod *v1.Pod) bool
-}
+// restarted records the restart count of a container. It returns true
+// if the container has restarted since it was last seen.
+func (t *trackedContainers) restarted(pod *v1.Pod, container v1.ContainerStatus) bool {
+	key := pod.Namespace + "/" + pod.Name + "/" + container.Name
 
-// ImageList implements PodSelector based on a list of images names.
-type ImageList struct {
-	sync.RWMutex
-	names map[string]bool
-}
-
-// NewImageList creates a new ImageList.
-func NewImageList() *ImageList {
-	return &ImageList{
-		names: make(map[string]bool),
-	}
-}
+	t.Lock()
+	defer t.Unlock()
 
-// Add adds an image to the list.
-func (l *ImageList) Add(image string) {
-	l.Lock()
-	l.names[image] = true
-	l.Unlock()
-}
+	previous, seen := t.restarts[key]
+	t.restarts[key] = container.RestartCount
 
-// Remove removes an image from the list.
-func (l *ImageList) Remove(image string) {
-	l.Lock()
-	delete(l.names, image)
-	l.Unlock()
+	return seen && container.RestartCount > previous
 }
 
-// Select returns true if one of the pod's images is in the list.
-func (l *ImageList) Select(pod *v1.Pod) bool {
-	l.RLock()
-	defer l.RUnlock()
+// PodSelector is used to choose which pods to log.
+type PodSelector interface {
+	Select(pod *v1.Pod) bool
 
-	for _, container := range pod.Spec.Containers {
-		if l.names[container.Image] {
-			return true
-		}
-	}
+	// Forget is called once a pod is deleted.
+	Forget(pod *v1.Pod)
 
-	return false
+	// ListOptions narrows down the pods that are listed or watched.
+	ListOptions() meta_v1.ListOptions
 }
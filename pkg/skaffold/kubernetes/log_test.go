@@ -0,0 +1,341 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func appSource() *logSource {
+	return &logSource{
+		pod: &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "gcr.io/project/app:abcd"}}},
+		},
+		container: "app",
+		image:     "gcr.io/project/app:abcd",
+		header:    "[app]",
+		color:     color.Default,
+	}
+}
+
+func TestStreamRequest(t *testing.T) {
+	start := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	logs := "2018-10-01T11:59:59.5Z before start\n" +
+		"2018-10-01T12:00:01.123456789Z Hello world!\n" +
+		"not timestamped\n" +
+		"2018-10-01T12:00:02Z Hello jerry!\n" +
+		"2018-10-01T12:00:02Z Hello tom!\n"
+
+	var out bytes.Buffer
+	a := NewLogAggregator(&out, nil, "", nil, LogOptions{})
+	last, err := a.streamRequest(context.Background(), appSource(), logPosition{time: start}, strings.NewReader(logs))
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "[app] Hello world!\n[app] not timestamped\n[app] Hello jerry!\n[app] Hello tom!\n", out.String())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, time.Date(2018, 10, 1, 12, 0, 2, 0, time.UTC), last.time)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, last.lines)
+}
+
+func TestStreamRequestMuted(t *testing.T) {
+	var out bytes.Buffer
+	a := NewLogAggregator(&out, nil, "", nil, LogOptions{})
+	a.Mute()
+
+	_, err := a.streamRequest(context.Background(), appSource(), logPosition{}, strings.NewReader("2018-10-01T12:00:01Z Hello world!\n"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "", out.String())
+}
+
+func TestStreamContainerLogsResumes(t *testing.T) {
+	start := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		description    string
+		startTime      time.Time
+		streams        []string
+		expected       string
+		expectedSinces []time.Time
+	}{
+		{
+			description: "resume after the last line",
+			startTime:   start,
+			streams: []string{
+				// Disconnected while the container is running
+				"2018-10-01T12:00:01Z line1\n2018-10-01T12:00:02.5Z line2\n",
+				// Resumed from the second of the last line
+				"2018-10-01T12:00:02.5Z line2\n2018-10-01T12:00:03Z line3\n",
+			},
+			expected:       "[app] line1\n[app] line2\n[app] line3\n",
+			expectedSinces: []time.Time{start, time.Date(2018, 10, 1, 12, 0, 2, 500000000, time.UTC)},
+		},
+		{
+			description: "lines with the same timestamp",
+			startTime:   start,
+			streams: []string{
+				"2018-10-01T12:00:01Z line1\n2018-10-01T12:00:01Z line2\n",
+				"2018-10-01T12:00:01Z line1\n2018-10-01T12:00:01Z line2\n2018-10-01T12:00:01Z line3\n",
+			},
+			expected:       "[app] line1\n[app] line2\n[app] line3\n",
+			expectedSinces: []time.Time{start, time.Date(2018, 10, 1, 12, 0, 1, 0, time.UTC)},
+		},
+		{
+			description: "from now on",
+			streams: []string{
+				"",
+				"2018-10-01T12:00:01Z line1\n",
+			},
+			expected: "[app] line1\n",
+			// Without any line, the stream resumes from the start too
+			expectedSinces: []time.Time{{}, {}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "default"},
+				Status: v1.PodStatus{
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name:        "app",
+						ContainerID: "docker://1234",
+						Ready:       true,
+						State:       v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					}},
+				},
+			}
+			pods := fake.NewSimpleClientset(pod).CoreV1().Pods("default")
+
+			var sinces []time.Time
+			var sinceSeconds []*int64
+
+			defer func(o func(context.Context, corev1.PodInterface, string, *v1.PodLogOptions) (io.ReadCloser, error), d time.Duration) {
+				openLogs, reconnectDelay = o, d
+			}(openLogs, reconnectDelay)
+			reconnectDelay = 0
+			openLogs = func(ctx context.Context, pods corev1.PodInterface, podName string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+				var since time.Time
+				if options.SinceTime != nil {
+					since = options.SinceTime.Time.UTC()
+				}
+				sinces = append(sinces, since)
+				sinceSeconds = append(sinceSeconds, options.SinceSeconds)
+
+				stream := test.streams[len(sinces)-1]
+				if len(sinces) == len(test.streams) {
+					// The container stops after the last stream
+					stopped := pod.DeepCopy()
+					stopped.Status.ContainerStatuses[0].State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}
+					if _, err := pods.Update(stopped); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				return ioutil.NopCloser(strings.NewReader(stream)), nil
+			}
+
+			var out bytes.Buffer
+			a := NewLogAggregator(&out, nil, "", nil, LogOptions{})
+			a.startTime = test.startTime
+			if test.startTime.IsZero() {
+				a.startedAt = time.Now()
+			}
+			a.trackedContainers.add("docker://1234")
+			a.streamContainerLogs(context.Background(), pods, &logSource{pod: pod, container: "app", header: "[app]", color: color.Default}, "docker://1234")
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, out.String())
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedSinces, sinces)
+			for _, seconds := range sinceSeconds {
+				if test.startTime.IsZero() != (seconds != nil && *seconds >= 1) {
+					t.Errorf("unexpected sinceSeconds: %v", seconds)
+				}
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, nil, false, a.trackedContainers.add("docker://1234"))
+		})
+	}
+}
+
+func TestContainerRestarts(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "default"}}
+	container := v1.ContainerStatus{
+		Name: "app",
+		LastTerminationState: v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 2},
+		},
+	}
+
+	var out bytes.Buffer
+	a := NewLogAggregator(&out, nil, "", nil, LogOptions{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, a.trackedContainers.restarted(pod, container))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, a.trackedContainers.restarted(pod, container))
+
+	container.RestartCount = 1
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, a.trackedContainers.restarted(pod, container))
+
+	a.printRestart(&logSource{pod: pod, container: "app", header: "[app]", color: color.Default}, container)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "[app] Container restarted (1 restarts): Error, exit code 2\n", out.String())
+}
+
+func TestStreamRequestFiltered(t *testing.T) {
+	logs := "2018-10-01T12:00:01Z GET /\n2018-10-01T12:00:02Z POST /login\n2018-10-01T12:00:03Z GET /health\n"
+
+	var tests = []struct {
+		description string
+		filter      LogFilter
+		expected    string
+	}{
+		{
+			description: "no filter",
+			expected:    "[app] GET /\n[app] POST /login\n[app] GET /health\n",
+		},
+		{
+			description: "match lines",
+			filter:      LogFilter{Match: "^GET"},
+			expected:    "[app] GET /\n[app] GET /health\n",
+		},
+		{
+			description: "include image",
+			filter:      LogFilter{Include: []string{"image:gcr.io/project/app"}},
+			expected:    "[app] GET /\n[app] POST /login\n[app] GET /health\n",
+		},
+		{
+			description: "include other container",
+			filter:      LogFilter{Include: []string{"container:sidecar"}},
+			expected:    "",
+		},
+		{
+			description: "exclude label",
+			filter:      LogFilter{Exclude: []string{"label:app=web"}},
+			expected:    "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var out bytes.Buffer
+			a := NewLogAggregator(&out, nil, "", nil, LogOptions{})
+			err := a.SetFilter(test.filter)
+			testutil.CheckError(t, false, err)
+
+			_, err = a.streamRequest(context.Background(), appSource(), logPosition{}, strings.NewReader(logs))
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, out.String())
+		})
+	}
+}
+
+func TestSetInvalidFilter(t *testing.T) {
+	a := NewLogAggregator(ioutil.Discard, nil, "", nil, LogOptions{})
+	a.SetFilter(LogFilter{Match: "GET"})
+
+	err := a.SetFilter(LogFilter{Match: "("})
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, LogFilter{Match: "GET"}, a.Filter())
+}
+
+func TestStreamRequestJSON(t *testing.T) {
+	var out bytes.Buffer
+	a := NewLogAggregator(&out, nil, "", nil, LogOptions{JSON: true})
+
+	_, err := a.streamRequest(context.Background(), appSource(), logPosition{}, strings.NewReader("2018-10-01T12:00:01.5Z Hello \"world\"!\n"))
+
+	expected := `{"pod":"app","container":"app","namespace":"default","timestamp":"2018-10-01T12:00:01.5Z","line":"Hello \"world\"!"}` + "\n"
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, out.String())
+}
+
+func TestStreamContainerLogsToFile(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	source := appSource()
+	pods := fake.NewSimpleClientset().CoreV1().Pods("default")
+
+	defer func(o func(context.Context, corev1.PodInterface, string, *v1.PodLogOptions) (io.ReadCloser, error)) {
+		openLogs = o
+	}(openLogs)
+	openLogs = func(context.Context, corev1.PodInterface, string, *v1.PodLogOptions) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("2018-10-01T12:00:01Z GET /\n2018-10-01T12:00:02Z POST /login\n")), nil
+	}
+
+	var out bytes.Buffer
+	a := NewLogAggregator(&out, nil, "", nil, LogOptions{Dir: tmpDir})
+	a.SetFilter(LogFilter{Match: "POST"})
+	a.streamContainerLogs(context.Background(), pods, source, "docker://1234")
+
+	content, err := ioutil.ReadFile(filepath.Join(tmpDir, "default_app_app.log"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "GET /\nPOST /login\n", string(content))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "[app] POST /login\n", out.String())
+}
+
+func TestPrint(t *testing.T) {
+	running := v1.ContainerStatus{Name: "app", ContainerID: "docker://1234", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}
+	crashed := v1.ContainerStatus{Name: "app", ContainerID: "docker://5678", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}}
+	pending := v1.ContainerStatus{Name: "sidecar"}
+	pods := []runtime.Object{
+		&v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "gcr.io/project/web:v1"}, {Name: "sidecar", Image: "sidecar"}}},
+			Status:     v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{crashed, pending}},
+		},
+		&v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "other", Namespace: "default", UID: "other"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "gcr.io/project/other:v1"}}},
+			Status:     v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{running}},
+		},
+	}
+
+	defer func(c func() (kubernetes.Interface, error)) { Client = c }(Client)
+	Client = func() (kubernetes.Interface, error) { return fake.NewSimpleClientset(pods...), nil }
+
+	var opened []string
+	defer func(o func(context.Context, corev1.PodInterface, string, *v1.PodLogOptions) (io.ReadCloser, error)) {
+		openLogs = o
+	}(openLogs)
+	openLogs = func(ctx context.Context, pods corev1.PodInterface, podName string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+		if options.Follow {
+			t.Error("logs shouldn't be followed")
+		}
+		opened = append(opened, podName+"/"+options.Container)
+		return ioutil.NopCloser(strings.NewReader("2018-10-01T12:00:01Z panic: oops\n")), nil
+	}
+
+	selector := NewDeployedPods(fake.NewSimpleClientset(), []string{"gcr.io/project/web"}, nil, false)
+
+	var out bytes.Buffer
+	a := NewLogAggregator(&out, selector, "default", NewColorPicker(nil), LogOptions{})
+	err := a.Print(context.Background(), time.Time{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "[web app] panic: oops\n", out.String())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"web/app"}, opened)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+)
+
+// LogFilter selects the containers and the lines whose logs are printed.
+//
+// A selector is either `image:<name>`, `label:<key>=<value>`, `label:<key>` or `container:<name>`.
+// A container is selected if it matches any of the Include selectors, or if there are none,
+// and none of the Exclude selectors. Images are matched with or without their tag.
+type LogFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Match is a regular expression that the printed lines must match.
+	Match string `json:"match,omitempty"`
+}
+
+// compiledFilter is a LogFilter ready to be applied.
+type compiledFilter struct {
+	include []selector
+	exclude []selector
+	match   *regexp.Regexp
+}
+
+// selector tells if a container of a pod is selected.
+type selector func(pod *v1.Pod, container, image string) bool
+
+func (f LogFilter) compile() (*compiledFilter, error) {
+	var c compiledFilter
+
+	for _, s := range f.Include {
+		sel, err := parseSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		c.include = append(c.include, sel)
+	}
+
+	for _, s := range f.Exclude {
+		sel, err := parseSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		c.exclude = append(c.exclude, sel)
+	}
+
+	if f.Match != "" {
+		match, err := regexp.Compile(f.Match)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid log filter %s", f.Match)
+		}
+		c.match = match
+	}
+
+	return &c, nil
+}
+
+func parseSelector(s string) (selector, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid log selector %s, expected image:<name>, label:<key>=<value> or container:<name>", s)
+	}
+	value := parts[1]
+
+	switch parts[0] {
+	case "image":
+		return func(_ *v1.Pod, _, image string) bool {
+			return image == value || baseName(image) == value
+		}, nil
+
+	case "label":
+		kv := strings.SplitN(value, "=", 2)
+		return func(pod *v1.Pod, _, _ string) bool {
+			labelValue, present := pod.Labels[kv[0]]
+			return present && (len(kv) == 1 || labelValue == kv[1])
+		}, nil
+
+	case "container":
+		return func(_ *v1.Pod, container, _ string) bool {
+			return container == value
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid log selector %s, expected image:<name>, label:<key>=<value> or container:<name>", s)
+	}
+}
+
+// selects tells if the logs of a container are printed.
+func (c *compiledFilter) selects(pod *v1.Pod, container, image string) bool {
+	for _, sel := range c.exclude {
+		if sel(pod, container, image) {
+			return false
+		}
+	}
+
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, sel := range c.include {
+		if sel(pod, container, image) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches tells if a line is printed.
+func (c *compiledFilter) matches(line string) bool {
+	return c.match == nil || c.match.MatchString(line)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogFilterSelects(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web-1234", Labels: map[string]string{"app": "web", "tier": "front"}}}
+
+	var tests = []struct {
+		description string
+		filter      LogFilter
+		container   string
+		image       string
+		expected    bool
+	}{
+		{
+			description: "no selector",
+			container:   "web",
+			image:       "gcr.io/project/web:abcd",
+			expected:    true,
+		},
+		{
+			description: "image with tag",
+			filter:      LogFilter{Include: []string{"image:gcr.io/project/web:abcd"}},
+			image:       "gcr.io/project/web:abcd",
+			expected:    true,
+		},
+		{
+			description: "image without tag",
+			filter:      LogFilter{Include: []string{"image:gcr.io/project/web"}},
+			image:       "gcr.io/project/web:abcd",
+			expected:    true,
+		},
+		{
+			description: "other image",
+			filter:      LogFilter{Include: []string{"image:gcr.io/project/worker"}},
+			image:       "gcr.io/project/web:abcd",
+			expected:    false,
+		},
+		{
+			description: "label value",
+			filter:      LogFilter{Include: []string{"label:app=web"}},
+			expected:    true,
+		},
+		{
+			description: "other label value",
+			filter:      LogFilter{Include: []string{"label:app=worker"}},
+			expected:    false,
+		},
+		{
+			description: "label key",
+			filter:      LogFilter{Include: []string{"label:tier"}},
+			expected:    true,
+		},
+		{
+			description: "any include",
+			filter:      LogFilter{Include: []string{"container:sidecar", "container:web"}},
+			container:   "web",
+			expected:    true,
+		},
+		{
+			description: "exclude wins",
+			filter:      LogFilter{Include: []string{"label:app=web"}, Exclude: []string{"container:istio-proxy"}},
+			container:   "istio-proxy",
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			compiled, err := test.filter.compile()
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, compiled.selects(pod, test.container, test.image))
+		})
+	}
+}
+
+func TestLogFilterMatches(t *testing.T) {
+	compiled, err := LogFilter{Match: "(?i)error|warn"}.compile()
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, true, compiled.matches("ERROR: connection refused\n"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, compiled.matches("GET /health\n"))
+}
+
+func TestLogFilterInvalid(t *testing.T) {
+	var tests = []struct {
+		description string
+		filter      LogFilter
+	}{
+		{
+			description: "unknown selector",
+			filter:      LogFilter{Include: []string{"pod:web"}},
+		},
+		{
+			description: "missing value",
+			filter:      LogFilter{Exclude: []string{"image:"}},
+		},
+		{
+			description: "invalid regexp",
+			filter:      LogFilter{Match: "("},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := test.filter.compile()
+
+			testutil.CheckError(t, true, err)
+		})
+	}
+}
@@ -0,0 +1,433 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// retryDelay is the time to wait before re-establishing a broken port forward.
+const retryDelay = 1 * time.Second
+
+// Forwarder forwards the ports of deployed pods and services to the host.
+// Each forwarded port keeps the same local port for the whole session, even
+// when the pods are recreated by a redeploy. Only one replica of a workload
+// is forwarded at a time.
+type Forwarder struct {
+	output      io.Writer
+	podSelector kubernetes.PodSelector
+	kubeContext string
+	resources   []*v1alpha3.PortForwardResource
+	forwardCmd  func(ctx context.Context, kubeContext string, fwd *forward) error
+
+	sync.Mutex
+	ctx        context.Context
+	stopped    bool
+	forwards   map[string]*forward
+	replicas   map[string]map[string]bool
+	localPorts map[string]int
+	takenPorts map[int]bool
+	keepAlives sync.WaitGroup
+}
+
+// forward is a port forward that is kept alive until cancelled.
+type forward struct {
+	resourceType string
+	resourceName string
+	namespace    string
+	port         int
+	localPort    int
+
+	cancel context.CancelFunc
+}
+
+func (f *forward) resource() string {
+	return fmt.Sprintf("%s/%s", f.resourceType, f.resourceName)
+}
+
+// NewForwarder creates a new Forwarder for pods matching the selector
+// and for a list of user defined resources.
+func NewForwarder(out io.Writer, podSelector kubernetes.PodSelector, kubeContext string, resources []*v1alpha3.PortForwardResource) *Forwarder {
+	return &Forwarder{
+		output:      out,
+		podSelector: podSelector,
+		kubeContext: kubeContext,
+		resources:   resources,
+		forwardCmd:  runForwardCmd,
+		forwards:    map[string]*forward{},
+		replicas:    map[string]map[string]bool{},
+		localPorts:  map[string]int{},
+		takenPorts:  map[int]bool{},
+	}
+}
+
+// Start forwards the user defined resources and starts watching for pods
+// whose containers declare ports.
+func (f *Forwarder) Start(ctx context.Context) error {
+	f.Lock()
+	f.ctx = ctx
+	f.Unlock()
+
+	for _, r := range f.resources {
+		key := fmt.Sprintf("%s/%s/%s/%d", r.Namespace, r.Type, r.Name, r.Port)
+		f.forward(key, &forward{
+			resourceType: r.Type,
+			resourceName: r.Name,
+			namespace:    r.Namespace,
+			port:         r.Port,
+			localPort:    r.LocalPort,
+		})
+	}
+
+	kubeclient, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	client := kubeclient.CoreV1()
+
+	go func() {
+		for {
+			watcher, err := client.Pods("").Watch(f.podSelector.ListOptions())
+			if err != nil {
+				logrus.Errorf("initializing pod watcher for port forwarding %s", err)
+				return
+			}
+
+			if done := f.watchPods(ctx, watcher); done {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchPods handles pod events until the context is cancelled, in which case
+// it returns true, or until the server closes the connection.
+func (f *Forwarder) watchPods(ctx context.Context, watcher watch.Interface) bool {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case evt, ok := <-watcher.ResultChan():
+			if !ok {
+				// expected: server connection timeout
+				return false
+			}
+
+			pod, ok := evt.Object.(*v1.Pod)
+			if !ok || !f.podSelector.Select(pod) {
+				continue
+			}
+
+			switch evt.Type {
+			case watch.Added, watch.Modified:
+				if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+					f.forwardPod(pod)
+				} else {
+					f.stopPod(pod)
+				}
+			case watch.Deleted:
+				f.stopPod(pod)
+				f.podSelector.Forget(pod)
+			}
+		}
+	}
+}
+
+// ForwardServices forwards the ports of the services that were just deployed.
+func (f *Forwarder) ForwardServices(deployed []deploy.Artifact) {
+	for _, d := range deployed {
+		if d.Obj == nil {
+			continue
+		}
+
+		svc, ok := (*d.Obj).(*v1.Service)
+		if !ok {
+			continue
+		}
+
+		namespace := svc.Namespace
+		if namespace == "" {
+			namespace = d.Namespace
+		}
+
+		for _, p := range svc.Spec.Ports {
+			key := fmt.Sprintf("%s/service/%s/%d", namespace, svc.Name, p.Port)
+			f.forward(key, &forward{
+				resourceType: "service",
+				resourceName: svc.Name,
+				namespace:    namespace,
+				port:         int(p.Port),
+			})
+		}
+	}
+}
+
+// Stop stops all the port forwards and waits for them to exit.
+func (f *Forwarder) Stop() {
+	f.Lock()
+	f.stopped = true
+	for key, fwd := range f.forwards {
+		fwd.cancel()
+		delete(f.forwards, key)
+	}
+	f.Unlock()
+
+	f.keepAlives.Wait()
+}
+
+// forwardPod forwards the ports of a running pod, unless another
+// replica of the same workload is already forwarded.
+func (f *Forwarder) forwardPod(pod *v1.Pod) {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			key := podKey(pod, c, p)
+
+			f.Lock()
+			if f.replicas[key] == nil {
+				f.replicas[key] = map[string]bool{}
+			}
+			f.replicas[key][pod.Name] = true
+			_, present := f.forwards[key]
+			f.Unlock()
+
+			if present {
+				continue
+			}
+
+			f.forward(key, &forward{
+				resourceType: "pod",
+				resourceName: pod.Name,
+				namespace:    pod.Namespace,
+				port:         int(p.ContainerPort),
+			})
+		}
+	}
+}
+
+// stopPod stops forwarding the ports of a pod that's gone. Another
+// running replica is forwarded instead, on the same local ports.
+func (f *Forwarder) stopPod(pod *v1.Pod) {
+	replacements := map[string]*forward{}
+
+	f.Lock()
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			key := podKey(pod, c, p)
+			delete(f.replicas[key], pod.Name)
+
+			fwd, present := f.forwards[key]
+			if !present || fwd.resourceName != pod.Name {
+				continue
+			}
+			fwd.cancel()
+			delete(f.forwards, key)
+
+			if replica := firstReplica(f.replicas[key]); replica != "" {
+				replacements[key] = &forward{
+					resourceType: "pod",
+					resourceName: replica,
+					namespace:    pod.Namespace,
+					port:         int(p.ContainerPort),
+				}
+			}
+		}
+	}
+	f.Unlock()
+
+	for key, fwd := range replacements {
+		f.forward(key, fwd)
+	}
+}
+
+func firstReplica(replicas map[string]bool) string {
+	var names []string
+	for name := range replicas {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	sort.Strings(names)
+	return names[0]
+}
+
+// podKey identifies a container port of the replicas of a workload, so that only one
+// replica is forwarded and a recreated pod is forwarded on the same local port. Pods
+// that have no controller are identified by their name.
+func podKey(pod *v1.Pod, c v1.Container, p v1.ContainerPort) string {
+	return fmt.Sprintf("%s/pod/%s/%s/%d", pod.Namespace, podOwner(pod), c.Name, p.ContainerPort)
+}
+
+// podOwner returns the controller of a pod. The ReplicaSets of a Deployment are named
+// after it, followed by the hash of their pod template, which changes at each rollout.
+func podOwner(pod *v1.Pod) string {
+	owner := meta_v1.GetControllerOf(pod)
+	if owner == nil {
+		return pod.Name
+	}
+
+	name := owner.Name
+	if hash := pod.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && hash != "" {
+		name = strings.TrimSuffix(name, "-"+hash)
+	}
+	return fmt.Sprintf("%s/%s", strings.ToLower(owner.Kind), name)
+}
+
+// forward starts or replaces the port forward registered under a given key.
+func (f *Forwarder) forward(key string, fwd *forward) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.ctx == nil || f.stopped {
+		return
+	}
+
+	if previous, present := f.forwards[key]; present {
+		if previous.resource() == fwd.resource() {
+			return
+		}
+		previous.cancel()
+	}
+
+	localPort, err := f.localPort(key, fwd.localPort, fwd.port)
+	if err != nil {
+		logrus.Warnf("unable to forward %s %d: %s", fwd.resource(), fwd.port, err)
+		return
+	}
+	fwd.localPort = localPort
+
+	ctx, cancel := context.WithCancel(f.ctx)
+	fwd.cancel = cancel
+	f.forwards[key] = fwd
+
+	color.Default.Fprintf(f.output, "Port Forwarding %s %d -> 127.0.0.1:%d\n", fwd.resource(), fwd.port, fwd.localPort)
+
+	f.keepAlives.Add(1)
+	go func() {
+		defer f.keepAlives.Done()
+		f.keepAlive(ctx, fwd)
+	}()
+}
+
+// localPort returns the local port allocated to a key. A new port is chosen
+// the first time a key is seen: the requested port, the remote port if it's
+// available or the next available port after it.
+func (f *Forwarder) localPort(key string, requested, remote int) (int, error) {
+	if port, present := f.localPorts[key]; present {
+		return port, nil
+	}
+
+	var port int
+	if requested != 0 {
+		if f.takenPorts[requested] || !isPortAvailable(requested) {
+			return 0, fmt.Errorf("local port %d is not available", requested)
+		}
+		port = requested
+	} else {
+		port = f.availablePort(remote)
+		if port == 0 {
+			return 0, errors.New("no local port available")
+		}
+	}
+
+	f.localPorts[key] = port
+	f.takenPorts[port] = true
+	return port, nil
+}
+
+func (f *Forwarder) availablePort(from int) int {
+	for port := from; port < 65536; port++ {
+		if !f.takenPorts[port] && isPortAvailable(port) {
+			return port
+		}
+	}
+
+	return 0
+}
+
+// For testing
+var isPortAvailable = func(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// keepAlive runs `kubectl port-forward` until the context is cancelled,
+// restarting it whenever the connection is lost.
+func (f *Forwarder) keepAlive(ctx context.Context, fwd *forward) {
+	for {
+		err := f.forwardCmd(ctx, f.kubeContext, fwd)
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		logrus.Debugf("port forwarding %s %d stopped: %v. Retrying...", fwd.resource(), fwd.port, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func runForwardCmd(ctx context.Context, kubeContext string, fwd *forward) error {
+	args := []string{"--context", kubeContext, "port-forward", fwd.resource(), fmt.Sprintf("%d:%d", fwd.localPort, fwd.port)}
+	if fwd.namespace != "" {
+		args = append(args, "--namespace", fwd.namespace)
+	}
+
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "port forwarding %s: %s", fwd.resource(), buf.String())
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type allPods struct{}
+
+func (allPods) Select(pod *v1.Pod) bool { return true }
+func (allPods) Forget(pod *v1.Pod)      {}
+
+func (allPods) ListOptions() meta_v1.ListOptions { return meta_v1.ListOptions{} }
+
+func fakeForwarder(t *testing.T, takenPorts ...int) (*Forwarder, func()) {
+	prevAvailable := isPortAvailable
+
+	isPortAvailable = func(port int) bool {
+		for _, taken := range takenPorts {
+			if port == taken {
+				return false
+			}
+		}
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := NewForwarder(ioutil.Discard, allPods{}, "kubecontext", nil)
+	f.forwardCmd = func(ctx context.Context, kubeContext string, fwd *forward) error {
+		<-ctx.Done()
+		return nil
+	}
+	f.ctx = ctx
+
+	return f, func() {
+		f.Stop()
+		cancel()
+		isPortAvailable = prevAvailable
+	}
+}
+
+// pod returns a pod of the app Deployment, controlled by one of its ReplicaSets.
+func pod(name string, ports ...int32) *v1.Pod {
+	return replica("5d8f", name, ports...)
+}
+
+func replica(hash, name string, ports ...int32) *v1.Pod {
+	var containerPorts []v1.ContainerPort
+	for _, port := range ports {
+		containerPorts = append(containerPorts, v1.ContainerPort{ContainerPort: port})
+	}
+	controller := true
+
+	return &v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"pod-template-hash": hash},
+			OwnerReferences: []meta_v1.OwnerReference{{
+				Kind:       "ReplicaSet",
+				Name:       "app-" + hash,
+				Controller: &controller,
+			}},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:  "app",
+				Image: "gcr.io/test/app:v1",
+				Ports: containerPorts,
+			}},
+		},
+	}
+}
+
+func forwardedPorts(f *Forwarder) map[string]int {
+	ports := map[string]int{}
+	for _, fwd := range f.forwards {
+		ports[fmt.Sprintf("%s %d", fwd.resource(), fwd.port)] = fwd.localPort
+	}
+	return ports
+}
+
+func TestForwardPods(t *testing.T) {
+	f, cleanup := fakeForwarder(t, 8080)
+	defer cleanup()
+
+	f.forwardPod(pod("app-1", 8080, 9000))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"pod/app-1 8080": 8081, "pod/app-1 9000": 9000}, forwardedPorts(f))
+
+	// Recreated pod should be forwarded on the same local ports
+	f.stopPod(pod("app-1", 8080, 9000))
+	f.forwardPod(pod("app-2", 8080, 9000))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"pod/app-2 8080": 8081, "pod/app-2 9000": 9000}, forwardedPorts(f))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[int]bool{8081: true, 9000: true}, f.takenPorts)
+}
+
+func TestForwardOneReplica(t *testing.T) {
+	f, cleanup := fakeForwarder(t)
+	defer cleanup()
+
+	f.forwardPod(pod("app-1", 8080))
+	f.forwardPod(pod("app-2", 8080))
+	f.forwardPod(pod("app-1", 8080))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"pod/app-1 8080": 8080}, forwardedPorts(f))
+
+	// A replica of the next rollout replaces the forwarded one when it's gone
+	f.forwardPod(replica("7c9b", "app-3", 8080))
+	f.stopPod(pod("app-2", 8080))
+	f.stopPod(pod("app-1", 8080))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"pod/app-3 8080": 8080}, forwardedPorts(f))
+
+	// Pods without a controller are forwarded separately
+	f.forwardPod(&v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "standalone", Namespace: "default"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Ports: []v1.ContainerPort{{ContainerPort: 8080}}}}},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"pod/app-3 8080": 8080, "pod/standalone 8080": 8081}, forwardedPorts(f))
+}
+
+func TestStopWaitsForForwards(t *testing.T) {
+	f, cleanup := fakeForwarder(t)
+	defer cleanup()
+
+	stopped := make(chan struct{})
+	f.forwardCmd = func(ctx context.Context, kubeContext string, fwd *forward) error {
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	}
+
+	f.forwardPod(pod("app-1", 8080))
+	f.Stop()
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("port forward still running after Stop")
+	}
+
+	// No forward is started once stopped
+	f.forwardPod(pod("app-2", 9000))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{}, forwardedPorts(f))
+}
+
+func TestForwardServices(t *testing.T) {
+	f, cleanup := fakeForwarder(t)
+	defer cleanup()
+
+	var svc runtime.Object = &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "web",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 80}},
+		},
+	}
+	var notAService runtime.Object = pod("app", 8080)
+
+	f.ForwardServices([]deploy.Artifact{
+		{Obj: &svc, Namespace: "ns"},
+		{Obj: &notAService},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"service/web 80": 80}, forwardedPorts(f))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "ns", f.forwards["ns/service/web/80"].namespace)
+}
+
+func TestForwardUserDefinedLocalPort(t *testing.T) {
+	f, cleanup := fakeForwarder(t, 9000)
+	defer cleanup()
+
+	f.resources = []*v1alpha3.PortForwardResource{
+		{Type: "deployment", Name: "web", Port: 8080, LocalPort: 9090},
+		{Type: "deployment", Name: "api", Port: 8080, LocalPort: 9000},
+	}
+	for _, r := range f.resources {
+		f.forward(r.Name, &forward{resourceType: r.Type, resourceName: r.Name, port: r.Port, localPort: r.LocalPort})
+	}
+
+	// Port 9000 is not available
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]int{"deployment/web 8080": 9090}, forwardedPorts(f))
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// For testing
+var recheckDelay = 5 * time.Second
+
+// DeployedPods implements PodSelector for the pods that run one of the given images,
+// whatever their tag, and that are labeled, directly or through the objects that
+// control them, with the given labels. Without images, only the labels are checked.
+// When the pod templates are labeled, only the labels of the pods are checked and the
+// pods are listed with a label selector.
+type DeployedPods struct {
+	client      kubernetes.Interface
+	images      map[string]bool
+	labels      map[string]string
+	labeledPods bool
+
+	lock     sync.Mutex
+	selected map[types.UID]bool
+	rejected map[types.UID]time.Time
+}
+
+// NewDeployedPods creates a new DeployedPods. labeledPods tells whether the
+// pod templates of the deployed objects are labeled.
+func NewDeployedPods(client kubernetes.Interface, images []string, labels map[string]string, labeledPods bool) *DeployedPods {
+	baseNames := map[string]bool{}
+	for _, image := range images {
+		baseNames[baseName(image)] = true
+	}
+
+	return &DeployedPods{
+		client:      client,
+		images:      baseNames,
+		labels:      labels,
+		labeledPods: labeledPods,
+		selected:    map[types.UID]bool{},
+		rejected:    map[types.UID]time.Time{},
+	}
+}
+
+// ListOptions selects the pods by their labels, when the pod templates are labeled.
+func (d *DeployedPods) ListOptions() meta_v1.ListOptions {
+	if !d.labeledPods {
+		return meta_v1.ListOptions{}
+	}
+
+	return meta_v1.ListOptions{
+		LabelSelector: k8slabels.SelectorFromSet(d.labels).String(),
+	}
+}
+
+// Select returns true if the pod runs one of the images and is labeled.
+func (d *DeployedPods) Select(pod *v1.Pod) bool {
+	d.lock.Lock()
+	selected := d.selected[pod.UID]
+	rejectedAt, rejected := d.rejected[pod.UID]
+	d.lock.Unlock()
+
+	if selected {
+		return true
+	}
+	// The objects that were deployed before get the labels of the run when they're
+	// deployed again, sometimes after their pods are seen. Rejected pods are checked
+	// again after a while.
+	if rejected && time.Since(rejectedAt) < recheckDelay {
+		return false
+	}
+
+	// The lock is not held while the controllers are fetched.
+	selected = d.runsImage(pod) && d.isLabeled(pod.Namespace, &pod.ObjectMeta)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if !selected {
+		d.rejected[pod.UID] = time.Now()
+		return false
+	}
+
+	delete(d.rejected, pod.UID)
+	d.selected[pod.UID] = true
+	return true
+}
+
+// Forget forgets whether a deleted pod was selected.
+func (d *DeployedPods) Forget(pod *v1.Pod) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.selected, pod.UID)
+	delete(d.rejected, pod.UID)
+}
+
+func (d *DeployedPods) runsImage(pod *v1.Pod) bool {
+	if len(d.images) == 0 {
+		return true
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if d.images[baseName(container.Image)] {
+			return true
+		}
+	}
+	return false
+}
+
+// isLabeled checks the labels of an object, then the ones of its controller, until
+// an object without a controller is found. Controllers don't always copy their labels
+// to the objects they create.
+func (d *DeployedPods) isLabeled(namespace string, meta *meta_v1.ObjectMeta) bool {
+	for meta != nil {
+		if hasLabels(meta.Labels, d.labels) {
+			return true
+		}
+
+		owner := meta_v1.GetControllerOf(meta)
+		if owner == nil || d.labeledPods {
+			return false
+		}
+
+		var err error
+		meta, err = d.getController(namespace, owner)
+		if err != nil {
+			logrus.Debugf("getting %s %s: %s", owner.Kind, owner.Name, err)
+			return false
+		}
+	}
+
+	return false
+}
+
+func (d *DeployedPods) getController(namespace string, owner *meta_v1.OwnerReference) (*meta_v1.ObjectMeta, error) {
+	opts := meta_v1.GetOptions{}
+
+	switch owner.Kind {
+	case "ReplicaSet":
+		obj, err := d.client.AppsV1().ReplicaSets(namespace).Get(owner.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+
+	case "Deployment":
+		obj, err := d.client.AppsV1().Deployments(namespace).Get(owner.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+
+	case "StatefulSet":
+		obj, err := d.client.AppsV1().StatefulSets(namespace).Get(owner.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+
+	case "DaemonSet":
+		obj, err := d.client.AppsV1().DaemonSets(namespace).Get(owner.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+
+	case "Job":
+		obj, err := d.client.BatchV1().Jobs(namespace).Get(owner.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+
+	default:
+		// Unknown controllers are not followed.
+		return nil, nil
+	}
+}
+
+func hasLabels(labels, expected map[string]string) bool {
+	for k, v := range expected {
+		if actual, present := labels[k]; !present || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+func baseName(image string) string {
+	parsed, err := docker.ParseReference(image)
+	if err != nil {
+		return image
+	}
+	return parsed.BaseName
+}
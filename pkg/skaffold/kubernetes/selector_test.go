@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controlledBy(kind, name string) []meta_v1.OwnerReference {
+	controller := true
+	return []meta_v1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func podOf(uid, image string, labels map[string]string, owners []meta_v1.OwnerReference) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            uid,
+			Namespace:       "default",
+			UID:             types.UID(uid),
+			Labels:          labels,
+			OwnerReferences: owners,
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: image}}},
+	}
+}
+
+func TestDeployedPodsSelect(t *testing.T) {
+	deployed := map[string]string{"deployed-with": "skaffold"}
+
+	objects := []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default", Labels: deployed}},
+		&appsv1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{Name: "web-1234", Namespace: "default", OwnerReferences: controlledBy("Deployment", "web")}},
+		&appsv1.Deployment{ObjectMeta: meta_v1.ObjectMeta{Name: "other", Namespace: "default"}},
+		&appsv1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{Name: "other-1234", Namespace: "default", OwnerReferences: controlledBy("Deployment", "other")}},
+	}
+
+	var tests = []struct {
+		description string
+		pod         *v1.Pod
+		expected    bool
+	}{
+		{
+			description: "labeled pod",
+			pod:         podOf("pod", "gcr.io/project/web:v1", deployed, nil),
+			expected:    true,
+		},
+		{
+			description: "labeled deployment",
+			pod:         podOf("web", "gcr.io/project/web@sha256:7f4bfa1df98afb4a1fbd0aa3ba8a6b4213ef3e5e52dbbcca49eb2b5dc61d6ff8", nil, controlledBy("ReplicaSet", "web-1234")),
+			expected:    true,
+		},
+		{
+			description: "unlabeled deployment",
+			pod:         podOf("other", "gcr.io/project/web:v1", nil, controlledBy("ReplicaSet", "other-1234")),
+			expected:    false,
+		},
+		{
+			description: "missing controller",
+			pod:         podOf("missing", "gcr.io/project/web:v1", nil, controlledBy("ReplicaSet", "missing")),
+			expected:    false,
+		},
+		{
+			description: "other image",
+			pod:         podOf("worker", "gcr.io/project/worker:v1", deployed, nil),
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			selector := NewDeployedPods(fake.NewSimpleClientset(objects...), []string{"gcr.io/project/web"}, deployed, false)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, selector.Select(test.pod))
+		})
+	}
+}
+
+func TestDeployedPodsRunID(t *testing.T) {
+	selector := NewDeployedPods(fake.NewSimpleClientset(), []string{"gcr.io/project/web"}, map[string]string{
+		"deployed-with":   "skaffold",
+		"skaffold-run-id": "1234",
+	}, false)
+
+	current := podOf("current", "gcr.io/project/web:v2", map[string]string{"deployed-with": "skaffold", "skaffold-run-id": "1234"}, nil)
+	previous := podOf("previous", "gcr.io/project/web:v1", map[string]string{"deployed-with": "skaffold", "skaffold-run-id": "5678"}, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, selector.Select(current))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, selector.Select(previous))
+}
+
+func TestDeployedPodsLabeledLater(t *testing.T) {
+	defer func(d time.Duration) { recheckDelay = d }(recheckDelay)
+	recheckDelay = 0
+
+	client := fake.NewSimpleClientset(&appsv1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{Name: "web-1234", Namespace: "default"}})
+	selector := NewDeployedPods(client, nil, map[string]string{"skaffold-run-id": "1234"}, false)
+	pod := podOf("web", "redis", nil, controlledBy("ReplicaSet", "web-1234"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, selector.Select(pod))
+
+	labeled := &appsv1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{Name: "web-1234", Namespace: "default", Labels: map[string]string{"skaffold-run-id": "1234"}}}
+	if _, err := client.AppsV1().ReplicaSets("default").Update(labeled); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, selector.Select(pod))
+}
+
+func TestDeployedPodsLabeledPods(t *testing.T) {
+	deployed := map[string]string{"skaffold-run-id": "1234"}
+	labeledDeployment := &appsv1.Deployment{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default", Labels: deployed}}
+	selector := NewDeployedPods(fake.NewSimpleClientset(labeledDeployment), nil, deployed, true)
+
+	labeled := podOf("labeled", "gcr.io/project/web:v1", deployed, nil)
+	// With labeled pod templates, the controllers are not checked
+	unlabeled := podOf("unlabeled", "gcr.io/project/web:v1", nil, controlledBy("Deployment", "web"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, selector.Select(labeled))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, selector.Select(unlabeled))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, meta_v1.ListOptions{LabelSelector: "skaffold-run-id=1234"}, selector.ListOptions())
+}
+
+func TestDeployedPodsForget(t *testing.T) {
+	selector := NewDeployedPods(fake.NewSimpleClientset(), nil, map[string]string{"skaffold-run-id": "1234"}, false)
+
+	selected := podOf("selected", "redis", map[string]string{"skaffold-run-id": "1234"}, nil)
+	rejected := podOf("rejected", "redis", nil, nil)
+	selector.Select(selected)
+	selector.Select(rejected)
+
+	selector.Forget(selected)
+	selector.Forget(rejected)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(selector.selected))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(selector.rejected))
+}
@@ -73,6 +73,32 @@ func WaitForPodReady(pods corev1.PodInterface, podName string) error {
 	})
 }
 
+// WaitForPodInitContainerRunning waits until a given init container is running.
+func WaitForPodInitContainerRunning(pods corev1.PodInterface, podName, containerName string, timeout time.Duration) error {
+	logrus.Infof("Waiting for %s to be initialized", podName)
+	return wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
+		pod, err := pods.Get(podName, meta_v1.GetOptions{
+			IncludeUninitialized: true,
+		})
+		if err != nil {
+			logrus.Infof("Getting pod %s", err)
+			return false, nil
+		}
+
+		for _, c := range pod.Status.InitContainerStatuses {
+			if c.Name != containerName {
+				continue
+			}
+			if c.State.Terminated != nil {
+				return false, fmt.Errorf("init container %s already terminated", containerName)
+			}
+			return c.State.Running != nil, nil
+		}
+
+		return false, nil
+	})
+}
+
 func WaitForPodComplete(pods corev1.PodInterface, podName string, timeout time.Duration) error {
 	logrus.Infof("Waiting for %s to be ready", podName)
 	return wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
@@ -17,21 +17,101 @@ limitations under the License.
 package runner
 
 import (
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"fmt"
+	gosync "sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sync"
 )
 
+// changes accumulates what needs to be done after files were changed.
+// It's safe to use from multiple goroutines.
 type changes struct {
-	diryArtifacts []*v1alpha2.Artifact
+	mu            gosync.Mutex
+	diryArtifacts []*v1alpha3.Artifact
+	needsResync   []*sync.Item
 	needsRedeploy bool
 	needsReload   bool
 }
 
-func (c *changes) Add(a *v1alpha2.Artifact) {
+func (c *changes) Add(a *v1alpha3.Artifact) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, dirty := range c.diryArtifacts {
+		if dirty == a {
+			return
+		}
+	}
 	c.diryArtifacts = append(c.diryArtifacts, a)
 }
 
-func (c *changes) reset() {
+func (c *changes) AddResync(s *sync.Item) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.needsResync = append(c.needsResync, s)
+}
+
+func (c *changes) AddRedeploy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.needsRedeploy = true
+}
+
+func (c *changes) AddReload() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.needsReload = true
+}
+
+func (c *changes) reloadRequested() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.needsReload
+}
+
+// take returns the accumulated changes and resets them.
+func (c *changes) take() *changes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	taken := &changes{
+		diryArtifacts: c.diryArtifacts,
+		needsResync:   c.needsResync,
+		needsRedeploy: c.needsRedeploy,
+		needsReload:   c.needsReload,
+	}
+
 	c.diryArtifacts = nil
+	c.needsResync = nil
 	c.needsRedeploy = false
 	c.needsReload = false
+
+	return taken
+}
+
+// pending describes the accumulated changes.
+func (c *changes) pending() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var pending []string
+	for _, a := range c.diryArtifacts {
+		pending = append(pending, fmt.Sprintf("rebuild %s", a.ImageName))
+	}
+	for _, s := range c.needsResync {
+		pending = append(pending, fmt.Sprintf("sync %d files for %s", len(s.Copy)+len(s.Delete), s.Image))
+	}
+	if c.needsRedeploy {
+		pending = append(pending, "redeploy")
+	}
+	if c.needsReload {
+		pending = append(pending, "reload configuration")
+	}
+
+	return pending
 }
@@ -41,8 +41,8 @@ type withNotification struct {
 	deploy.Deployer
 }
 
-func (w withNotification) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]deploy.Artifact, error) {
-	res, err := w.Deployer.Deploy(ctx, out, builds)
+func (w withNotification) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []deploy.Labeller) ([]deploy.Artifact, error) {
+	res, err := w.Deployer.Deploy(ctx, out, builds, labellers)
 	if err != nil {
 		return nil, err
 	}
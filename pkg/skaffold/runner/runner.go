@@ -21,22 +21,26 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"strings"
+	gosync "sync"
 	"time"
 
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/gcb"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/kaniko"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/local"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	kubectx "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/context"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/portforward"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sync"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -52,36 +56,68 @@ type SkaffoldRunner struct {
 	build.Builder
 	deploy.Deployer
 	tag.Tagger
+	sync.Syncer
 
 	opts         *config.SkaffoldOptions
 	watchFactory watch.Factory
-	builds       []build.Artifact
+	kubeContext  string
+	portForward  []*v1alpha3.PortForwardResource
+	forwarder    *portforward.Forwarder
+	requires     []v1alpha3.ConfigDependency
+	labellers    []deploy.Labeller
+	namespaces   []string
+
+	// keptLogFilter is the log filter of a previous run of dev mode.
+	keptLogFilter *kubernetes.LogFilter
+
+	// applyMu makes the changes detected by the watcher and the triggered
+	// ones be applied one at a time.
+	applyMu gosync.Mutex
+
+	// buildsMu guards builds, which the watcher reads to find the files to sync.
+	buildsMu gosync.Mutex
+	builds   []build.Artifact
 }
 
-// NewForConfig returns a new SkaffoldRunner for a SkaffoldConfig
-func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig) (*SkaffoldRunner, error) {
+// NewForConfig returns a new SkaffoldRunner for a SkaffoldConfig. The profiles that were
+// activated automatically are only used to label the deployed resources.
+func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig, activatedProfiles []string) (*SkaffoldRunner, error) {
 	kubeContext, err := kubectx.CurrentContext()
 	if err != nil {
 		return nil, errors.Wrap(err, "getting current cluster context")
 	}
 	logrus.Infof("Using kubectl context: %s", kubeContext)
 
+	event.InitializeState(cfg.Build.Artifacts)
+
 	tagger, err := getTagger(cfg.Build.TagPolicy, opts.CustomTag)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold tag config")
 	}
 
-	builder, err := getBuilder(&cfg.Build, kubeContext)
+	builder, err := getBuilder(&cfg.Build, kubeContext, opts)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold build config")
 	}
 
-	deployer, err := getDeployer(&cfg.Deploy, kubeContext, opts.Namespace)
+	deployer, err := getDeployer(&cfg.Deploy, kubeContext, opts.Namespace, opts.LabelPodTemplates)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold deploy config")
 	}
 
-	deployer = deploy.WithLabels(deployer, opts, builder, deployer, tagger)
+	watchFactory, err := getWatchFactory(opts.Trigger)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing trigger")
+	}
+
+	labellers := []deploy.Labeller{opts, profilesLabeller{selected: opts.Profiles, activated: activatedProfiles}, builder, deployer, tagger}
+	if opts.RunID != "" {
+		var releases deploy.Deployer
+		if cfg.Deploy.HelmDeploy != nil {
+			releases = deploy.NewHelmDeployer(cfg.Deploy.HelmDeploy, kubeContext, opts.Namespace, opts.LabelPodTemplates)
+		}
+		deployer = deploy.WithLabelCleanup(deployer, runLabels(opts.RunID), releases)
+	}
 	builder, deployer = WithTimings(builder, deployer)
 	if opts.Notification {
 		deployer = WithNotification(deployer)
@@ -91,16 +127,25 @@ func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig) (*Sk
 		Builder:      builder,
 		Deployer:     deployer,
 		Tagger:       tagger,
+		Syncer:       sync.NewKubectlSyncer(kubeContext, runLabels(opts.RunID), opts.LabelPodTemplates),
 		opts:         opts,
-		watchFactory: watch.NewWatcher,
+		watchFactory: watchFactory,
+		kubeContext:  kubeContext,
+		portForward:  cfg.Deploy.PortForward,
+		requires:     cfg.Requires,
+		labellers:    labellers,
 	}, nil
 }
 
-func getBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, error) {
+func getBuilder(cfg *v1alpha3.BuildConfig, kubeContext string, opts *config.SkaffoldOptions) (build.Builder, error) {
 	switch {
 	case cfg.LocalBuild != nil:
 		logrus.Debugf("Using builder: local")
-		return local.NewBuilder(cfg.LocalBuild, kubeContext)
+		artifactCache, err := getArtifactCache(opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading artifact cache")
+		}
+		return local.NewBuilder(cfg.LocalBuild, kubeContext, artifactCache)
 
 	case cfg.GoogleCloudBuild != nil:
 		logrus.Debugf("Using builder: google cloud")
@@ -108,35 +153,104 @@ func getBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, e
 
 	case cfg.KanikoBuild != nil:
 		logrus.Debugf("Using builder: kaniko")
-		return kaniko.NewBuilder(cfg.KanikoBuild), nil
+		return kaniko.NewBuilder(cfg.KanikoBuild, kubeContext), nil
 
 	default:
 		return nil, fmt.Errorf("Unknown builder for config %+v", cfg)
 	}
 }
 
-func getDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace string) (deploy.Deployer, error) {
-	switch {
-	case cfg.KubectlDeploy != nil:
-		// TODO(dgageot): this should be the folder containing skaffold.yaml. Should also be moved elsewhere.
-		cwd, err := os.Getwd()
-		if err != nil {
-			return nil, errors.Wrap(err, "finding current directory")
+func getArtifactCache(opts *config.SkaffoldOptions) (*cache.Cache, error) {
+	file := opts.CacheFile
+	if file == "" {
+		var err error
+		if file, err = cache.DefaultFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.PurgeCache {
+		logrus.Infof("Purging artifact cache %s", file)
+		if err := cache.Purge(file); err != nil {
+			return nil, err
 		}
-		return deploy.NewKubectlDeployer(cwd, cfg.KubectlDeploy, kubeContext, namespace), nil
+	}
 
-	case cfg.HelmDeploy != nil:
-		return deploy.NewHelmDeployer(cfg.HelmDeploy, kubeContext, namespace), nil
+	if !opts.CacheArtifacts {
+		return nil, nil
+	}
 
-	case cfg.KustomizeDeploy != nil:
-		return deploy.NewKustomizeDeployer(cfg.KustomizeDeploy, kubeContext, namespace), nil
+	return cache.Load(file)
+}
 
-	default:
+func getDeployer(cfg *v1alpha3.DeployConfig, kubeContext string, namespace string, labelPodTemplates bool) (deploy.Deployer, error) {
+	deployers, err := getDeployers(cfg, kubeContext, namespace, labelPodTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(deployers) {
+	case 0:
 		return nil, fmt.Errorf("Unknown deployer for config %+v", cfg)
+	case 1:
+		return deployers[0], nil
+	default:
+		return deploy.NewMultiDeployer(deployers...), nil
+	}
+}
+
+// getDeployers returns the configured deployers, in the order they were declared in.
+func getDeployers(cfg *v1alpha3.DeployConfig, kubeContext string, namespace string, labelPodTemplates bool) ([]deploy.Deployer, error) {
+	var deployers []deploy.Deployer
+
+	for _, name := range cfg.Deployers() {
+		switch name {
+		case "helm":
+			deployers = append(deployers, deploy.NewHelmDeployer(cfg.HelmDeploy, kubeContext, namespace, labelPodTemplates))
+
+		case "kubectl":
+			// TODO(dgageot): this should be the folder containing skaffold.yaml. Should also be moved elsewhere.
+			cwd, err := os.Getwd()
+			if err != nil {
+				return nil, errors.Wrap(err, "finding current directory")
+			}
+			deployers = append(deployers, deploy.NewKubectlDeployer(cwd, cfg.KubectlDeploy, kubeContext, namespace, labelPodTemplates))
+
+		case "kustomize":
+			deployers = append(deployers, deploy.NewKustomizeDeployer(cfg.KustomizeDeploy, kubeContext, namespace, labelPodTemplates))
+		}
+	}
+
+	return deployers, nil
+}
+
+func getWatchFactory(trigger string) (watch.Factory, error) {
+	switch trigger {
+	case "", "polling", "manual":
+		return watch.NewWatcher, nil
+
+	case "notify":
+		return watch.NewNotifyWatcher, nil
+
+	default:
+		return nil, fmt.Errorf("Unknown trigger: %s", trigger)
+	}
+}
+
+func getLogOptions(format, dir string) (kubernetes.LogOptions, error) {
+	switch format {
+	case "", "text":
+		return kubernetes.LogOptions{Dir: dir}, nil
+
+	case "json":
+		return kubernetes.LogOptions{JSON: true, Dir: dir}, nil
+
+	default:
+		return kubernetes.LogOptions{}, fmt.Errorf("Unknown log format: %s", format)
 	}
 }
 
-func getTagger(t v1alpha2.TagPolicy, customTag string) (tag.Tagger, error) {
+func getTagger(t v1alpha3.TagPolicy, customTag string) (tag.Tagger, error) {
 	switch {
 	case customTag != "":
 		return &tag.CustomTag{
@@ -160,51 +274,163 @@ func getTagger(t v1alpha2.TagPolicy, customTag string) (tag.Tagger, error) {
 	}
 }
 
+// Deploy deploys the built artifacts with the labels of the runner's components.
+// It keeps track of the namespaces the objects were deployed to.
+func (r *SkaffoldRunner) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]deploy.Artifact, error) {
+	deployed, err := r.Deployer.Deploy(ctx, out, builds, r.labellers)
+	r.addNamespaces(deployed)
+	return deployed, err
+}
+
+// addNamespaces records the namespaces of the deployed objects, where the files are synced.
+func (r *SkaffoldRunner) addNamespaces(deployed []deploy.Artifact) {
+	var defaultNamespace string
+	if r.opts != nil {
+		defaultNamespace = r.opts.Namespace
+	}
+
+	namespaces, err := deploy.Namespaces(deployed, defaultNamespace)
+	if err != nil {
+		logrus.Warnln("Finding the namespaces of the deployed objects:", err)
+		return
+	}
+
+	for _, namespace := range namespaces {
+		if !contains(r.namespaces, namespace) {
+			r.namespaces = append(r.namespaces, namespace)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Run builds artifacts ad then deploys them.
-func (r *SkaffoldRunner) Run(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact) error {
+func (r *SkaffoldRunner) Run(ctx context.Context, out io.Writer, artifacts []*v1alpha3.Artifact) error {
 	bRes, err := r.Build(ctx, out, r.Tagger, artifacts)
 	if err != nil {
 		return errors.Wrap(err, "build step")
 	}
 
-	_, err = r.Deploy(ctx, out, bRes)
+	deployed, err := r.Deploy(ctx, out, bRes)
 	if err != nil {
 		return errors.Wrap(err, "deploy step")
 	}
 
+	return r.CheckStatus(ctx, out, deployed)
+}
+
+// CheckStatus waits for the deployed resources to be ready,
+// if the status check is enabled.
+func (r *SkaffoldRunner) CheckStatus(ctx context.Context, out io.Writer, deployed []deploy.Artifact) error {
+	if r.opts == nil || !r.opts.StatusCheck {
+		return nil
+	}
+
+	client, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting kubernetes client")
+	}
+
+	if err := deploy.StatusCheck(ctx, out, client, deployed, r.opts.Namespace, r.opts.StatusCheckDeadline); err != nil {
+		return errors.Wrap(err, "status check")
+	}
+
+	return nil
+}
+
+// Logs prints the logs of the pods deployed by skaffold that run the images of the artifacts.
+// With follow, it streams them until the context is cancelled.
+func (r *SkaffoldRunner) Logs(ctx context.Context, out io.Writer, artifacts []*v1alpha3.Artifact, since time.Time, follow bool) error {
+	client, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+
+	var images []string
+	for _, artifact := range artifacts {
+		images = append(images, artifact.ImageName)
+	}
+
+	labels := map[string]string{}
+	for k, v := range constants.Labels.DefaultLabels {
+		labels[k] = v
+	}
+	if r.opts.RunID != "" {
+		labels[constants.Labels.RunID] = r.opts.RunID
+	}
+
+	logOptions, err := getLogOptions(r.opts.LogFormat, r.opts.LogDir)
+	if err != nil {
+		return err
+	}
+	podSelector := kubernetes.NewDeployedPods(client, images, labels, r.opts.LabelPodTemplates)
+	logger := kubernetes.NewLogAggregator(out, podSelector, r.opts.Namespace, kubernetes.NewColorPicker(artifacts), logOptions)
+	if err := logger.SetFilter(r.logFilter()); err != nil {
+		return errors.Wrap(err, "setting log filter")
+	}
+
+	if !follow {
+		return logger.Print(ctx, since)
+	}
+
+	if err := logger.StartSince(ctx, since); err != nil {
+		return errors.Wrap(err, "starting logger")
+	}
+	<-ctx.Done()
 	return nil
 }
 
 // Dev watches for changes and runs the skaffold build and deploy
 // pipeline until interrrupted by the user.
-func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact) ([]build.Artifact, error) {
-	imageList := kubernetes.NewImageList()
+func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1alpha3.Artifact) ([]build.Artifact, error) {
+	client, err := kubernetes.Client()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	podSelector := kubernetes.NewDeployedPods(client, nil, runLabels(r.opts.RunID), r.opts.LabelPodTemplates)
 	colorPicker := kubernetes.NewColorPicker(artifacts)
-	logger := kubernetes.NewLogAggregator(out, imageList, colorPicker)
+	logOptions, err := getLogOptions(r.opts.LogFormat, r.opts.LogDir)
+	if err != nil {
+		return nil, err
+	}
+	logger := kubernetes.NewLogAggregator(out, podSelector, r.opts.Namespace, colorPicker, logOptions)
+	if err := logger.SetFilter(r.logFilter()); err != nil {
+		return nil, errors.Wrap(err, "setting log filter")
+	}
+	// The filter can be changed through the control server
+	defer func() {
+		filter := logger.Filter()
+		r.keptLogFilter = &filter
+	}()
+	if r.opts != nil && r.opts.PortForward {
+		r.forwarder = portforward.NewForwarder(out, podSelector, r.kubeContext, r.portForward)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// Create watcher and register artifacts to build current state of files.
-	changed := changes{}
+	changed := &changes{}
+	immediate := r.opts.Trigger != "manual" && r.opts.WatchDebounce == 0
+	detected := make(chan struct{})
 	onChange := func() error {
-		logger.Mute()
-
-		var err error
-
-		switch {
-		case changed.needsReload:
-			err = ErrorConfigurationChanged
-		case len(changed.diryArtifacts) > 0:
-			err = r.buildAndDeploy(ctx, out, changed.diryArtifacts, imageList)
-		case changed.needsRedeploy:
-			if _, err := r.Deploy(ctx, out, r.builds); err != nil {
-				logrus.Warnln("Skipping Deploy due to error:", err)
-			}
+		if immediate || changed.reloadRequested() {
+			return r.applyChanges(ctx, out, changed.take(), logger)
 		}
 
-		color.Default.Fprintln(out, "Watching for changes...")
-		changed.reset()
-		logger.Unmute()
-
-		return err
+		color.Default.Fprintln(out, "Pending changes:", strings.Join(changed.pending(), ", "))
+		select {
+		case detected <- struct{}{}:
+		case <-ctx.Done():
+		}
+		return nil
 	}
 
 	watcher := r.watchFactory()
@@ -214,8 +440,19 @@ func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1
 		artifact := artifacts[i]
 
 		if err := watcher.Register(
-			func() ([]string, error) { return dependenciesForArtifact(artifact) },
-			func() { changed.Add(artifact) },
+			func() ([]string, error) { return build.DependenciesForArtifact(artifact) },
+			func(e watch.Events) {
+				s, err := sync.NewItem(artifact, e, r.lastBuilds())
+				switch {
+				case err != nil:
+					logrus.Warnf("error computing files to sync for %s: %s", artifact.ImageName, err)
+					changed.Add(artifact)
+				case s != nil:
+					changed.AddResync(s)
+				default:
+					changed.Add(artifact)
+				}
+			},
 		); err != nil {
 			return nil, errors.Wrapf(err, "watching files for artifact %s", artifact.ImageName)
 		}
@@ -224,21 +461,29 @@ func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1
 	// Watch deployment configuration
 	if err := watcher.Register(
 		func() ([]string, error) { return r.Dependencies() },
-		func() { changed.needsRedeploy = true },
+		func(watch.Events) { changed.AddRedeploy() },
 	); err != nil {
 		return nil, errors.Wrap(err, "watching files for deployer")
 	}
 
-	// Watch Skaffold configuration
+	// Watch Skaffold configuration, and the configurations it requires
 	if err := watcher.Register(
-		func() ([]string, error) { return []string{r.opts.ConfigurationFile}, nil },
-		func() { changed.needsReload = true },
+		func() ([]string, error) { return r.configurationFiles(), nil },
+		func(watch.Events) { changed.AddReload() },
 	); err != nil {
 		return nil, errors.Wrapf(err, "watching skaffold configuration %s", r.opts.ConfigurationFile)
 	}
 
+	// Start port forwarding
+	if r.forwarder != nil {
+		if err := r.forwarder.Start(ctx); err != nil {
+			return nil, errors.Wrap(err, "starting port forwarding")
+		}
+		defer r.forwarder.Stop()
+	}
+
 	// First run
-	if err := r.buildAndDeploy(ctx, out, artifacts, imageList); err != nil {
+	if err := r.buildAndDeploy(ctx, out, artifacts); err != nil {
 		return nil, errors.Wrap(err, "first run")
 	}
 
@@ -247,12 +492,210 @@ func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1
 		return nil, errors.Wrap(err, "starting logger")
 	}
 
-	return nil, watcher.Run(ctx, PollInterval, onChange)
+	// Builds are only triggered when changes are accumulated
+	var triggers chan struct{}
+	if !immediate {
+		triggers = make(chan struct{})
+	}
+	if r.opts.TriggerPort != 0 {
+		if err := r.startControlServer(ctx, triggers, logger); err != nil {
+			return nil, errors.Wrap(err, "starting control server")
+		}
+	}
+
+	// Apply accumulated changes when triggered
+	failed := make(chan error, 1)
+	if !immediate {
+		if r.opts.Trigger == "manual" {
+			color.Default.Fprintln(out, r.triggerHelp())
+		}
+
+		go func() {
+			if err := r.applyOnTrigger(ctx, out, changed, detected, triggers, logger); err != nil {
+				failed <- err
+				cancel()
+			}
+		}()
+	}
+
+	if err := watcher.Run(ctx, PollInterval, onChange); err != nil {
+		return nil, err
+	}
+
+	select {
+	case err := <-failed:
+		return nil, err
+	default:
+		return nil, nil
+	}
+}
+
+// applyOnTrigger applies the accumulated changes once the debounce window has
+// elapsed without new changes or, in manual mode, each time a build is
+// triggered by the user.
+func (r *SkaffoldRunner) applyOnTrigger(ctx context.Context, out io.Writer, changed *changes, detected <-chan struct{}, triggers <-chan struct{}, logger *kubernetes.LogAggregator) error {
+	manual := r.opts.Trigger == "manual"
+
+	var keyPresses <-chan struct{}
+	if manual {
+		keyPresses = stdinTriggers()
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-detected:
+			if !manual {
+				debounce = time.After(r.opts.WatchDebounce)
+			}
+			continue
+		case <-debounce:
+		case <-triggers:
+		case <-keyPresses:
+		}
+		debounce = nil
+
+		c := changed.take()
+		if len(c.pending()) == 0 {
+			color.Default.Fprintln(out, "No changes to apply")
+			continue
+		}
+
+		if err := r.applyChanges(ctx, out, c, logger); err != nil {
+			return err
+		}
+	}
+}
+
+// configurationFiles lists the configuration file and the configurations it requires.
+func (r *SkaffoldRunner) configurationFiles() []string {
+	files := []string{r.opts.ConfigurationFile}
+	for _, required := range r.requires {
+		files = append(files, required.Path)
+	}
+	return files
+}
+
+// runLabels are the labels of the objects deployed by a run.
+// profilesLabeller labels the deployed resources with the profiles that were
+// selected and those that were activated automatically.
+type profilesLabeller struct {
+	selected  []string
+	activated []string
+}
+
+func (p profilesLabeller) Labels() map[string]string {
+	var profiles []string
+	profiles = append(profiles, p.selected...)
+	profiles = append(profiles, p.activated...)
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	return map[string]string{
+		"profiles": strings.Join(profiles, ","),
+	}
+}
+
+func runLabels(runID string) map[string]string {
+	return map[string]string{
+		constants.Labels.RunID: runID,
+	}
+}
+
+// LogFilter returns the log filter that dev mode ended with. It can be given to
+// the runner of a restarted dev mode with KeepLogFilter.
+func (r *SkaffoldRunner) LogFilter() kubernetes.LogFilter {
+	return r.logFilter()
+}
+
+// KeepLogFilter makes dev mode start with the log filter of a previous run,
+// instead of the one set with the command line flags.
+func (r *SkaffoldRunner) KeepLogFilter(filter kubernetes.LogFilter) {
+	r.keptLogFilter = &filter
+}
+
+// logFilter is the log filter set with the command line flags, or kept from a previous run.
+func (r *SkaffoldRunner) logFilter() kubernetes.LogFilter {
+	if r.keptLogFilter != nil {
+		return *r.keptLogFilter
+	}
+
+	return kubernetes.LogFilter{
+		Include: r.opts.LogInclude,
+		Exclude: r.opts.LogExclude,
+		Match:   r.opts.LogMatch,
+	}
+}
+
+// triggerHelp tells the user how to trigger a build in manual mode.
+func (r *SkaffoldRunner) triggerHelp() string {
+	if r.opts.TriggerPort != 0 {
+		return fmt.Sprintf("Press enter or POST to http://127.0.0.1:%d%s to apply pending changes", r.opts.TriggerPort, TriggerPath)
+	}
+	return "Press enter to apply pending changes"
+}
+
+// applyChanges syncs, rebuilds, redeploys or reloads depending on what changed.
+func (r *SkaffoldRunner) applyChanges(ctx context.Context, out io.Writer, changed *changes, logger *kubernetes.LogAggregator) error {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	logger.Mute()
+
+	var err error
+
+	if !changed.needsReload {
+		for _, a := range r.syncFiles(ctx, out, changed.needsResync) {
+			changed.Add(a)
+		}
+	}
+
+	switch {
+	case changed.needsReload:
+		err = ErrorConfigurationChanged
+	case len(changed.diryArtifacts) > 0:
+		err = r.buildAndDeploy(ctx, out, changed.diryArtifacts)
+	case changed.needsRedeploy:
+		dRes, err := r.Deploy(ctx, out, r.lastBuilds())
+		if err != nil {
+			logrus.Warnln("Skipping Deploy due to error:", err)
+		}
+		r.forwardServices(dRes)
+	}
+
+	color.Default.Fprintln(out, "Watching for changes...")
+	logger.Unmute()
+
+	return err
+}
+
+// syncFiles copies changed files into the running containers, instead of
+// rebuilding and redeploying their artifacts. It returns the artifacts that
+// couldn't be synced and need to be rebuilt.
+func (r *SkaffoldRunner) syncFiles(ctx context.Context, out io.Writer, items []*sync.Item) []*v1alpha3.Artifact {
+	var failed []*v1alpha3.Artifact
+
+	for _, s := range items {
+		color.Default.Fprintf(out, "Syncing %d files for %s\n", len(s.Copy)+len(s.Delete), s.Image)
+
+		s.Namespaces = r.namespaces
+
+		if err := r.Sync(ctx, s); err != nil {
+			logrus.Warnln("Rebuilding instead of syncing due to error:", err)
+			failed = append(failed, s.Artifact)
+		}
+	}
+
+	return failed
 }
 
 // buildAndDeploy builds a subset of the artifacts and deploys everything.
-func (r *SkaffoldRunner) buildAndDeploy(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact, images *kubernetes.ImageList) error {
-	firstRun := r.builds == nil
+func (r *SkaffoldRunner) buildAndDeploy(ctx context.Context, out io.Writer, artifacts []*v1alpha3.Artifact) error {
+	previous := r.lastBuilds()
+	firstRun := previous == nil
 
 	bRes, err := r.Build(ctx, out, r.Tagger, artifacts)
 	if err != nil {
@@ -264,15 +707,11 @@ func (r *SkaffoldRunner) buildAndDeploy(ctx context.Context, out io.Writer, arti
 		return nil
 	}
 
-	// Update which images are logged.
-	for _, build := range bRes {
-		images.Add(build.Tag)
-	}
-
 	// Make sure all artifacts are redeployed. Not only those that were just rebuilt.
-	r.builds = mergeWithPreviousBuilds(bRes, r.builds)
+	builds := mergeWithPreviousBuilds(bRes, previous)
+	r.setBuilds(builds)
 
-	_, err = r.Deploy(ctx, out, r.builds)
+	dRes, err := r.Deploy(ctx, out, builds)
 	if err != nil {
 		if firstRun {
 			return errors.Wrap(err, "exiting dev mode because the first deploy failed")
@@ -282,9 +721,32 @@ func (r *SkaffoldRunner) buildAndDeploy(ctx context.Context, out io.Writer, arti
 		return nil
 	}
 
+	r.forwardServices(dRes)
 	return nil
 }
 
+// lastBuilds returns the latest build of each artifact.
+func (r *SkaffoldRunner) lastBuilds() []build.Artifact {
+	r.buildsMu.Lock()
+	defer r.buildsMu.Unlock()
+
+	return r.builds
+}
+
+func (r *SkaffoldRunner) setBuilds(builds []build.Artifact) {
+	r.buildsMu.Lock()
+	defer r.buildsMu.Unlock()
+
+	r.builds = builds
+}
+
+// forwardServices port forwards the services that were just deployed.
+func (r *SkaffoldRunner) forwardServices(deployed []deploy.Artifact) {
+	if r.forwarder != nil {
+		r.forwarder.ForwardServices(deployed)
+	}
+}
+
 func mergeWithPreviousBuilds(builds, previous []build.Artifact) []build.Artifact {
 	updatedBuilds := map[string]bool{}
 	for _, build := range builds {
@@ -302,31 +764,3 @@ func mergeWithPreviousBuilds(builds, previous []build.Artifact) []build.Artifact
 
 	return merged
 }
-
-func dependenciesForArtifact(a *v1alpha2.Artifact) ([]string, error) {
-	var (
-		paths []string
-		err   error
-	)
-
-	switch {
-	case a.DockerArtifact != nil:
-		paths, err = docker.GetDependencies(a.Workspace, a.DockerArtifact)
-
-	case a.BazelArtifact != nil:
-		paths, err = bazel.GetDependencies(a.Workspace, a.BazelArtifact)
-
-	default:
-		return nil, fmt.Errorf("undefined artifact type: %+v", a.ArtifactType)
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	var p []string
-	for _, path := range paths {
-		p = append(p, filepath.Join(a.Workspace, path))
-	}
-	return p, nil
-}
@@ -18,9 +18,14 @@ package runner
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,7 +35,8 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sync"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	clientgo "k8s.io/client-go/kubernetes"
@@ -39,6 +45,7 @@ import (
 
 type TestBuilder struct {
 	built  []build.Artifact
+	builds int
 	errors []error
 }
 
@@ -46,7 +53,7 @@ func (t *TestBuilder) Labels() map[string]string {
 	return map[string]string{}
 }
 
-func (t *TestBuilder) Build(ctx context.Context, w io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]build.Artifact, error) {
+func (t *TestBuilder) Build(ctx context.Context, w io.Writer, tagger tag.Tagger, artifacts []*v1alpha3.Artifact) ([]build.Artifact, error) {
 	if len(t.errors) > 0 {
 		err := t.errors[0]
 		t.errors = t.errors[1:]
@@ -58,10 +65,12 @@ func (t *TestBuilder) Build(ctx context.Context, w io.Writer, tagger tag.Tagger,
 	for _, artifact := range artifacts {
 		builds = append(builds, build.Artifact{
 			ImageName: artifact.ImageName,
+			Tag:       artifact.ImageName + ":latest",
 		})
 	}
 
 	t.built = builds
+	t.builds++
 	return builds, nil
 }
 
@@ -78,7 +87,7 @@ func (t *TestDeployer) Dependencies() ([]string, error) {
 	return nil, nil
 }
 
-func (t *TestDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]deploy.Artifact, error) {
+func (t *TestDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []deploy.Labeller) ([]deploy.Artifact, error) {
 	if len(t.errors) > 0 {
 		err := t.errors[0]
 		t.errors = t.errors[1:]
@@ -93,12 +102,31 @@ func (t *TestDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 	return nil
 }
 
+func (t *TestDeployer) Render(ctx context.Context, out io.Writer, builds []build.Artifact) error {
+	return nil
+}
+
 func resetClient()                               { kubernetes.Client = kubernetes.GetClientset }
 func fakeGetClient() (clientgo.Interface, error) { return fake.NewSimpleClientset(), nil }
 
+type TestSyncer struct {
+	synced []*sync.Item
+	err    error
+}
+
+func (t *TestSyncer) Sync(ctx context.Context, s *sync.Item) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	t.synced = append(t.synced, s)
+	return nil
+}
+
 type TestWatcher struct {
 	changedArtifacts [][]int
-	changeCallbacks  []func()
+	changeCallbacks  []func(watch.Events)
+	events           watch.Events
 	err              error
 }
 
@@ -111,7 +139,7 @@ func NewWatcherFactory(err error, changedArtifacts ...[]int) watch.Factory {
 	}
 }
 
-func (t *TestWatcher) Register(deps func() ([]string, error), onChange func()) error {
+func (t *TestWatcher) Register(deps func() ([]string, error), onChange func(watch.Events)) error {
 	t.changeCallbacks = append(t.changeCallbacks, onChange)
 	return nil
 }
@@ -119,7 +147,7 @@ func (t *TestWatcher) Register(deps func() ([]string, error), onChange func()) e
 func (t *TestWatcher) Run(ctx context.Context, pollInterval time.Duration, onChange func() error) error {
 	for _, artifactIndices := range t.changedArtifacts {
 		for _, artifactIndex := range artifactIndices {
-			t.changeCallbacks[artifactIndex]()
+			t.changeCallbacks[artifactIndex](t.events)
 		}
 		onChange()
 	}
@@ -129,7 +157,7 @@ func (t *TestWatcher) Run(ctx context.Context, pollInterval time.Duration, onCha
 func TestNewForConfig(t *testing.T) {
 	var tests = []struct {
 		description      string
-		config           *v1alpha2.SkaffoldConfig
+		config           *v1alpha3.SkaffoldConfig
 		shouldErr        bool
 		expectedBuilder  build.Builder
 		expectedDeployer deploy.Deployer
@@ -137,15 +165,15 @@ func TestNewForConfig(t *testing.T) {
 		{
 			description: "local builder config",
 			config: &config.SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+				Build: v1alpha3.BuildConfig{
+					TagPolicy: v1alpha3.TagPolicy{ShaTagger: &v1alpha3.ShaTagger{}},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{
-					DeployType: v1alpha2.DeployType{
-						KubectlDeploy: &v1alpha2.KubectlDeploy{},
+				Deploy: v1alpha3.DeployConfig{
+					DeployType: v1alpha3.DeployType{
+						KubectlDeploy: &v1alpha3.KubectlDeploy{},
 					},
 				},
 			},
@@ -154,16 +182,16 @@ func TestNewForConfig(t *testing.T) {
 		},
 		{
 			description: "bad tagger config",
-			config: &v1alpha2.SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					TagPolicy: v1alpha2.TagPolicy{},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+			config: &v1alpha3.SkaffoldConfig{
+				Build: v1alpha3.BuildConfig{
+					TagPolicy: v1alpha3.TagPolicy{},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
-				Deploy: v1alpha2.DeployConfig{
-					DeployType: v1alpha2.DeployType{
-						KubectlDeploy: &v1alpha2.KubectlDeploy{},
+				Deploy: v1alpha3.DeployConfig{
+					DeployType: v1alpha3.DeployType{
+						KubectlDeploy: &v1alpha3.KubectlDeploy{},
 					},
 				},
 			},
@@ -171,8 +199,8 @@ func TestNewForConfig(t *testing.T) {
 		},
 		{
 			description: "unknown builder",
-			config: &v1alpha2.SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{},
+			config: &v1alpha3.SkaffoldConfig{
+				Build: v1alpha3.BuildConfig{},
 			},
 			shouldErr:        true,
 			expectedBuilder:  &local.Builder{},
@@ -181,10 +209,10 @@ func TestNewForConfig(t *testing.T) {
 		{
 			description: "unknown tagger",
 			config: &config.SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					TagPolicy: v1alpha2.TagPolicy{},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+				Build: v1alpha3.BuildConfig{
+					TagPolicy: v1alpha3.TagPolicy{},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				}},
 			shouldErr:        true,
@@ -194,10 +222,10 @@ func TestNewForConfig(t *testing.T) {
 		{
 			description: "unknown deployer",
 			config: &config.SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
-					BuildType: v1alpha2.BuildType{
-						LocalBuild: &v1alpha2.LocalBuild{},
+				Build: v1alpha3.BuildConfig{
+					TagPolicy: v1alpha3.TagPolicy{ShaTagger: &v1alpha3.ShaTagger{}},
+					BuildType: v1alpha3.BuildType{
+						LocalBuild: &v1alpha3.LocalBuild{},
 					},
 				},
 			},
@@ -206,7 +234,7 @@ func TestNewForConfig(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			cfg, err := NewForConfig(&config.SkaffoldOptions{}, test.config)
+			cfg, err := NewForConfig(&config.SkaffoldOptions{}, test.config, nil)
 
 			testutil.CheckError(t, test.shouldErr, err)
 			if cfg != nil {
@@ -219,6 +247,108 @@ func TestNewForConfig(t *testing.T) {
 	}
 }
 
+func TestGetDeployer(t *testing.T) {
+	var tests = []struct {
+		description string
+		cfg         v1alpha3.DeployType
+		shouldErr   bool
+		expected    deploy.Deployer
+	}{
+		{
+			description: "kubectl",
+			cfg:         v1alpha3.DeployType{KubectlDeploy: &v1alpha3.KubectlDeploy{}},
+			expected:    &deploy.KubectlDeployer{},
+		},
+		{
+			description: "helm and kubectl",
+			cfg: v1alpha3.DeployType{
+				HelmDeploy:    &v1alpha3.HelmDeploy{},
+				KubectlDeploy: &v1alpha3.KubectlDeploy{},
+			},
+			expected: &deploy.MultiDeployer{},
+		},
+		{
+			description: "no deployer",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			deployer, err := getDeployer(&v1alpha3.DeployConfig{DeployType: test.cfg}, "kubecontext", "", false)
+
+			testutil.CheckErrorAndTypeEquality(t, test.shouldErr, err, test.expected, deployer)
+		})
+	}
+}
+
+func TestGetDeployersInDeclaredOrder(t *testing.T) {
+	var tests = []struct {
+		description string
+		contents    string
+		profile     string
+		expected    []string
+	}{
+		{
+			description: "kubectl before helm",
+			contents: `apiVersion: skaffold/v1alpha3
+kind: Config
+deploy:
+  kubectl: {}
+  helm: {}
+`,
+			expected: []string{"*deploy.KubectlDeployer", "*deploy.HelmDeployer"},
+		},
+		{
+			description: "helm before kubectl",
+			contents: `apiVersion: skaffold/v1alpha3
+kind: Config
+deploy:
+  helm: {}
+  kubectl: {}
+`,
+			expected: []string{"*deploy.HelmDeployer", "*deploy.KubectlDeployer"},
+		},
+		{
+			description: "kustomize before kubectl in a profile",
+			contents: `apiVersion: skaffold/v1alpha3
+kind: Config
+deploy:
+  kubectl: {}
+  kustomize: {}
+profiles:
+- name: profile
+  deploy:
+    kustomize: {}
+    kubectl: {}
+`,
+			profile:  "profile",
+			expected: []string{"*deploy.KustomizeDeployer", "*deploy.KubectlDeployer"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg := &v1alpha3.SkaffoldConfig{}
+			err := cfg.Parse([]byte(test.contents), true)
+			testutil.CheckError(t, false, err)
+
+			if test.profile != "" {
+				err := cfg.ApplyProfiles([]string{test.profile})
+				testutil.CheckError(t, false, err)
+			}
+
+			deployers, err := getDeployers(&cfg.Deploy, "kubecontext", "", false)
+
+			var types []string
+			for _, d := range deployers {
+				types = append(types, fmt.Sprintf("%T", d))
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, types)
+		})
+	}
+}
+
 func TestRun(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -229,13 +359,13 @@ func TestRun(t *testing.T) {
 	}{
 		{
 			description: "run no error",
-			config:      &v1alpha2.SkaffoldConfig{},
+			config:      &v1alpha3.SkaffoldConfig{},
 			builder:     &TestBuilder{},
 			deployer:    &TestDeployer{},
 		},
 		{
 			description: "run build error",
-			config:      &v1alpha2.SkaffoldConfig{},
+			config:      &v1alpha3.SkaffoldConfig{},
 			builder: &TestBuilder{
 				errors: []error{fmt.Errorf("")},
 			},
@@ -243,9 +373,9 @@ func TestRun(t *testing.T) {
 		},
 		{
 			description: "run deploy error",
-			config: &v1alpha2.SkaffoldConfig{
-				Build: v1alpha2.BuildConfig{
-					Artifacts: []*v1alpha2.Artifact{
+			config: &v1alpha3.SkaffoldConfig{
+				Build: v1alpha3.BuildConfig{
+					Artifacts: []*v1alpha3.Artifact{
 						{
 							ImageName: "test",
 						},
@@ -334,6 +464,7 @@ func TestDev(t *testing.T) {
 				Builder:      test.builder,
 				Deployer:     test.deployer,
 				Tagger:       &tag.ChecksumTagger{},
+				opts:         &config.SkaffoldOptions{},
 				watchFactory: test.watcherFactory,
 			}
 			_, err := runner.Dev(context.Background(), ioutil.Discard, nil)
@@ -349,7 +480,7 @@ func TestBuildAndDeployAllArtifacts(t *testing.T) {
 
 	builder := &TestBuilder{}
 	deployer := &TestDeployer{}
-	artifacts := []*v1alpha2.Artifact{
+	artifacts := []*v1alpha3.Artifact{
 		{ImageName: "image1"},
 		{ImageName: "image2"},
 	}
@@ -357,6 +488,7 @@ func TestBuildAndDeployAllArtifacts(t *testing.T) {
 	runner := &SkaffoldRunner{
 		Builder:  builder,
 		Deployer: deployer,
+		opts:     &config.SkaffoldOptions{},
 	}
 
 	ctx := context.Background()
@@ -389,3 +521,334 @@ func TestBuildAndDeployAllArtifacts(t *testing.T) {
 		t.Errorf("Expected 2 artifacts to be deployed. Got %d", len(deployer.deployed))
 	}
 }
+
+func TestDevSync(t *testing.T) {
+	kubernetes.Client = fakeGetClient
+	defer resetClient()
+
+	var tests = []struct {
+		description     string
+		events          watch.Events
+		syncErr         error
+		expectedSynced  int
+		expectedRebuilt int
+	}{
+		{
+			description:    "sync matching file",
+			events:         watch.Events{Modified: []string{"index.html"}},
+			expectedSynced: 1,
+		},
+		{
+			description:     "rebuild when a file can't be synced",
+			events:          watch.Events{Modified: []string{"index.html", "main.go"}},
+			expectedRebuilt: 1,
+		},
+		{
+			description:     "rebuild when the sync fails",
+			events:          watch.Events{Modified: []string{"index.html"}},
+			syncErr:         errors.New("container not found"),
+			expectedRebuilt: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			builder := &TestBuilder{}
+			syncer := &TestSyncer{err: test.syncErr}
+			artifacts := []*v1alpha3.Artifact{
+				{
+					ImageName: "image1",
+					Workspace: ".",
+					Sync:      map[string]string{"*.html": "/static"},
+				},
+			}
+
+			runner := &SkaffoldRunner{
+				Builder:  builder,
+				Deployer: &TestDeployer{},
+				Syncer:   syncer,
+				opts:     &config.SkaffoldOptions{},
+				watchFactory: func() watch.Watcher {
+					return &TestWatcher{
+						changedArtifacts: [][]int{{0}},
+						events:           test.events,
+					}
+				},
+			}
+
+			_, err := runner.Dev(context.Background(), ioutil.Discard, artifacts)
+			if err != nil {
+				t.Errorf("Didn't expect an error. Got %s", err)
+			}
+
+			if len(syncer.synced) != test.expectedSynced {
+				t.Errorf("Expected %d sync. Got %d", test.expectedSynced, len(syncer.synced))
+			}
+			// The first run always builds all the artifacts.
+			if builder.builds != 1+test.expectedRebuilt {
+				t.Errorf("Expected %d builds. Got %d", 1+test.expectedRebuilt, builder.builds)
+			}
+		})
+	}
+}
+
+// busyWatcher keeps reporting changes while the debounced builds run,
+// then reports a change of the configuration.
+type busyWatcher struct {
+	changeCallbacks []func(watch.Events)
+}
+
+func (b *busyWatcher) Register(deps func() ([]string, error), onChange func(watch.Events)) error {
+	b.changeCallbacks = append(b.changeCallbacks, onChange)
+	return nil
+}
+
+func (b *busyWatcher) Run(ctx context.Context, pollInterval time.Duration, onChange func() error) error {
+	for i := 0; i < 50; i++ {
+		b.changeCallbacks[0](watch.Events{Modified: []string{"index.html"}})
+		b.changeCallbacks[1](watch.Events{Modified: []string{"main.go"}})
+		if err := onChange(); err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	b.changeCallbacks[len(b.changeCallbacks)-1](watch.Events{Modified: []string{"skaffold.yaml"}})
+	return onChange()
+}
+
+// TestDevDebounceConcurrentChanges is meant to be run with -race.
+func TestDevDebounceConcurrentChanges(t *testing.T) {
+	kubernetes.Client = fakeGetClient
+	defer resetClient()
+
+	runner := &SkaffoldRunner{
+		Builder:  &TestBuilder{},
+		Deployer: &TestDeployer{},
+		Syncer:   &TestSyncer{},
+		opts: &config.SkaffoldOptions{
+			WatchDebounce: time.Millisecond,
+		},
+		watchFactory: func() watch.Watcher { return &busyWatcher{} },
+	}
+	artifacts := []*v1alpha3.Artifact{
+		{
+			ImageName: "image1",
+			Workspace: ".",
+			Sync:      map[string]string{"*.html": "/static"},
+		},
+		{
+			ImageName: "image2",
+			Workspace: ".",
+		},
+	}
+
+	_, err := runner.Dev(context.Background(), ioutil.Discard, artifacts)
+
+	if err != ErrorConfigurationChanged {
+		t.Errorf("Expected the configuration to change. Got %v", err)
+	}
+}
+
+// triggeringWatcher reports a change and then triggers a build
+// through the local HTTP endpoint.
+type triggeringWatcher struct {
+	changeCallbacks []func(watch.Events)
+	port            int
+	built           <-chan struct{}
+	cancel          context.CancelFunc
+}
+
+func (t *triggeringWatcher) Register(deps func() ([]string, error), onChange func(watch.Events)) error {
+	t.changeCallbacks = append(t.changeCallbacks, onChange)
+	return nil
+}
+
+func (t *triggeringWatcher) Run(ctx context.Context, pollInterval time.Duration, onChange func() error) error {
+	defer t.cancel()
+
+	// First run
+	<-t.built
+
+	t.changeCallbacks[0](watch.Events{Modified: []string{"main.go"}})
+	if err := onChange(); err != nil {
+		return err
+	}
+
+	select {
+	case <-t.built:
+		return errors.New("shouldn't rebuild before being triggered")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	resp, err := http.Post(fmt.Sprintf("http://127.0.0.1:%d%s", t.port, TriggerPath), "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	select {
+	case <-t.built:
+		return nil
+	case <-time.After(5 * time.Second):
+		return errors.New("timed out waiting for a rebuild")
+	}
+}
+
+type notifyingBuilder struct {
+	TestBuilder
+	built chan struct{}
+}
+
+func (n *notifyingBuilder) Build(ctx context.Context, w io.Writer, tagger tag.Tagger, artifacts []*v1alpha3.Artifact) ([]build.Artifact, error) {
+	builds, err := n.TestBuilder.Build(ctx, w, tagger, artifacts)
+	n.built <- struct{}{}
+	return builds, err
+}
+
+func TestDevManualTrigger(t *testing.T) {
+	kubernetes.Client = fakeGetClient
+	defer resetClient()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	builder := &notifyingBuilder{built: make(chan struct{}, 2)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runner := &SkaffoldRunner{
+		Builder:  builder,
+		Deployer: &TestDeployer{},
+		Tagger:   &tag.ChecksumTagger{},
+		opts: &config.SkaffoldOptions{
+			Trigger:     "manual",
+			TriggerPort: port,
+		},
+		watchFactory: func() watch.Watcher {
+			return &triggeringWatcher{
+				port:   port,
+				built:  builder.built,
+				cancel: cancel,
+			}
+		},
+	}
+
+	_, err = runner.Dev(ctx, ioutil.Discard, []*v1alpha3.Artifact{{ImageName: "image1"}})
+
+	testutil.CheckError(t, false, err)
+}
+
+func TestControlServerLogs(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runner := &SkaffoldRunner{
+		opts: &config.SkaffoldOptions{TriggerPort: port},
+	}
+	logger := kubernetes.NewLogAggregator(ioutil.Discard, nil, "", nil, kubernetes.LogOptions{})
+	err = runner.startControlServer(ctx, nil, logger)
+	testutil.CheckError(t, false, err)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", port, LogsPath)
+	post := func(body string) int {
+		resp, err := http.Post(url, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, http.StatusOK, post(`{"include":["image:gcr.io/project/web"],"match":"GET"}`))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, http.StatusBadRequest, post(`{"match":"("}`))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, http.StatusBadRequest, post(`{"include":["pod:web"]}`))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var filter kubernetes.LogFilter
+	err = json.NewDecoder(resp.Body).Decode(&filter)
+
+	expected := kubernetes.LogFilter{Include: []string{"image:gcr.io/project/web"}, Match: "GET"}
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, filter)
+
+	// Builds can't be triggered when changes are applied immediately
+	resp, err = http.Post(fmt.Sprintf("http://127.0.0.1:%d%s", port, TriggerPath), "", nil)
+	testutil.CheckError(t, false, err)
+	resp.Body.Close()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestKeepLogFilter(t *testing.T) {
+	runner := &SkaffoldRunner{
+		opts: &config.SkaffoldOptions{LogMatch: "GET"},
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, kubernetes.LogFilter{Match: "GET"}, runner.LogFilter())
+
+	runner.KeepLogFilter(kubernetes.LogFilter{Match: "POST"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, kubernetes.LogFilter{Match: "POST"}, runner.LogFilter())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "GET", runner.opts.LogMatch)
+}
+
+func TestConfigurationFiles(t *testing.T) {
+	runner := &SkaffoldRunner{
+		opts: &config.SkaffoldOptions{ConfigurationFile: "skaffold.yaml"},
+		requires: []v1alpha3.ConfigDependency{
+			{Name: "web", Path: "services/web/skaffold.yaml"},
+			{Name: "worker", Path: "services/worker/skaffold.yaml"},
+		},
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"skaffold.yaml", "services/web/skaffold.yaml", "services/worker/skaffold.yaml"}, runner.configurationFiles())
+}
+
+func TestProfilesLabeller(t *testing.T) {
+	var tests = []struct {
+		description string
+		selected    []string
+		activated   []string
+		expected    map[string]string
+	}{
+		{
+			description: "no profile",
+		},
+		{
+			description: "selected profiles",
+			selected:    []string{"p1", "p2"},
+			expected:    map[string]string{"profiles": "p1,p2"},
+		},
+		{
+			description: "selected and activated profiles",
+			selected:    []string{"p1"},
+			activated:   []string{"p2"},
+			expected:    map[string]string{"profiles": "p1,p2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			labels := profilesLabeller{selected: test.selected, activated: test.activated}.Labels()
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, labels)
+		})
+	}
+}
@@ -25,7 +25,8 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/color"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
 )
 
 // WithTimings creates a deployer that logs the duration of each phase.
@@ -43,7 +44,7 @@ type withTimings struct {
 	deploy.Deployer
 }
 
-func (w withTimings) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]build.Artifact, error) {
+func (w withTimings) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha3.Artifact) ([]build.Artifact, error) {
 	start := time.Now()
 	color.Default.Fprintln(out, "Starting build...")
 
@@ -54,15 +55,20 @@ func (w withTimings) Build(ctx context.Context, out io.Writer, tagger tag.Tagger
 	return bRes, err
 }
 
-func (w withTimings) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact) ([]deploy.Artifact, error) {
+func (w withTimings) Deploy(ctx context.Context, out io.Writer, builds []build.Artifact, labellers []deploy.Labeller) ([]deploy.Artifact, error) {
 	start := time.Now()
 	color.Default.Fprintln(out, "Starting deploy...")
+	event.DeployInProgress()
 
-	dRes, err := w.Deployer.Deploy(ctx, out, builds)
-	if err == nil {
-		color.Default.Fprintln(out, "Deploy complete in", time.Since(start))
+	dRes, err := w.Deployer.Deploy(ctx, out, builds, labellers)
+	if err != nil {
+		event.DeployFailed(err)
+		return dRes, err
 	}
-	return dRes, err
+
+	color.Default.Fprintln(out, "Deploy complete in", time.Since(start))
+	event.DeployComplete()
+	return dRes, nil
 }
 
 func (w withTimings) Labels() map[string]string {
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	gosync "sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TriggerPath is the path of the HTTP endpoint that triggers a rebuild.
+	TriggerPath = "/build"
+
+	// LogsPath is the path of the HTTP endpoint that reads or changes the log filter.
+	LogsPath = "/logs"
+)
+
+// For testing
+var stdin io.Reader = os.Stdin
+
+var (
+	keyPresses     = make(chan struct{})
+	readStdinOnce  gosync.Once
+	triggerTimeout = 100 * time.Millisecond
+)
+
+// stdinTriggers notifies each time the user presses enter.
+// stdin is read only once for the whole session since dev mode
+// is restarted each time the configuration changes.
+func stdinTriggers() <-chan struct{} {
+	readStdinOnce.Do(func() {
+		go func() {
+			scanner := bufio.NewScanner(stdin)
+			for scanner.Scan() {
+				keyPresses <- struct{}{}
+			}
+		}()
+	})
+
+	return keyPresses
+}
+
+// startControlServer serves local HTTP endpoints that control dev mode
+// until the context is cancelled:
+//  - POST /build triggers a rebuild, when changes are accumulated.
+//  - GET /logs returns the log filter and POST /logs changes it.
+func (r *SkaffoldRunner) startControlServer(ctx context.Context, triggers chan<- struct{}, logger *kubernetes.LogAggregator) error {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", r.opts.TriggerPort))
+	if err != nil {
+		return errors.Wrap(err, "listening for control requests")
+	}
+
+	mux := http.NewServeMux()
+	if triggers != nil {
+		mux.HandleFunc(TriggerPath, func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			select {
+			case triggers <- struct{}{}:
+				w.WriteHeader(http.StatusAccepted)
+			case <-time.After(triggerTimeout):
+				// A build is already in progress.
+				w.WriteHeader(http.StatusConflict)
+			}
+		})
+	}
+	mux.HandleFunc(LogsPath, func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var filter kubernetes.LogFilter
+			if err := json.NewDecoder(req.Body).Decode(&filter); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := logger.SetFilter(filter); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logger.Filter())
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			logrus.Warnln("Serving control endpoints:", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var apiVersionRegexp = regexp.MustCompile(`(?m)^(apiVersion:[ \t]*["']?)[^"'\s#]+`)
+
+// UpgradeConfig upgrades the contents of a skaffold.yaml to the latest version.
+// When the upgrade only changes the version, the apiVersion line is rewritten in place
+// so that the comments and the order of the keys are preserved.
+// Otherwise, the upgraded configuration is marshalled from scratch.
+func UpgradeConfig(contents []byte) ([]byte, error) {
+	cfg, err := config.GetConfig(contents, false)
+	if err != nil {
+		return nil, err
+	}
+
+	upgraded, err := RunTransform(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	marshalled, err := yaml.Marshal(upgraded)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling new config")
+	}
+
+	inPlace := apiVersionRegexp.ReplaceAll(contents, []byte("${1}"+upgraded.GetVersion()))
+	if sameConfig(inPlace, marshalled) {
+		return inPlace, nil
+	}
+
+	return marshalled, nil
+}
+
+// sameConfig checks that some contents parse into the given marshalled configuration.
+func sameConfig(contents []byte, marshalled []byte) bool {
+	cfg, err := config.GetConfig(contents, false)
+	if err != nil {
+		return false
+	}
+
+	buf, err := yaml.Marshal(cfg)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(buf, marshalled)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// TestUpgradeExamples checks that upgrading each example from the previous
+// version only changes its apiVersion.
+func TestUpgradeExamples(t *testing.T) {
+	examples, err := filepath.Glob(filepath.Join("..", "..", "..", "examples", "*", "skaffold.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples = append(examples, filepath.Join("..", "..", "..", "examples", "annotated-skaffold.yaml"))
+
+	for _, example := range examples {
+		t.Run(example, func(t *testing.T) {
+			expected, err := ioutil.ReadFile(example)
+			if err != nil {
+				t.Fatal(err)
+			}
+			previous := apiVersionRegexp.ReplaceAll(expected, []byte("${1}"+v1alpha2.Version))
+
+			upgraded, err := UpgradeConfig(previous)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, string(expected), string(upgraded))
+		})
+	}
+}
+
+// TestUpgradeGolden checks the upgrades that change more than the apiVersion
+// against the golden files in testdata.
+func TestUpgradeGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*", "*.input.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			contents, err := ioutil.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := ioutil.ReadFile(strings.TrimSuffix(input, ".input.yaml") + ".golden.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			upgraded, err := UpgradeConfig(contents)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, string(expected), string(upgraded))
+		})
+	}
+}
+
+func TestUpgradeKeepsQuotes(t *testing.T) {
+	upgraded, err := UpgradeConfig([]byte("# comment\napiVersion: \"skaffold/v1alpha2\"\nkind: Config\n"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "# comment\napiVersion: \"skaffold/v1alpha3\"\nkind: Config\n", string(upgraded))
+}
+
+func TestUpgradeUnknownVersion(t *testing.T) {
+	_, err := UpgradeConfig([]byte("apiVersion: skaffold/v0\nkind: Config\n"))
+
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,395 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const Version string = "skaffold/v1alpha3"
+
+type SkaffoldConfig struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+
+	Requires []ConfigDependency `yaml:"requires,omitempty"`
+	Build    BuildConfig        `yaml:"build,omitempty"`
+	Deploy   DeployConfig       `yaml:"deploy,omitempty"`
+	Profiles []Profile          `yaml:"profiles,omitempty"`
+}
+
+// ConfigDependency references another skaffold configuration, whose
+// artifacts and deployers are added to the pipeline.
+type ConfigDependency struct {
+	// Name identifies the module. It defaults to the name of the
+	// folder containing the configuration.
+	Name string `yaml:"name,omitempty"`
+
+	// Path is the path to the configuration file, or to the folder
+	// containing its skaffold.yaml, relative to the including configuration.
+	Path string `yaml:"path"`
+}
+
+func (c *SkaffoldConfig) GetVersion() string {
+	return c.APIVersion
+}
+
+// BuildConfig contains all the configuration for the build steps
+type BuildConfig struct {
+	Artifacts []*Artifact `yaml:"artifacts,omitempty"`
+	TagPolicy TagPolicy   `yaml:"tagPolicy,omitempty"`
+	BuildType `yaml:",inline"`
+}
+
+// TagPolicy contains all the configuration for the tagging step
+type TagPolicy struct {
+	GitTagger         *GitTagger         `yaml:"gitCommit"`
+	ShaTagger         *ShaTagger         `yaml:"sha256"`
+	EnvTemplateTagger *EnvTemplateTagger `yaml:"envTemplate"`
+	DateTimeTagger    *DateTimeTagger    `yaml:"dateTime"`
+}
+
+// ShaTagger contains the configuration for the SHA tagger.
+type ShaTagger struct{}
+
+// GitTagger contains the configuration for the git tagger.
+type GitTagger struct{}
+
+// EnvTemplateTagger contains the configuration for the envTemplate tagger.
+type EnvTemplateTagger struct {
+	Template string `yaml:"template"`
+}
+
+// DateTimeTagger contains the configuration for the DateTime tagger.
+type DateTimeTagger struct {
+	Format   string `yaml:"format,omitempty"`
+	TimeZone string `yaml:"timezone,omitempty"`
+}
+
+// BuildType contains the specific implementation and parameters needed
+// for the build step. Only one field should be populated.
+type BuildType struct {
+	LocalBuild       *LocalBuild       `yaml:"local"`
+	GoogleCloudBuild *GoogleCloudBuild `yaml:"googleCloudBuild"`
+	KanikoBuild      *KanikoBuild      `yaml:"kaniko"`
+}
+
+// LocalBuild contains the fields needed to do a build on the local docker daemon
+// and optionally push to a repository.
+type LocalBuild struct {
+	SkipPush     *bool `yaml:"skipPush"`
+	UseDockerCLI bool  `yaml:"useDockerCLI"`
+	UseBuildkit  bool  `yaml:"useBuildkit"`
+	Concurrency  *int  `yaml:"concurrency"`
+}
+
+// GoogleCloudBuild contains the fields needed to do a remote build on
+// Google Container Builder.
+type GoogleCloudBuild struct {
+	ProjectID   string `yaml:"projectId"`
+	DiskSizeGb  int64  `yaml:"diskSizeGb,omitempty"`
+	MachineType string `yaml:"machineType,omitempty"`
+}
+
+// KanikoBuild contains the fields needed to do a on-cluster build using
+// the kaniko image
+type KanikoBuild struct {
+	GCSBucket      string `yaml:"gcsBucket,omitempty"`
+	PullSecret     string `yaml:"pullSecret,omitempty"`
+	PullSecretName string `yaml:"pullSecretName,omitempty"`
+	Namespace      string `yaml:"namespace,omitempty"`
+	Timeout        string `yaml:"timeout,omitempty"`
+}
+
+// DeployConfig contains all the configuration needed by the deploy steps
+type DeployConfig struct {
+	DeployType  `yaml:",inline"`
+	PortForward []*PortForwardResource `yaml:"portForward,omitempty"`
+
+	// Order lists the deployment methods in the order they were declared in.
+	Order []string `yaml:"-"`
+}
+
+// Deployers returns the names of the configured deployment methods,
+// in the order they were declared in.
+func (c DeployConfig) Deployers() []string {
+	var names []string
+	for _, order := range [][]string{c.Order, {"helm", "kubectl", "kustomize"}} {
+		for _, name := range order {
+			if c.isConfigured(name) && !contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func (c DeployConfig) isConfigured(name string) bool {
+	switch name {
+	case "helm":
+		return c.HelmDeploy != nil
+	case "kubectl":
+		return c.KubectlDeploy != nil
+	case "kustomize":
+		return c.KustomizeDeploy != nil
+	default:
+		return false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// PortForwardResource describes a resource whose port should be forwarded
+// to the host in dev mode.
+type PortForwardResource struct {
+	Type      string `yaml:"resourceType"`
+	Name      string `yaml:"resourceName"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Port      int    `yaml:"port"`
+	LocalPort int    `yaml:"localPort,omitempty"`
+}
+
+// DeployType contains the specific implementation and parameters needed
+// for the deploy step. When several fields are populated, the deployers
+// run in the order they are declared in.
+type DeployType struct {
+	HelmDeploy      *HelmDeploy      `yaml:"helm"`
+	KubectlDeploy   *KubectlDeploy   `yaml:"kubectl"`
+	KustomizeDeploy *KustomizeDeploy `yaml:"kustomize"`
+}
+
+// KubectlDeploy contains the configuration needed for deploying with `kubectl apply`
+type KubectlDeploy struct {
+	Manifests       []string     `yaml:"manifests,omitempty"`
+	RemoteManifests []string     `yaml:"remoteManifests,omitempty"`
+	Flags           KubectlFlags `yaml:"flags,omitempty"`
+}
+
+// KubectlFlags describes additional options flags that are passed on the command
+// line to kubectl either on every command (Global), on creations (Apply)
+// or deletions (Delete).
+type KubectlFlags struct {
+	Global []string `yaml:"global,omitempty"`
+	Apply  []string `yaml:"apply,omitempty"`
+	Delete []string `yaml:"delete,omitempty"`
+}
+
+// HelmDeploy contains the configuration needed for deploying with helm
+type HelmDeploy struct {
+	Releases []HelmRelease `yaml:"releases,omitempty"`
+}
+
+type KustomizeDeploy struct {
+	KustomizePath string       `yaml:"kustomizePath,omitempty"`
+	Flags         KubectlFlags `yaml:"flags,omitempty"`
+}
+
+type HelmRelease struct {
+	Name              string                 `yaml:"name"`
+	ChartPath         string                 `yaml:"chartPath"`
+	ValuesFilePath    string                 `yaml:"valuesFilePath"`
+	Values            map[string]string      `yaml:"values,omitempty"`
+	Namespace         string                 `yaml:"namespace"`
+	Version           string                 `yaml:"version"`
+	SetValues         map[string]string      `yaml:"setValues"`
+	SetValueTemplates map[string]string      `yaml:"setValueTemplates"`
+	Wait              bool                   `yaml:"wait"`
+	Overrides         map[string]interface{} `yaml:"overrides"`
+	Packaged          *HelmPackaged          `yaml:"packaged"`
+	ImageStrategy     HelmImageStrategy      `yaml:"imageStrategy"`
+}
+
+// HelmPackaged represents parameters for packaging helm chart.
+type HelmPackaged struct {
+	// Version sets the version on the chart to this semver version.
+	Version string `yaml:"version"`
+
+	// AppVersion set the appVersion on the chart to this version
+	AppVersion string `yaml:"appVersion"`
+}
+
+type HelmImageStrategy struct {
+	HelmImageConfig `yaml:",inline"`
+}
+
+type HelmImageConfig struct {
+	HelmFQNConfig        *HelmFQNConfig        `yaml:"fqn"`
+	HelmConventionConfig *HelmConventionConfig `yaml:"helm"`
+}
+
+// HelmFQNConfig represents image config to use the FullyQualifiedImageName as param to set
+type HelmFQNConfig struct {
+	Property string `yaml:"property"`
+}
+
+// HelmConventionConfig represents image config in the syntax of image.repository and image.tag
+type HelmConventionConfig struct {
+}
+
+// Artifact represents items that need to be built, along with the context in which
+// they should be built.
+type Artifact struct {
+	ImageName    string            `yaml:"imageName"`
+	Workspace    string            `yaml:"workspace,omitempty"`
+	Sync         map[string]string `yaml:"sync,omitempty"`
+	ArtifactType `yaml:",inline"`
+}
+
+// Profile is additional configuration that overrides default
+// configuration when it is activated.
+type Profile struct {
+	Name       string       `yaml:"name"`
+	Activation []Activation `yaml:"activation,omitempty"`
+	Build      BuildConfig  `yaml:"build,omitempty"`
+	Deploy     DeployConfig `yaml:"deploy,omitempty"`
+	Patches    []JSONPatch  `yaml:"patches,omitempty"`
+}
+
+// JSONPatch is a JSON Patch operation that modifies the configuration,
+// as described by https://tools.ietf.org/html/rfc6902.
+// The supported operations are add, replace and remove.
+type JSONPatch struct {
+	Op    string      `yaml:"op"`
+	Path  string      `yaml:"path"`
+	Value interface{} `yaml:"value,omitempty"`
+}
+
+// Activation lists conditions that activate a profile automatically.
+// A profile is activated when all the conditions of one of its
+// activations are met.
+type Activation struct {
+	// Env is either the name of an environment variable that must be set,
+	// or NAME=value where value is a regular expression that must match
+	// the whole value of the variable.
+	Env string `yaml:"env,omitempty"`
+
+	// KubeContext is a regular expression that must match the whole
+	// name of the current kubectl context.
+	KubeContext string `yaml:"kubeContext,omitempty"`
+
+	// Command is the skaffold command that must be running, like dev, run or build.
+	Command string `yaml:"command,omitempty"`
+}
+
+type ArtifactType struct {
+	DockerArtifact    *DockerArtifact    `yaml:"docker"`
+	BazelArtifact     *BazelArtifact     `yaml:"bazel"`
+	JibMavenArtifact  *JibMavenArtifact  `yaml:"jibMaven"`
+	JibGradleArtifact *JibGradleArtifact `yaml:"jibGradle"`
+	CustomArtifact    *CustomArtifact    `yaml:"custom"`
+}
+
+type DockerArtifact struct {
+	DockerfilePath string             `yaml:"dockerfilePath,omitempty"`
+	BuildArgs      map[string]*string `yaml:"buildArgs,omitempty"`
+	CacheFrom      []string           `yaml:"cacheFrom,omitempty"`
+}
+
+type BazelArtifact struct {
+	BuildTarget string `yaml:"target"`
+}
+
+// JibMavenArtifact builds images with Jib's Maven plugin.
+type JibMavenArtifact struct {
+	// Module selects which module to build in a multi-module project.
+	Module string `yaml:"module,omitempty"`
+
+	// Profile is a Maven profile to activate.
+	Profile string `yaml:"profile,omitempty"`
+}
+
+// JibGradleArtifact builds images with Jib's Gradle plugin.
+type JibGradleArtifact struct {
+	// Project selects which sub-project to build in a multi-project build.
+	Project string `yaml:"project,omitempty"`
+}
+
+// CustomArtifact builds images with a user provided script.
+type CustomArtifact struct {
+	// BuildCommand is run in the workspace to build, and optionally push, the image.
+	BuildCommand string `yaml:"buildCommand"`
+
+	// Dependencies are the files to watch. Defaults to the whole workspace.
+	Dependencies *CustomDependencies `yaml:"dependencies,omitempty"`
+}
+
+// CustomDependencies lists the files a custom artifact depends on,
+// either as paths and glob patterns or as the output of a command.
+type CustomDependencies struct {
+	Paths   []string `yaml:"paths,omitempty"`
+	Command string   `yaml:"command,omitempty"`
+}
+
+// Parse reads a SkaffoldConfig from yaml.
+func (c *SkaffoldConfig) Parse(contents []byte, useDefaults bool) error {
+	if err := yaml.UnmarshalStrict(contents, c); err != nil {
+		return err
+	}
+
+	if err := c.setDeployOrder(contents); err != nil {
+		return errors.Wrap(err, "reading the order of the deployment methods")
+	}
+
+	if useDefaults {
+		if err := c.setDefaultValues(); err != nil {
+			return errors.Wrap(err, "applying default values")
+		}
+	}
+
+	return nil
+}
+
+// setDeployOrder records the order in which the deployment methods
+// of the configuration and of its profiles are declared.
+func (c *SkaffoldConfig) setDeployOrder(contents []byte) error {
+	var declared struct {
+		Deploy   yaml.MapSlice `yaml:"deploy"`
+		Profiles []struct {
+			Deploy yaml.MapSlice `yaml:"deploy"`
+		} `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(contents, &declared); err != nil {
+		return err
+	}
+
+	c.Deploy.Order = keys(declared.Deploy)
+	for i := range c.Profiles {
+		if i < len(declared.Profiles) {
+			c.Profiles[i].Deploy.Order = keys(declared.Profiles[i].Deploy)
+		}
+	}
+
+	return nil
+}
+
+func keys(fields yaml.MapSlice) []string {
+	var keys []string
+	for _, field := range fields {
+		if key, ok := field.Key.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
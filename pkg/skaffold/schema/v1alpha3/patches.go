@@ -0,0 +1,165 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// applyPatches applies the JSON patches of a profile to the configuration.
+func applyPatches(config *SkaffoldConfig, profile Profile) error {
+	buf, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+
+	for _, patch := range profile.Patches {
+		doc, err = patch.apply(doc)
+		if err != nil {
+			return errors.Wrapf(err, "applying patch %s %s of profile %s", patch.Op, patch.Path, profile.Name)
+		}
+	}
+
+	buf, err = yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	patched := SkaffoldConfig{}
+	if err := yaml.UnmarshalStrict(buf, &patched); err != nil {
+		return errors.Wrapf(err, "invalid configuration after applying the patches of profile %s", profile.Name)
+	}
+
+	patched.Deploy.Order = config.Deploy.Order
+	*config = patched
+	return nil
+}
+
+func (p JSONPatch) apply(doc interface{}) (interface{}, error) {
+	switch p.Op {
+	case "add", "replace", "remove":
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", p.Op)
+	}
+
+	if p.Path == "" {
+		if p.Op == "remove" {
+			return nil, errors.New("can't remove the whole configuration")
+		}
+		return p.Value, nil
+	}
+	if !strings.HasPrefix(p.Path, "/") {
+		return nil, fmt.Errorf("path %s should start with /", p.Path)
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(p.Path[1:], "/") {
+		token = strings.Replace(token, "~1", "/", -1)
+		token = strings.Replace(token, "~0", "~", -1)
+		tokens = append(tokens, token)
+	}
+
+	return p.applyAt(doc, "", tokens)
+}
+
+// applyAt applies the patch to the node found at parentPath,
+// given the remaining tokens of the patch's path.
+func (p JSONPatch) applyAt(node interface{}, parentPath string, tokens []string) (interface{}, error) {
+	key := tokens[0]
+	path := parentPath + "/" + key
+	last := len(tokens) == 1
+
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		child, present := n[key]
+		if last {
+			if !present && p.Op != "add" {
+				return nil, fmt.Errorf("no value at %s", path)
+			}
+			if p.Op == "remove" {
+				delete(n, key)
+			} else {
+				n[key] = p.Value
+			}
+			return n, nil
+		}
+
+		if !present || child == nil {
+			return nil, fmt.Errorf("no value at %s", path)
+		}
+		patched, err := p.applyAt(child, path, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		n[key] = patched
+		return n, nil
+
+	case []interface{}:
+		if last && p.Op == "add" && key == "-" {
+			return append(n, p.Value), nil
+		}
+
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid index at %s", path)
+		}
+		if index > len(n) || (index == len(n) && !(last && p.Op == "add")) {
+			return nil, fmt.Errorf("index out of range at %s", path)
+		}
+
+		if !last {
+			patched, err := p.applyAt(n[index], path, tokens[1:])
+			if err != nil {
+				return nil, err
+			}
+			n[index] = patched
+			return n, nil
+		}
+
+		switch p.Op {
+		case "add":
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = p.Value
+		case "replace":
+			n[index] = p.Value
+		case "remove":
+			n = append(n[:index], n[index+1:]...)
+		}
+		return n, nil
+
+	default:
+		return nil, fmt.Errorf("no object or array at %s", parentPathOrRoot(parentPath))
+	}
+}
+
+func parentPathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestJSONPatch(t *testing.T) {
+	var tests = []struct {
+		description string
+		patch       JSONPatch
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "add to object",
+			patch:       JSONPatch{Op: "add", Path: "/deploy/namespace", Value: "prod"},
+			expected:    "list: [a, b]\ndeploy: {kubectl: {}, namespace: prod}\nslash/key: value\n",
+		},
+		{
+			description: "insert in array",
+			patch:       JSONPatch{Op: "add", Path: "/list/1", Value: "c"},
+			expected:    "list: [a, c, b]\ndeploy: {kubectl: {}}\nslash/key: value\n",
+		},
+		{
+			description: "append to array",
+			patch:       JSONPatch{Op: "add", Path: "/list/-", Value: "c"},
+			expected:    "list: [a, b, c]\ndeploy: {kubectl: {}}\nslash/key: value\n",
+		},
+		{
+			description: "replace escaped key",
+			patch:       JSONPatch{Op: "replace", Path: "/slash~1key", Value: "other"},
+			expected:    "list: [a, b]\ndeploy: {kubectl: {}}\nslash/key: other\n",
+		},
+		{
+			description: "remove from array",
+			patch:       JSONPatch{Op: "remove", Path: "/list/0"},
+			expected:    "list: [b]\ndeploy: {kubectl: {}}\nslash/key: value\n",
+		},
+		{
+			description: "replace missing key",
+			patch:       JSONPatch{Op: "replace", Path: "/deploy/helm", Value: "other"},
+			shouldErr:   true,
+		},
+		{
+			description: "remove out of range",
+			patch:       JSONPatch{Op: "remove", Path: "/list/2"},
+			shouldErr:   true,
+		},
+		{
+			description: "path through scalar",
+			patch:       JSONPatch{Op: "add", Path: "/slash~1key/name", Value: "other"},
+			shouldErr:   true,
+		},
+		{
+			description: "unsupported operation",
+			patch:       JSONPatch{Op: "move", Path: "/list/0"},
+			shouldErr:   true,
+		},
+		{
+			description: "relative path",
+			patch:       JSONPatch{Op: "remove", Path: "list/0"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var doc interface{}
+			if err := yaml.Unmarshal([]byte("list: [a, b]\ndeploy: {kubectl: {}}\nslash/key: value\n"), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			patched, err := test.patch.apply(doc)
+			testutil.CheckError(t, test.shouldErr, err)
+			if test.shouldErr {
+				return
+			}
+
+			var expected interface{}
+			if err := yaml.Unmarshal([]byte(test.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, nil, expected, patched)
+		})
+	}
+}
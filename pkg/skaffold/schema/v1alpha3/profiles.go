@@ -0,0 +1,166 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	kubectx "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/context"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ApplyProfiles returns configuration modified by the application
+// of a list of profiles.
+func (c *SkaffoldConfig) ApplyProfiles(profiles []string) error {
+	var err error
+
+	byName := profilesByName(c.Profiles)
+	for _, name := range profiles {
+		profile, present := byName[name]
+		if !present {
+			return fmt.Errorf("couldn't find profile %s", name)
+		}
+
+		err = applyProfile(c, profile)
+		if err != nil {
+			return errors.Wrapf(err, "applying profile %s", name)
+		}
+	}
+
+	c.Profiles = nil
+	if err := c.setDefaultValues(); err != nil {
+		return errors.Wrap(err, "applying default values")
+	}
+
+	return nil
+}
+
+// For testing
+var currentKubeContext = kubectx.CurrentContext
+
+// ActivatedProfiles returns the names of the profiles that are activated
+// automatically for a given skaffold command. When the current kubectl
+// context can't be found, the kubeContext conditions don't match.
+func (c *SkaffoldConfig) ActivatedProfiles(command string) ([]string, error) {
+	var kubeContext *string
+	var kubeContextRead bool
+
+	var activated []string
+	for _, profile := range c.Profiles {
+		for _, activation := range profile.Activation {
+			if activation.KubeContext != "" && !kubeContextRead {
+				kubeContextRead = true
+				current, err := currentKubeContext()
+				if err != nil {
+					logrus.Warnf("Ignoring the kubeContext activations: unable to get the current kubectl context: %s", err)
+				} else {
+					kubeContext = &current
+				}
+			}
+
+			matches, err := activation.matches(command, kubeContext)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid activation for profile %s", profile.Name)
+			}
+			if matches {
+				logrus.Infof("Profile %s is activated by %s", profile.Name, activation)
+				activated = append(activated, profile.Name)
+				break
+			}
+		}
+	}
+
+	return activated, nil
+}
+
+func (a Activation) matches(command string, kubeContext *string) (bool, error) {
+	if a.Command != "" && a.Command != command {
+		return false, nil
+	}
+
+	if a.KubeContext != "" {
+		if kubeContext == nil {
+			return false, nil
+		}
+		matches, err := matchesWhole(a.KubeContext, *kubeContext)
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+
+	if a.Env != "" {
+		kv := strings.SplitN(a.Env, "=", 2)
+		value, present := os.LookupEnv(kv[0])
+		if !present {
+			return false, nil
+		}
+		if len(kv) == 2 {
+			return matchesWhole(kv[1], value)
+		}
+	}
+
+	return true, nil
+}
+
+func (a Activation) String() string {
+	var conditions []string
+	if a.Env != "" {
+		conditions = append(conditions, "env "+a.Env)
+	}
+	if a.KubeContext != "" {
+		conditions = append(conditions, "kubeContext "+a.KubeContext)
+	}
+	if a.Command != "" {
+		conditions = append(conditions, "command "+a.Command)
+	}
+	return strings.Join(conditions, ", ")
+}
+
+func matchesWhole(pattern, value string) (bool, error) {
+	return regexp.MatchString("^(?:"+pattern+")$", value)
+}
+
+func applyProfile(config *SkaffoldConfig, profile Profile) error {
+	logrus.Infof("Applying profile: %s", profile.Name)
+
+	buf, err := yaml.Marshal(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(buf, config); err != nil {
+		return err
+	}
+	if deployers := profile.Deploy.Deployers(); len(deployers) > 0 {
+		config.Deploy.Order = deployers
+	}
+
+	return applyPatches(config, profile)
+}
+
+func profilesByName(profiles []Profile) map[string]Profile {
+	byName := make(map[string]Profile)
+	for _, profile := range profiles {
+		byName[profile.Name] = profile
+	}
+	return byName
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestActivatedProfiles(t *testing.T) {
+	var tests = []struct {
+		description string
+		command     string
+		kubeContext string
+		contextErr  error
+		activation  []Activation
+		shouldErr   bool
+		expected    []string
+	}{
+		{
+			description: "no activation",
+		},
+		{
+			description: "command",
+			command:     "dev",
+			activation:  []Activation{{Command: "dev"}},
+			expected:    []string{"profile"},
+		},
+		{
+			description: "other command",
+			command:     "run",
+			activation:  []Activation{{Command: "dev"}},
+		},
+		{
+			description: "kube context",
+			kubeContext: "gke_project_staging",
+			activation:  []Activation{{KubeContext: "gke_.*_staging"}},
+			expected:    []string{"profile"},
+		},
+		{
+			description: "partial kube context",
+			kubeContext: "gke_project_staging_old",
+			activation:  []Activation{{KubeContext: "gke_.*_staging"}},
+		},
+		{
+			description: "env variable set",
+			activation:  []Activation{{Env: "SKAFFOLD_TEST_ENV"}},
+			expected:    []string{"profile"},
+		},
+		{
+			description: "env variable not set",
+			activation:  []Activation{{Env: "SKAFFOLD_TEST_UNKNOWN_ENV"}},
+		},
+		{
+			description: "env variable value",
+			activation:  []Activation{{Env: "SKAFFOLD_TEST_ENV=stag.*"}},
+			expected:    []string{"profile"},
+		},
+		{
+			description: "other env variable value",
+			activation:  []Activation{{Env: "SKAFFOLD_TEST_ENV=prod"}},
+		},
+		{
+			description: "all conditions of an activation",
+			command:     "run",
+			kubeContext: "minikube",
+			activation:  []Activation{{Command: "run", KubeContext: "docker-for-desktop", Env: "SKAFFOLD_TEST_ENV"}},
+		},
+		{
+			description: "any activation",
+			command:     "run",
+			kubeContext: "minikube",
+			activation:  []Activation{{Command: "dev"}, {KubeContext: "minikube"}},
+			expected:    []string{"profile"},
+		},
+		{
+			description: "invalid regexp",
+			activation:  []Activation{{Env: "SKAFFOLD_TEST_ENV=("}},
+			shouldErr:   true,
+		},
+		{
+			description: "kube context error",
+			contextErr:  errors.New("no kubeconfig"),
+			activation:  []Activation{{KubeContext: "minikube"}},
+		},
+		{
+			description: "other activation with a kube context error",
+			command:     "dev",
+			contextErr:  errors.New("no kubeconfig"),
+			activation:  []Activation{{KubeContext: "minikube"}, {Command: "dev"}},
+			expected:    []string{"profile"},
+		},
+	}
+
+	os.Setenv("SKAFFOLD_TEST_ENV", "staging")
+	defer os.Unsetenv("SKAFFOLD_TEST_ENV")
+	defer func(c func() (string, error)) { currentKubeContext = c }(currentKubeContext)
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			currentKubeContext = func() (string, error) { return test.kubeContext, test.contextErr }
+			cfg := &SkaffoldConfig{
+				Profiles: []Profile{
+					{Name: "profile", Activation: test.activation},
+					{Name: "other"},
+				},
+			}
+
+			activated, err := cfg.ActivatedProfiles(test.command)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, activated)
+		})
+	}
+}
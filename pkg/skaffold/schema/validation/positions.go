@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// positions maps the paths of the keys and the items of a yaml document,
+// like `build.artifacts[0].imageName`, to the lines they're found at.
+type positions map[string]int
+
+type frame struct {
+	indent int
+	path   string
+	item   bool
+}
+
+// indexLines finds the line of each key and each item in a block style yaml document.
+// Flow style collections and the contents of multi-line strings are not indexed.
+func indexLines(contents []byte) positions {
+	lines := positions{}
+	items := map[string]int{}
+	stack := []frame{{indent: -1}}
+
+	for i, line := range strings.Split(string(contents), "\n") {
+		rest := strings.TrimLeft(line, " ")
+		if rest == "" || strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, "---") {
+			continue
+		}
+		indent := len(line) - len(rest)
+
+		for strings.HasPrefix(rest, "-") && (len(rest) == 1 || rest[1] == ' ') {
+			stack = pop(stack, func(f frame) bool { return f.indent > indent || (f.item && f.indent == indent) })
+			parent := stack[len(stack)-1].path
+			path := fmt.Sprintf("%s[%d]", parent, items[parent])
+			items[parent]++
+			lines[path] = i + 1
+			stack = append(stack, frame{indent: indent, path: path, item: true})
+
+			trimmed := strings.TrimLeft(rest[1:], " ")
+			indent += len(rest) - len(trimmed)
+			rest = trimmed
+		}
+
+		colon := strings.Index(rest, ":")
+		if colon <= 0 || strings.HasPrefix(rest, "{") || strings.HasPrefix(rest, "[") {
+			continue
+		}
+		if colon < len(rest)-1 && rest[colon+1] != ' ' {
+			continue
+		}
+		key := strings.Trim(rest[:colon], `"'`)
+
+		stack = pop(stack, func(f frame) bool { return f.indent >= indent })
+		path := key
+		if parent := stack[len(stack)-1].path; parent != "" {
+			path = parent + "." + key
+		}
+		if _, found := lines[path]; !found {
+			lines[path] = i + 1
+		}
+		stack = append(stack, frame{indent: indent, path: path})
+	}
+
+	return lines
+}
+
+func pop(stack []frame, shouldPop func(f frame) bool) []frame {
+	for len(stack) > 1 && shouldPop(stack[len(stack)-1]) {
+		stack = stack[:len(stack)-1]
+	}
+	return stack
+}
+
+// line returns the line of a path or, if it can't be found,
+// the line of its closest parent. It returns 0 for unknown paths.
+func (p positions) line(path string) int {
+	for path != "" {
+		if line, found := p[path]; found {
+			return line
+		}
+
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			return 0
+		}
+		path = path[:cut]
+	}
+
+	return 0
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const indexed = `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  # comment
+  artifacts:
+  - imageName: gcr.io/project/web
+    workspace: web
+  -   imageName: "gcr.io/project/worker"
+      docker:
+        buildArgs:
+          key: value
+  tagPolicy:
+    sha256: {}
+deploy:
+  kubectl:
+    manifests:
+      - k8s/*.yaml
+      - gcr.io/project/web:latest
+  helm:
+    releases:
+    - name: web
+      values:
+        image: gcr.io/project/web
+`
+
+func TestIndexLines(t *testing.T) {
+	expected := positions{
+		"apiVersion":                              1,
+		"kind":                                    2,
+		"build":                                   3,
+		"build.artifacts":                         5,
+		"build.artifacts[0]":                      6,
+		"build.artifacts[0].imageName":            6,
+		"build.artifacts[0].workspace":            7,
+		"build.artifacts[1]":                      8,
+		"build.artifacts[1].imageName":            8,
+		"build.artifacts[1].docker":               9,
+		"build.artifacts[1].docker.buildArgs":     10,
+		"build.artifacts[1].docker.buildArgs.key": 11,
+		"build.tagPolicy":                         12,
+		"build.tagPolicy.sha256":                  13,
+		"deploy":                                  14,
+		"deploy.kubectl":                          15,
+		"deploy.kubectl.manifests":                16,
+		"deploy.kubectl.manifests[0]":             17,
+		"deploy.kubectl.manifests[1]":             18,
+		"deploy.helm":                             19,
+		"deploy.helm.releases":                    20,
+		"deploy.helm.releases[0]":                 21,
+		"deploy.helm.releases[0].name":            21,
+		"deploy.helm.releases[0].values":          22,
+		"deploy.helm.releases[0].values.image":    23,
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, indexLines([]byte(indexed)))
+}
+
+func TestLine(t *testing.T) {
+	lines := indexLines([]byte(indexed))
+
+	var tests = []struct {
+		path     string
+		expected int
+	}{
+		{path: "build.artifacts[1].docker", expected: 9},
+		{path: "build.artifacts[1].docker.dockerfilePath", expected: 9},
+		{path: "build.artifacts[0].bazel.target", expected: 6},
+		{path: "build.kaniko", expected: 3},
+		{path: "profiles[0].build", expected: 0},
+		{path: "", expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, lines.line(test.path))
+		})
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Problem is an error found in a configuration file.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// Validate checks a configuration file and the configurations it requires
+// and returns all the problems it finds.
+func Validate(filename string) ([]Problem, error) {
+	return validateFile(filename, map[string]bool{})
+}
+
+func validateFile(filename string, visited map[string]bool) ([]Problem, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving %s", filename)
+	}
+	if visited[abs] {
+		return nil, nil
+	}
+	visited[abs] = true
+
+	contents, err := util.ReadConfiguration(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", filename)
+	}
+
+	v := &validator{
+		file:      filename,
+		lines:     indexLines(contents),
+		localFile: filename != "-" && !strings.Contains(filename, "://"),
+	}
+
+	cfg, ok := v.parse(contents)
+	if !ok {
+		return v.problems, nil
+	}
+	v.validate(cfg, filepath.Dir(filename))
+
+	problems := v.problems
+	if !v.localFile {
+		return problems, nil
+	}
+	for _, required := range cfg.Requires {
+		path := filepath.Join(filepath.Dir(filename), required.Path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, "skaffold.yaml")
+		}
+
+		found, err := validateFile(path, visited)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+
+	return problems, nil
+}
+
+type validator struct {
+	file      string
+	lines     positions
+	localFile bool
+	problems  []Problem
+}
+
+// report records a problem at the position of a yaml path.
+func (v *validator) report(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    v.lines.line(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+var yamlErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// parse reads a configuration at the latest version. Each of the
+// unknown or misplaced keys is reported at its own line.
+func (v *validator) parse(contents []byte) (*v1alpha3.SkaffoldConfig, bool) {
+	cfg, err := config.GetConfig(contents, false)
+	if err == nil {
+		latest, ok := cfg.(*v1alpha3.SkaffoldConfig)
+		if !ok {
+			v.report("apiVersion", "version %s is outdated, run `skaffold fix` to upgrade to %s", cfg.GetVersion(), v1alpha3.Version)
+			return nil, false
+		}
+		return latest, true
+	}
+
+	typeErr, ok := errors.Cause(err).(*yaml.TypeError)
+	if !ok {
+		v.report("", "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return nil, false
+	}
+
+	for _, msg := range typeErr.Errors {
+		problem := Problem{File: v.file, Message: msg}
+		if matches := yamlErrorLine.FindStringSubmatch(msg); matches != nil {
+			problem.Line, _ = strconv.Atoi(matches[1])
+			problem.Message = matches[2]
+		}
+		v.problems = append(v.problems, problem)
+	}
+	return nil, false
+}
+
+func (v *validator) validate(cfg *v1alpha3.SkaffoldConfig, dir string) {
+	v.validateBuild("build", cfg.Build, dir)
+	v.validateDeploy("deploy", cfg.Deploy, cfg.Build.Artifacts, dir)
+	if cfg.Deploy.DeployType == (v1alpha3.DeployType{}) && len(cfg.Requires) == 0 {
+		v.report("deploy", "no deployer configured, set one of helm, kubectl or kustomize")
+	}
+
+	for i, profile := range cfg.Profiles {
+		path := fmt.Sprintf("profiles[%d]", i)
+
+		v.validateBuild(path+".build", profile.Build, dir)
+
+		artifacts := profile.Build.Artifacts
+		if len(artifacts) == 0 {
+			artifacts = cfg.Build.Artifacts
+		}
+		v.validateDeploy(path+".deploy", profile.Deploy, artifacts, dir)
+	}
+}
+
+func (v *validator) validateBuild(path string, build v1alpha3.BuildConfig, dir string) {
+	v.exactlyOne(path, "build type", []field{
+		{"local", build.LocalBuild != nil},
+		{"googleCloudBuild", build.GoogleCloudBuild != nil},
+		{"kaniko", build.KanikoBuild != nil},
+	})
+	v.exactlyOne(path+".tagPolicy", "tag policy", []field{
+		{"gitCommit", build.TagPolicy.GitTagger != nil},
+		{"sha256", build.TagPolicy.ShaTagger != nil},
+		{"envTemplate", build.TagPolicy.EnvTemplateTagger != nil},
+		{"dateTime", build.TagPolicy.DateTimeTagger != nil},
+	})
+
+	remote := ""
+	switch {
+	case build.KanikoBuild != nil:
+		remote = "kaniko"
+	case build.GoogleCloudBuild != nil:
+		remote = "googleCloudBuild"
+	}
+
+	seen := map[string]bool{}
+	for i, a := range build.Artifacts {
+		artifactPath := fmt.Sprintf("%s.artifacts[%d]", path, i)
+
+		switch {
+		case a.ImageName == "":
+			v.report(artifactPath, "artifact has no imageName")
+		case seen[a.ImageName]:
+			v.report(artifactPath+".imageName", "image %s is built by several artifacts", a.ImageName)
+		}
+		seen[a.ImageName] = true
+
+		v.exactlyOne(artifactPath, "artifact type", []field{
+			{"docker", a.DockerArtifact != nil},
+			{"bazel", a.BazelArtifact != nil},
+			{"jibMaven", a.JibMavenArtifact != nil},
+			{"jibGradle", a.JibGradleArtifact != nil},
+			{"custom", a.CustomArtifact != nil},
+		})
+		if remote != "" && a.ArtifactType != (v1alpha3.ArtifactType{}) && a.DockerArtifact == nil {
+			v.report(artifactPath, "%s can only build docker artifacts", remote)
+		}
+
+		v.validateWorkspace(artifactPath, a, dir)
+	}
+}
+
+// validateWorkspace checks that the workspace and the Dockerfile of an artifact exist.
+func (v *validator) validateWorkspace(path string, a *v1alpha3.Artifact, dir string) {
+	if !v.localFile {
+		return
+	}
+
+	workspace := filepath.Join(dir, a.Workspace)
+	if info, err := os.Stat(workspace); err != nil || !info.IsDir() {
+		v.report(path+".workspace", "workspace %s doesn't exist", workspace)
+		return
+	}
+
+	if a.DockerArtifact == nil && a.ArtifactType != (v1alpha3.ArtifactType{}) {
+		return
+	}
+
+	dockerfile := "Dockerfile"
+	if a.DockerArtifact != nil && a.DockerArtifact.DockerfilePath != "" {
+		dockerfile = a.DockerArtifact.DockerfilePath
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(workspace, dockerfile)
+	}
+	if _, err := os.Stat(dockerfile); err != nil {
+		v.report(path+".docker.dockerfilePath", "Dockerfile %s doesn't exist", dockerfile)
+	}
+}
+
+func (v *validator) validateDeploy(path string, deploy v1alpha3.DeployConfig, artifacts []*v1alpha3.Artifact, dir string) {
+	images := map[string]bool{}
+	for _, a := range artifacts {
+		images[a.ImageName] = true
+	}
+
+	if helm := deploy.HelmDeploy; helm != nil {
+		for i, release := range helm.Releases {
+			releasePath := fmt.Sprintf("%s.helm.releases[%d]", path, i)
+			var keys []string
+			for key := range release.Values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if image := release.Values[key]; !images[image] {
+					v.report(releasePath+".values."+key, "image %s isn't built by any artifact", image)
+				}
+			}
+		}
+	}
+
+	if deploy.KubectlDeploy != nil && deploy.HelmDeploy == nil && deploy.KustomizeDeploy == nil {
+		v.validateManifestImages(path+".kubectl", deploy.KubectlDeploy, artifacts, dir)
+	}
+}
+
+// validateManifestImages checks that the images that are built
+// are referenced by the manifests deployed with kubectl.
+func (v *validator) validateManifestImages(path string, kubectl *v1alpha3.KubectlDeploy, artifacts []*v1alpha3.Artifact, dir string) {
+	if !v.localFile || len(kubectl.RemoteManifests) > 0 {
+		return
+	}
+
+	manifests := kubectl.Manifests
+	if len(manifests) == 0 {
+		manifests = constants.DefaultKubectlManifests
+	}
+	files, err := util.ExpandPathsGlob(dir, manifests)
+	if err != nil {
+		v.report(path+".manifests", "%s", err)
+		return
+	}
+
+	deployed := map[string]bool{}
+	for _, file := range files {
+		images, err := deploy.ParseImagesFromKubernetesYaml(file)
+		if err != nil {
+			v.report(path+".manifests", "reading %s: %s", file, err)
+			continue
+		}
+		for _, image := range images {
+			deployed[image] = true
+		}
+	}
+
+	for _, a := range artifacts {
+		if a.ImageName != "" && !deployed[a.ImageName] {
+			v.report(path+".manifests", "image %s isn't referenced by any manifest", a.ImageName)
+		}
+	}
+}
+
+// field is a field of a union type, like the build type or the artifact type.
+type field struct {
+	name string
+	set  bool
+}
+
+// exactlyOne reports a problem when more than one of the fields are set.
+func (v *validator) exactlyOne(path string, kind string, fields []field) {
+	var set []string
+	for _, f := range fields {
+		if f.set {
+			set = append(set, f.name)
+		}
+	}
+
+	if len(set) > 1 {
+		v.report(path+"."+set[1], "only one %s can be set, found %s", kind, strings.Join(set, " and "))
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const pod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: gcr.io/project/web
+`
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		description string
+		config      string
+		expected    []string
+	}{
+		{
+			description: "valid",
+			config: `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/project/web
+    workspace: web
+deploy:
+  kubectl:
+    manifests:
+    - k8s/*.yaml
+`,
+		},
+		{
+			description: "unknown keys",
+			config: `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - image: gcr.io/project/web
+    workspace: web
+deploy:
+  kubctl: {}
+`,
+			expected: []string{
+				"skaffold.yaml:5: field image not found in type v1alpha3.Artifact",
+				"skaffold.yaml:8: field kubctl not found in type v1alpha3.DeployConfig",
+			},
+		},
+		{
+			description: "outdated version",
+			config:      "apiVersion: skaffold/v1alpha2\nkind: Config\n",
+			expected:    []string{"skaffold.yaml:1: version skaffold/v1alpha2 is outdated, run `skaffold fix` to upgrade to skaffold/v1alpha3"},
+		},
+		{
+			description: "semantic problems",
+			config: `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/project/web
+    workspace: web
+  - imageName: gcr.io/project/web
+    workspace: web
+    docker:
+      dockerfilePath: Dockerfile.dev
+  - imageName: gcr.io/project/worker
+    workspace: worker
+    bazel:
+      target: //:worker.tar
+  tagPolicy:
+    sha256: {}
+    gitCommit: {}
+  local: {}
+  kaniko: {}
+deploy:
+  helm:
+    releases:
+    - name: web
+      chartPath: chart
+      values:
+        image: gcr.io/project/web
+        sidecar: gcr.io/project/sidecar
+`,
+			expected: []string{
+				"skaffold.yaml:19: only one build type can be set, found local and kaniko",
+				"skaffold.yaml:16: only one tag policy can be set, found gitCommit and sha256",
+				"skaffold.yaml:7: image gcr.io/project/web is built by several artifacts",
+				"skaffold.yaml:10: Dockerfile " + filepath.Join("web", "Dockerfile.dev") + " doesn't exist",
+				"skaffold.yaml:11: kaniko can only build docker artifacts",
+				"skaffold.yaml:12: workspace worker doesn't exist",
+				"skaffold.yaml:27: image gcr.io/project/sidecar isn't built by any artifact",
+			},
+		},
+		{
+			description: "unused image",
+			config: `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/project/web
+    workspace: web
+  - imageName: gcr.io/project/other
+    workspace: web
+deploy:
+  kubectl:
+    manifests:
+    - k8s/*.yaml
+`,
+			expected: []string{"skaffold.yaml:11: image gcr.io/project/other isn't referenced by any manifest"},
+		},
+		{
+			description: "profiles",
+			config: `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/project/web
+    workspace: web
+deploy:
+  kubectl:
+    manifests:
+    - k8s/*.yaml
+profiles:
+- name: gcb
+  build:
+    googleCloudBuild: {}
+    kaniko: {}
+`,
+			expected: []string{"skaffold.yaml:15: only one build type can be set, found googleCloudBuild and kaniko"},
+		},
+		{
+			description: "no deployer",
+			config:      "apiVersion: skaffold/v1alpha3\nkind: Config\n",
+			expected:    []string{"skaffold.yaml: no deployer configured, set one of helm, kubectl or kustomize"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			write(t, tmpDir, "skaffold.yaml", test.config)
+			write(t, tmpDir, filepath.Join("web", "Dockerfile"), "FROM scratch")
+			write(t, tmpDir, filepath.Join("k8s", "pod.yaml"), pod)
+			defer chdir(t, tmpDir)()
+
+			problems, err := Validate("skaffold.yaml")
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, messages(problems))
+		})
+	}
+}
+
+func TestValidateRequires(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	write(t, tmpDir, "skaffold.yaml", `apiVersion: skaffold/v1alpha3
+kind: Config
+requires:
+- path: web
+`)
+	write(t, tmpDir, filepath.Join("web", "skaffold.yaml"), `apiVersion: skaffold/v1alpha3
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/project/web
+    workspace: missing
+deploy:
+  kubectl: {}
+`)
+	write(t, tmpDir, filepath.Join("web", "k8s", "pod.yaml"), pod)
+	defer chdir(t, tmpDir)()
+
+	problems, err := Validate("skaffold.yaml")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{
+		filepath.Join("web", "skaffold.yaml") + ":6: workspace " + filepath.Join("web", "missing") + " doesn't exist",
+	}, messages(problems))
+}
+
+func messages(problems []Problem) []string {
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.String())
+	}
+	return messages
+}
+
+func write(t *testing.T, dir, file, content string) {
+	path := filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func chdir(t *testing.T, dir string) func() {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return func() { os.Chdir(wd) }
+}
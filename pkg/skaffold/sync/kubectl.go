@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+// KubectlSyncer syncs files into running containers using the kubectl CLI.
+type KubectlSyncer struct {
+	kubeContext string
+	labels      map[string]string
+	labeledPods bool
+}
+
+// NewKubectlSyncer returns a new KubectlSyncer for a given kube context. Only the pods
+// deployed with the given labels are synced. labeledPods tells whether the pod templates
+// of the deployed objects are labeled.
+func NewKubectlSyncer(kubeContext string, labels map[string]string, labeledPods bool) *KubectlSyncer {
+	return &KubectlSyncer{
+		kubeContext: kubeContext,
+		labels:      labels,
+		labeledPods: labeledPods,
+	}
+}
+
+// Sync copies and deletes the files of a sync Item in every running container
+// whose image is the Item's image, in the pods deployed in the Item's namespaces.
+func (k *KubectlSyncer) Sync(ctx context.Context, s *Item) error {
+	if len(s.Copy) > 0 {
+		logrus.Infoln("Copying files:", s.Copy, "to", s.Image)
+
+		if err := k.perform(ctx, s.Image, s.Namespaces, s.Copy, k.copyFileCmd); err != nil {
+			return errors.Wrap(err, "copying files")
+		}
+	}
+
+	if len(s.Delete) > 0 {
+		logrus.Infoln("Deleting files:", s.Delete, "from", s.Image)
+
+		if err := k.perform(ctx, s.Image, s.Namespaces, s.Delete, k.deleteFileCmd); err != nil {
+			return errors.Wrap(err, "deleting files")
+		}
+	}
+
+	return nil
+}
+
+func (k *KubectlSyncer) perform(ctx context.Context, image string, namespaces []string, files map[string]string, cmdFn func(context.Context, v1.Pod, v1.Container, string, string) *exec.Cmd) error {
+	client, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+
+	deployed := kubernetes.NewDeployedPods(client, nil, k.labels, k.labeledPods)
+
+	synced := false
+	for _, namespace := range namespaces {
+		pods, err := client.CoreV1().Pods(namespace).List(deployed.ListOptions())
+		if err != nil {
+			return errors.Wrapf(err, "getting pods in namespace %s", namespace)
+		}
+
+		for _, p := range pods.Items {
+			if p.Status.Phase != v1.PodRunning || !deployed.Select(&p) {
+				continue
+			}
+
+			for _, c := range p.Spec.Containers {
+				if c.Image != image {
+					continue
+				}
+
+				for src, dst := range files {
+					if err := util.RunCmd(cmdFn(ctx, p, c, src, dst)); err != nil {
+						return errors.Wrapf(err, "syncing %s in pod %s", src, p.Name)
+					}
+				}
+				synced = true
+			}
+		}
+	}
+
+	if !synced {
+		return fmt.Errorf("didn't find any running container for image %s", image)
+	}
+
+	return nil
+}
+
+func (k *KubectlSyncer) copyFileCmd(ctx context.Context, p v1.Pod, c v1.Container, src, dst string) *exec.Cmd {
+	return exec.CommandContext(ctx, "kubectl", "--context", k.kubeContext, "cp", src, fmt.Sprintf("%s/%s:%s", p.Namespace, p.Name, dst), "-c", c.Name)
+}
+
+func (k *KubectlSyncer) deleteFileCmd(ctx context.Context, p v1.Pod, c v1.Container, src, dst string) *exec.Cmd {
+	return exec.CommandContext(ctx, "kubectl", "--context", k.kubeContext, "exec", p.Name, "--namespace", p.Namespace, "-c", c.Name, "--", "rm", "-rf", dst)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func runningPod(namespace, name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(namespace + "/" + name), Labels: labels},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app", Image: "image:tag"}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestKubectlSync(t *testing.T) {
+	runLabels := map[string]string{"skaffold-run-id": "abc"}
+
+	var tests = []struct {
+		description string
+		pods        []runtime.Object
+		namespaces  []string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "only the labeled pods of the deployed namespaces",
+			pods: []runtime.Object{
+				runningPod("ns", "deployed", runLabels),
+				runningPod("ns", "not-deployed", nil),
+				runningPod("other", "other-run", map[string]string{"skaffold-run-id": "def"}),
+				runningPod("other", "deployed", runLabels),
+			},
+			namespaces: []string{"ns"},
+			expected:   "kubectl --context kubecontext cp src ns/deployed:dst -c app",
+		},
+		{
+			description: "no pod in the deployed namespaces",
+			pods: []runtime.Object{
+				runningPod("other", "deployed", runLabels),
+			},
+			namespaces: []string{"ns"},
+			shouldErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c func() (k8s.Interface, error)) { kubernetes.Client = c }(kubernetes.Client)
+			kubernetes.Client = func() (k8s.Interface, error) {
+				return fake.NewSimpleClientset(test.pods...), nil
+			}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmd(test.expected, nil)
+
+			syncer := NewKubectlSyncer("kubecontext", runLabels, true)
+			err := syncer.Sync(context.Background(), &Item{
+				Image:      "image:tag",
+				Copy:       map[string]string{"src": "dst"},
+				Namespaces: test.namespaces,
+			})
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+	"github.com/pkg/errors"
+)
+
+// Syncer copies files into running containers.
+type Syncer interface {
+	Sync(context.Context, *Item) error
+}
+
+// Item is a set of files to copy into, or to delete from, the containers
+// running a given image.
+type Item struct {
+	Artifact *v1alpha3.Artifact
+	Image    string
+	Copy     map[string]string
+	Delete   map[string]string
+
+	// Namespaces are where the containers are looked for.
+	Namespaces []string
+}
+
+// NewItem returns the files that can be synced for a given set of file events.
+// It returns nil if the artifact has no sync rules or if at least one of the
+// changed files isn't covered by those rules, in which case the artifact
+// should be rebuilt.
+func NewItem(a *v1alpha3.Artifact, e watch.Events, builds []build.Artifact) (*Item, error) {
+	if len(a.Sync) == 0 {
+		return nil, nil
+	}
+
+	tag := latestTag(a.ImageName, builds)
+	if tag == "" {
+		return nil, fmt.Errorf("could not find latest tag for image %s in builds: %v", a.ImageName, builds)
+	}
+
+	toCopy, err := intersect(a.Workspace, a.Sync, append(e.Added, e.Modified...))
+	if err != nil {
+		return nil, errors.Wrap(err, "intersecting sync map and added, modified files")
+	}
+	if toCopy == nil {
+		return nil, nil
+	}
+
+	toDelete, err := intersect(a.Workspace, a.Sync, e.Deleted)
+	if err != nil {
+		return nil, errors.Wrap(err, "intersecting sync map and deleted files")
+	}
+	if toDelete == nil {
+		return nil, nil
+	}
+
+	return &Item{
+		Artifact: a,
+		Image:    tag,
+		Copy:     toCopy,
+		Delete:   toDelete,
+	}, nil
+}
+
+func latestTag(image string, builds []build.Artifact) string {
+	for _, build := range builds {
+		if build.ImageName == image {
+			return build.Tag
+		}
+	}
+	return ""
+}
+
+// intersect maps each file to its destination in the container.
+// It returns nil if one of the files doesn't match any sync pattern.
+func intersect(workspace string, syncMap map[string]string, files []string) (map[string]string, error) {
+	ret := map[string]string{}
+
+	for _, f := range files {
+		relPath, err := filepath.Rel(workspace, f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "changed file %s can't be found relative to workspace %s", f, workspace)
+		}
+
+		dst, match, err := destination(syncMap, relPath)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			return nil, nil
+		}
+
+		ret[f] = dst
+	}
+
+	return ret, nil
+}
+
+// destination maps a file to its path in the container. The file keeps its path
+// relative to the static prefix of the pattern it matches, so that `src/**/*.js`
+// syncs `src/a/x.js` to `<dst>/a/x.js`.
+func destination(syncMap map[string]string, relPath string) (string, bool, error) {
+	relPath = filepath.ToSlash(relPath)
+
+	for pattern, dst := range syncMap {
+		pattern = filepath.ToSlash(pattern)
+
+		match, err := matchPattern(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+		if err != nil {
+			return "", false, errors.Wrapf(err, "matching %s to sync pattern %s", relPath, pattern)
+		}
+		if match {
+			// Container paths always use forward slashes.
+			return path.Join(dst, strings.TrimPrefix(relPath, staticPrefix(pattern))), true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// matchPattern matches path segments against pattern segments.
+// A `**` segment matches any number of directories.
+func matchPattern(pattern, segments []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(segments) == 0, nil
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			match, err := matchPattern(pattern[1:], segments[i:])
+			if err != nil || match {
+				return match, err
+			}
+		}
+		return false, nil
+	}
+
+	if len(segments) == 0 {
+		// Still validate the rest of the pattern
+		_, err := path.Match(pattern[0], "")
+		return false, err
+	}
+
+	match, err := path.Match(pattern[0], segments[0])
+	if err != nil || !match {
+		return false, err
+	}
+	return matchPattern(pattern[1:], segments[1:])
+}
+
+// staticPrefix returns the leading directories of a pattern that contain no wildcard.
+func staticPrefix(pattern string) string {
+	segments := strings.Split(pattern, "/")
+
+	var prefix string
+	for _, segment := range segments[:len(segments)-1] {
+		if strings.ContainsAny(segment, `*?[\`) {
+			break
+		}
+		prefix += segment + "/"
+	}
+
+	return prefix
+}
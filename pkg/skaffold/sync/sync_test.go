@@ -0,0 +1,253 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha3"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestNewSyncItem(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifact    *v1alpha3.Artifact
+		evt         watch.Events
+		builds      []build.Artifact
+		shouldErr   bool
+		expected    *Item
+	}{
+		{
+			description: "match copy",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"*.html": "/static",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Added: []string{"index.html"},
+			},
+			expected: &Item{
+				Image: "test:123",
+				Copy: map[string]string{
+					"index.html": "/static/index.html",
+				},
+				Delete: map[string]string{},
+			},
+		},
+		{
+			description: "match copy in subdirectory",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"node/*.js": "/app",
+				},
+				Workspace: "workspace",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Modified: []string{filepath.Join("workspace", "node", "server.js")},
+			},
+			expected: &Item{
+				Image: "test:123",
+				Copy: map[string]string{
+					filepath.Join("workspace", "node", "server.js"): "/app/server.js",
+				},
+				Delete: map[string]string{},
+			},
+		},
+		{
+			description: "keep the directories below the static prefix",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"src/*/*.js": "/app",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Modified: []string{filepath.Join("src", "a", "x.js"), filepath.Join("src", "b", "x.js")},
+			},
+			expected: &Item{
+				Image: "test:123",
+				Copy: map[string]string{
+					filepath.Join("src", "a", "x.js"): "/app/a/x.js",
+					filepath.Join("src", "b", "x.js"): "/app/b/x.js",
+				},
+				Delete: map[string]string{},
+			},
+		},
+		{
+			description: "match any directory with **",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"static/**/*.css": "/www",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Modified: []string{filepath.Join("static", "main.css"), filepath.Join("static", "themes", "dark", "main.css")},
+			},
+			expected: &Item{
+				Image: "test:123",
+				Copy: map[string]string{
+					filepath.Join("static", "main.css"):                   "/www/main.css",
+					filepath.Join("static", "themes", "dark", "main.css"): "/www/themes/dark/main.css",
+				},
+				Delete: map[string]string{},
+			},
+		},
+		{
+			description: "no sync map",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Workspace: ".",
+			},
+			evt: watch.Events{
+				Added: []string{"main.go"},
+			},
+		},
+		{
+			description: "sync all or nothing",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"*.html": "/static",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Added:    []string{"index.html"},
+				Modified: []string{"main.go"},
+			},
+		},
+		{
+			description: "match delete",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"*.html": "/static",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Deleted: []string{"index.html"},
+			},
+			expected: &Item{
+				Image: "test:123",
+				Copy:  map[string]string{},
+				Delete: map[string]string{
+					"index.html": "/static/index.html",
+				},
+			},
+		},
+		{
+			description: "no tag for image",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "notbuildyet",
+				Sync: map[string]string{
+					"*.html": "/static",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Added: []string{"index.html"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "bad pattern",
+			artifact: &v1alpha3.Artifact{
+				ImageName: "test",
+				Sync: map[string]string{
+					"[": "/static",
+				},
+				Workspace: ".",
+			},
+			builds: []build.Artifact{
+				{
+					ImageName: "test",
+					Tag:       "test:123",
+				},
+			},
+			evt: watch.Events{
+				Added: []string{"index.html"},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if test.expected != nil {
+				test.expected.Artifact = test.artifact
+			}
+
+			actual, err := NewItem(test.artifact, test.evt, test.builds)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, actual)
+		})
+	}
+}
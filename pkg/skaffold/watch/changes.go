@@ -18,6 +18,7 @@ package watch
 
 import (
 	"os"
+	"sort"
 
 	"github.com/pkg/errors"
 )
@@ -41,30 +42,43 @@ func stat(deps func() ([]string, error)) (fileMap, error) {
 	return fm, nil
 }
 
-func hasChanged(prev, curr fileMap) bool {
-	if len(prev) != len(curr) {
-		return true
-	}
+// Events are the file changes detected for a component.
+type Events struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// HasChanged returns true if at least one file was added, modified or deleted.
+func (e Events) HasChanged() bool {
+	return len(e.Added) > 0 || len(e.Modified) > 0 || len(e.Deleted) > 0
+}
+
+func events(prev, curr fileMap) Events {
+	e := Events{}
 
 	for k, prevV := range prev {
 		currV, ok := curr[k]
 		if !ok {
-			// Deleted
-			return true
+			e.Deleted = append(e.Deleted, k)
+			continue
 		}
 		if prevV.ModTime() != currV.ModTime() {
 			// Ignore directory time changes
 			if !currV.IsDir() && !prevV.IsDir() {
-				return true
+				e.Modified = append(e.Modified, k)
 			}
 		}
 	}
 	for k := range curr {
 		if _, ok := prev[k]; !ok {
-			// Created
-			return true
+			e.Added = append(e.Added, k)
 		}
 	}
 
-	return false
+	sort.Strings(e.Added)
+	sort.Strings(e.Modified)
+	sort.Strings(e.Deleted)
+
+	return e
 }
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// fsNotifier is a notifier backed by fsnotify, that relies on inotify, kqueue
+// or ReadDirectoryChangesW depending on the platform.
+type fsNotifier struct {
+	watcher *fsnotify.Watcher
+	events  chan string
+	errors  chan error
+	done    chan struct{}
+}
+
+func newOSNotifier() (notifier, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating file watcher")
+	}
+
+	n := &fsNotifier{
+		watcher: watcher,
+		events:  make(chan string),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+	}
+	go n.forward()
+
+	return n, nil
+}
+
+func (n *fsNotifier) Add(dir string) error {
+	return n.watcher.Add(dir)
+}
+
+func (n *fsNotifier) Remove(dir string) error {
+	return n.watcher.Remove(dir)
+}
+
+func (n *fsNotifier) Events() <-chan string { return n.events }
+
+func (n *fsNotifier) Errors() <-chan error { return n.errors }
+
+func (n *fsNotifier) Close() error {
+	close(n.done)
+	return n.watcher.Close()
+}
+
+// forward sends the path of each event until the notifier is closed.
+// A queue overflow is reported as an empty path.
+func (n *fsNotifier) forward() {
+	for {
+		select {
+		case <-n.done:
+			return
+		case e, ok := <-n.watcher.Events:
+			if !ok {
+				return
+			}
+			n.sendEvent(e.Name)
+		case err, ok := <-n.watcher.Errors:
+			if !ok {
+				return
+			}
+			if err == fsnotify.ErrEventOverflow {
+				n.sendEvent("")
+				continue
+			}
+			select {
+			case n.errors <- err:
+			case <-n.done:
+			}
+		}
+	}
+}
+
+func (n *fsNotifier) sendEvent(path string) {
+	select {
+	case n.events <- path:
+	case <-n.done:
+	}
+}
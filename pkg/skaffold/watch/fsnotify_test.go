@@ -0,0 +1,50 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestFSNotifier(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	n, err := newOSNotifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	err = n.Add(tmpDir)
+	testutil.CheckError(t, false, err)
+
+	file := write(t, tmpDir, ".", "new.txt")
+
+	select {
+	case path := <-n.Events():
+		testutil.CheckErrorAndDeepEqual(t, false, nil, filepath.Join(tmpDir, "new.txt"), path)
+	case err := <-n.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for an event on %s", file)
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// notifyDelay is the time to wait for more file events before
+// recomputing the dependencies of the components that changed.
+const notifyDelay = 100 * time.Millisecond
+
+// notifier receives file system events for a set of directories.
+// An empty path on the Events channel means that some events were lost.
+type notifier interface {
+	Add(dir string) error
+	Remove(dir string) error
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
+// For testing
+var newNotifier = newOSNotifier
+
+type notifyWatcher struct {
+	components []*component
+	dirs       []map[string]bool
+}
+
+// NewNotifyWatcher creates a new Watcher that relies on file system
+// notifications to detect changes. Only the components whose directories
+// changed have their dependencies recomputed. It falls back to polling if
+// notifications are not supported.
+func NewNotifyWatcher() Watcher {
+	return &notifyWatcher{}
+}
+
+// Register adds a new component to the watch list.
+func (w *notifyWatcher) Register(deps func() ([]string, error), onChange func(Events)) error {
+	state, err := stat(deps)
+	if err != nil {
+		return errors.Wrap(err, "listing files")
+	}
+
+	w.components = append(w.components, &component{
+		deps:     deps,
+		onChange: onChange,
+		state:    state,
+	})
+	w.dirs = append(w.dirs, directories(state))
+	return nil
+}
+
+// Run watches files until the context is cancelled or an error occurs.
+// pollInterval is only used when falling back to polling.
+func (w *notifyWatcher) Run(ctx context.Context, pollInterval time.Duration, onChange func() error) error {
+	n, err := newNotifier()
+	if err != nil {
+		logrus.Warnf("File notifications are not available, falling back to polling: %s", err)
+
+		polling := watchList(w.components)
+		return polling.Run(ctx, pollInterval, onChange)
+	}
+	defer n.Close()
+
+	for _, dirs := range w.dirs {
+		if err := addAll(n, dirs); err != nil {
+			return err
+		}
+	}
+
+	dirty := map[int]bool{}
+	var flush <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-n.Errors():
+			return errors.Wrap(err, "watching files")
+		case path := <-n.Events():
+			for i, dirs := range w.dirs {
+				if path == "" || dirs[path] || dirs[filepath.Dir(path)] {
+					dirty[i] = true
+				}
+			}
+			if len(dirty) > 0 && flush == nil {
+				flush = time.After(notifyDelay)
+			}
+		case <-flush:
+			flush = nil
+
+			changed := 0
+			for i := range dirty {
+				component := w.components[i]
+
+				state, err := stat(component.deps)
+				if err != nil {
+					return errors.Wrap(err, "listing files")
+				}
+
+				if e := events(component.state, state); e.HasChanged() {
+					component.onChange(e)
+					component.state = state
+					changed++
+
+					dirs := directories(state)
+					if err := addAll(n, dirs); err != nil {
+						return err
+					}
+					previous := w.dirs[i]
+					w.dirs[i] = dirs
+					w.removeUnused(n, previous)
+				}
+			}
+			dirty = map[int]bool{}
+
+			if changed > 0 {
+				if err := onChange(); err != nil {
+					return errors.Wrap(err, "calling final callback")
+				}
+			}
+		}
+	}
+}
+
+func addAll(n notifier, dirs map[string]bool) error {
+	for dir := range dirs {
+		if err := n.Add(dir); err != nil {
+			if os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
+			return errors.Wrapf(err, "watching directory %s", dir)
+		}
+	}
+	return nil
+}
+
+// removeUnused stops watching the directories that no component depends on anymore.
+func (w *notifyWatcher) removeUnused(n notifier, previous map[string]bool) {
+	for dir := range previous {
+		if !w.watches(dir) {
+			// The watch is already gone if the directory was deleted.
+			if err := n.Remove(dir); err != nil {
+				logrus.Debugf("Unwatching directory %s: %s", dir, err)
+			}
+		}
+	}
+}
+
+func (w *notifyWatcher) watches(dir string) bool {
+	for _, dirs := range w.dirs {
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// directories lists the directories to watch for a set of files:
+// their parent directories and, for relative paths, every ancestor
+// up to the current directory so that new sub-directories are seen.
+func directories(state fileMap) map[string]bool {
+	dirs := map[string]bool{}
+
+	for path, info := range state {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+
+		for !dirs[dir] {
+			dirs[dir] = true
+
+			parent := filepath.Dir(dir)
+			if filepath.IsAbs(dir) || parent == dir || filepath.Base(parent) == ".." {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	return dirs
+}
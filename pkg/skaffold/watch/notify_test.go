@@ -0,0 +1,217 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeNotifier struct {
+	dirs   map[string]bool
+	events chan string
+}
+
+func (f *fakeNotifier) Add(dir string) error    { f.dirs[dir] = true; return nil }
+func (f *fakeNotifier) Remove(dir string) error { delete(f.dirs, dir); return nil }
+func (f *fakeNotifier) Events() <-chan string   { return f.events }
+func (f *fakeNotifier) Errors() <-chan error    { return nil }
+func (f *fakeNotifier) Close() error            { return nil }
+
+func TestDirectories(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	state := fileMap{}
+	for _, path := range []string{"Dockerfile", filepath.Join("src", "main", "app.go"), filepath.Join("..", "other", "lib.go")} {
+		state[path] = fileInfo(t, tmpDir, false)
+	}
+	state[filepath.Join(tmpDir, "file")] = fileInfo(t, tmpDir, false)
+	state["static"] = fileInfo(t, tmpDir, true)
+
+	expected := map[string]bool{
+		".":                          true,
+		"src":                        true,
+		filepath.Join("src", "main"): true,
+		filepath.Join("..", "other"): true,
+		"static":                     true,
+		tmpDir:                       true,
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, directories(state))
+}
+
+func TestNotifyWatcherOnlyChecksDirtyComponents(t *testing.T) {
+	n := &fakeNotifier{
+		dirs:   map[string]bool{},
+		events: make(chan string),
+	}
+	prev := newNotifier
+	newNotifier = func() (notifier, error) { return n, nil }
+	defer func() { newNotifier = prev }()
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	frontend := write(t, tmpDir, "frontend", "index.html")
+	backend := write(t, tmpDir, "backend", "main.go")
+
+	var frontendListed, backendListed int
+	var frontendEvents []Events
+
+	w := NewNotifyWatcher()
+	w.Register(func() ([]string, error) {
+		frontendListed++
+		return []string{frontend}, nil
+	}, func(e Events) { frontendEvents = append(frontendEvents, e) })
+	w.Register(func() ([]string, error) {
+		backendListed++
+		return []string{backend}, nil
+	}, func(Events) { t.Error("backend shouldn't have changed") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx, time.Hour, func() error {
+			cancel()
+			return nil
+		})
+	}()
+
+	touch(t, frontend)
+	n.events <- frontend
+
+	select {
+	case err := <-done:
+		testutil.CheckError(t, false, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for changes")
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Events{{Modified: []string{frontend}}}, frontendEvents)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, frontendListed)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, backendListed)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, n.dirs[filepath.Dir(backend)])
+}
+
+func TestNotifyWatcherRemovesUnusedDirectories(t *testing.T) {
+	n := &fakeNotifier{
+		dirs:   map[string]bool{},
+		events: make(chan string),
+	}
+	prev := newNotifier
+	newNotifier = func() (notifier, error) { return n, nil }
+	defer func() { newNotifier = prev }()
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	oldFile := write(t, tmpDir, "old", "main.go")
+	newFile := write(t, tmpDir, "new", "main.go")
+
+	deps := []string{oldFile}
+
+	w := NewNotifyWatcher()
+	w.Register(func() ([]string, error) { return deps, nil }, func(Events) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx, time.Hour, func() error {
+			cancel()
+			return nil
+		})
+	}()
+
+	deps = []string{newFile}
+	n.events <- oldFile
+
+	select {
+	case err := <-done:
+		testutil.CheckError(t, false, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for changes")
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, n.dirs[filepath.Dir(oldFile)])
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, n.dirs[filepath.Dir(newFile)])
+}
+
+func TestNotifyWatcherFallsBackToPolling(t *testing.T) {
+	prev := newNotifier
+	newNotifier = func() (notifier, error) { return nil, errors.New("not supported") }
+	defer func() { newNotifier = prev }()
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	file := write(t, tmpDir, "app", "main.go")
+
+	w := NewNotifyWatcher()
+	w.Register(func() ([]string, error) { return []string{file}, nil }, func(Events) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx, 10*time.Millisecond, func() error {
+			cancel()
+			return nil
+		})
+	}()
+
+	touch(t, file)
+
+	select {
+	case err := <-done:
+		testutil.CheckError(t, false, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for changes")
+	}
+}
+
+func write(t *testing.T, root, dir, file string) string {
+	path := filepath.Join(root, dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func touch(t *testing.T, path string) {
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileInfo(t *testing.T, path string, dir bool) os.FileInfo {
+	if !dir {
+		path = write(t, path, "files", "file")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
@@ -28,7 +28,7 @@ type Factory func() Watcher
 
 // Watcher monitors files changes for multiples components.
 type Watcher interface {
-	Register(deps func() ([]string, error), onChange func()) error
+	Register(deps func() ([]string, error), onChange func(Events)) error
 	Run(ctx context.Context, pollInterval time.Duration, onChange func() error) error
 }
 
@@ -41,12 +41,12 @@ func NewWatcher() Watcher {
 
 type component struct {
 	deps     func() ([]string, error)
-	onChange func()
+	onChange func(Events)
 	state    fileMap
 }
 
 // Register adds a new component to the watch list.
-func (w *watchList) Register(deps func() ([]string, error), onChange func()) error {
+func (w *watchList) Register(deps func() ([]string, error), onChange func(Events)) error {
 	state, err := stat(deps)
 	if err != nil {
 		return errors.Wrap(err, "listing files")
@@ -78,8 +78,8 @@ func (w *watchList) Run(ctx context.Context, pollInterval time.Duration, onChang
 					return errors.Wrap(err, "listing files")
 				}
 
-				if hasChanged(component.state, state) {
-					component.onChange()
+				if e := events(component.state, state); e.HasChanged() {
+					component.onChange(e)
 					component.state = state
 					changed++
 				}